@@ -0,0 +1,125 @@
+// Package apperrors defines the error kinds shared by the services and the
+// HTTP layer. Services return errors of a kind, the error middleware turns the
+// kind into a status code, so handlers do not need to know every error.
+package apperrors
+
+import "errors"
+
+// Error kinds. Each kind is rendered with one HTTP status code.
+var (
+	ErrBadRequest           = errors.New("bad request")
+	ErrValidation           = errors.New("validation failed")
+	ErrUnauthorized         = errors.New("unauthorized")
+	ErrForbidden            = errors.New("forbidden")
+	ErrNotFound             = errors.New("not found")
+	ErrConflict             = errors.New("conflict")
+	ErrPreconditionFailed   = errors.New("precondition failed")
+	ErrUnsupportedMediaType = errors.New("unsupported media type")
+	ErrLocked               = errors.New("locked")
+	ErrPreconditionRequired = errors.New("precondition required")
+	ErrTooManyRequests      = errors.New("too many requests")
+)
+
+// Field error codes used by the constructors in this package. Validation
+// rules add their own codes, for example "length" or "pattern".
+const (
+	CodeRequired = "required"
+	CodeInvalid  = "invalid"
+	CodeTaken    = "taken"
+)
+
+// FieldError describes why one request field was rejected. Code is a stable
+// machine readable reason, Params holds the values the rule was checked
+// against, for example the minimum length.
+type FieldError struct {
+	Field   string                 `json:"field"`
+	Code    string                 `json:"code"`
+	Message string                 `json:"message"`
+	Params  map[string]interface{} `json:"params,omitempty"`
+}
+
+// Error is an error of a kind with a message that is safe to show to clients.
+// Fields lists the offending request fields, Err is an optional cause.
+type Error struct {
+	Kind    error
+	Message string
+	Fields  []FieldError
+	Err     error
+}
+
+// New returns an error of the given kind.
+func New(kind error, message string) *Error {
+	return &Error{Kind: kind, Message: message}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Unwrap exposes the kind and the cause to errors.Is and errors.As.
+func (e *Error) Unwrap() []error {
+	if e.Err == nil {
+		return []error{e.Kind}
+	}
+	return []error{e.Kind, e.Err}
+}
+
+// BadRequest returns an error for a request that cannot be processed at all,
+// for example a body that is not valid JSON.
+func BadRequest(message string) *Error {
+	return New(ErrBadRequest, message)
+}
+
+// Validation returns an error for request fields with invalid values.
+// Callers that collect several field errors pass all of them at once.
+func Validation(message string, fields ...FieldError) *Error {
+	return &Error{Kind: ErrValidation, Message: message, Fields: fields}
+}
+
+// Invalid returns a validation error for a single field.
+func Invalid(field, message string) *Error {
+	return Validation(message, FieldError{Field: field, Code: CodeInvalid, Message: message})
+}
+
+// Unauthorized returns an error for a missing or invalid credential.
+func Unauthorized(message string) *Error {
+	return New(ErrUnauthorized, message)
+}
+
+// Forbidden returns an error for a caller that may not perform the action.
+func Forbidden(message string) *Error {
+	return New(ErrForbidden, message)
+}
+
+// NotFound returns an error for a resource that does not exist.
+func NotFound(message string) *Error {
+	return New(ErrNotFound, message)
+}
+
+// Conflict returns an error for a request that conflicts with the current
+// state. fields name the values that caused it, for example a taken email.
+func Conflict(message string, fields ...string) *Error {
+	err := New(ErrConflict, message)
+	for _, field := range fields {
+		err.Fields = append(err.Fields, FieldError{Field: field, Code: CodeTaken, Message: message})
+	}
+	return err
+}
+
+// KindOf returns the kind of err, or nil for errors without a kind.
+func KindOf(err error) error {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr.Kind
+	}
+	return nil
+}
+
+// Fields returns the field errors carried by err.
+func Fields(err error) []FieldError {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr.Fields
+	}
+	return nil
+}
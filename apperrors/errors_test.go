@@ -0,0 +1,30 @@
+package apperrors
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestError_KindSurvivesWrappingUnit tests that the kind and fields are found through wrapped errors
+func TestError_KindSurvivesWrappingUnit(t *testing.T) {
+	cause := errors.New("duplicate key")
+	err := fmt.Errorf("save: %w", &Error{Kind: ErrConflict, Message: "email already taken", Fields: []FieldError{{Field: "email"}}, Err: cause})
+
+	assert.ErrorIs(t, err, ErrConflict)
+	assert.ErrorIs(t, err, cause)
+	assert.Equal(t, ErrConflict, KindOf(err))
+	assert.Equal(t, "email", Fields(err)[0].Field)
+	assert.Nil(t, KindOf(cause))
+}
+
+// TestInvalid_NamesFieldUnit tests that a single field validation error carries its field
+func TestInvalid_NamesFieldUnit(t *testing.T) {
+	err := Invalid("age", "age must be greater than 15")
+
+	assert.ErrorIs(t, err, ErrValidation)
+	assert.Equal(t, "age must be greater than 15", err.Error())
+	assert.Equal(t, []FieldError{{Field: "age", Code: CodeInvalid, Message: "age must be greater than 15"}}, err.Fields)
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -15,6 +17,85 @@ type Config struct {
 	DBPassword string
 	DBName     string
 	JWTSecret  string
+
+	JWTSigningAlgorithm string
+	JWTKeyRotation      time.Duration
+	JWTKeyGracePeriod   time.Duration
+	JWTKeyCheckInterval time.Duration
+	JWTIssuer           string
+	JWTAudience         string
+	JWTClockSkew        time.Duration
+
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+
+	RevocationStore         string
+	RevocationPruneInterval time.Duration
+
+	BootstrapAdminUsername string
+
+	MigrateOnStart bool
+
+	PasswordHashAlgorithm string
+	BcryptCost            int
+	Argon2Memory          uint32
+	Argon2Iterations      uint32
+	Argon2Parallelism     uint8
+
+	MFAIssuer       string
+	MFAChallengeTTL time.Duration
+
+	AppBaseURL       string
+	PasswordResetTTL time.Duration
+
+	RequireVerifiedEmail       bool
+	LoginIdentifiers           []string
+	NewUserStatus              string
+	EmailVerificationTTL       time.Duration
+	VerificationResendInterval time.Duration
+	EmailChangeTTL             time.Duration
+	PhoneVerificationTTL       time.Duration
+
+	LoginFreeAttempts    int
+	LoginMaxAttempts     int
+	LoginBaseDelay       time.Duration
+	LoginMaxDelay        time.Duration
+	LoginLockoutDuration time.Duration
+	AuthRateLimit        int
+	AuthRateLimitWindow  time.Duration
+
+	PersonalAccessTokenMaxTTL time.Duration
+
+	RequireIfMatch bool
+
+	EmailAllowedDomains    []string
+	EmailDeniedDomains     []string
+	EmailBlockDisposable   bool
+	EmailDisposableFile    string
+	EmailDisposableRefresh time.Duration
+	EmailCheckMX           bool
+	EmailTenantPolicies    string
+	TenantHeader           string
+
+	PasswordMinLength          int
+	PasswordMaxLength          int
+	PasswordMinClasses         int
+	PasswordMinScore           int
+	PasswordRejectPersonalInfo bool
+	PasswordBreachedDir        string
+	PasswordHistory            int
+	PasswordMaxAge             time.Duration
+
+	MailDriver   string
+	MailFrom     string
+	MailDropDir  string
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+
+	SmsDriver  string
+	SmsDropDir string
 }
 
 var AppConfig Config
@@ -24,6 +105,60 @@ func LoadConfig() {
 	viper.SetConfigType("env") 
 	viper.AutomaticEnv()       
 
+	viper.SetDefault("JWT_SIGNING_ALG", "RS256")
+	viper.SetDefault("JWT_KEY_ROTATION_INTERVAL", "720h")
+	viper.SetDefault("JWT_KEY_GRACE_PERIOD", "24h")
+	viper.SetDefault("JWT_KEY_CHECK_INTERVAL", "1m")
+	viper.SetDefault("JWT_ISSUER", "gin-user-app")
+	viper.SetDefault("JWT_AUDIENCE", "gin-user-app")
+	viper.SetDefault("JWT_CLOCK_SKEW", "30s")
+	viper.SetDefault("ACCESS_TOKEN_TTL", "15m")
+	viper.SetDefault("REFRESH_TOKEN_TTL", "720h")
+	viper.SetDefault("REVOCATION_STORE", "postgres")
+	viper.SetDefault("REVOCATION_PRUNE_INTERVAL", "10m")
+	viper.SetDefault("MIGRATE_ON_START", true)
+	viper.SetDefault("PASSWORD_HASH_ALGORITHM", "argon2id")
+	viper.SetDefault("BCRYPT_COST", 10)
+	viper.SetDefault("ARGON2_MEMORY_KIB", 19456)
+	viper.SetDefault("ARGON2_ITERATIONS", 2)
+	viper.SetDefault("ARGON2_PARALLELISM", 1)
+	viper.SetDefault("MFA_ISSUER", "gin-user-app")
+	viper.SetDefault("MFA_CHALLENGE_TTL", "5m")
+	viper.SetDefault("APP_BASE_URL", "http://localhost:8080")
+	viper.SetDefault("PASSWORD_RESET_TTL", "30m")
+	viper.SetDefault("REQUIRE_VERIFIED_EMAIL", false)
+	viper.SetDefault("LOGIN_IDENTIFIERS", "username,email")
+	viper.SetDefault("NEW_USER_STATUS", "active")
+	viper.SetDefault("EMAIL_VERIFICATION_TTL", "48h")
+	viper.SetDefault("VERIFICATION_RESEND_INTERVAL", "1m")
+	viper.SetDefault("EMAIL_CHANGE_TTL", "24h")
+	viper.SetDefault("PHONE_VERIFICATION_TTL", "10m")
+	viper.SetDefault("LOGIN_FREE_ATTEMPTS", 3)
+	viper.SetDefault("LOGIN_MAX_ATTEMPTS", 10)
+	viper.SetDefault("LOGIN_BASE_DELAY", "1s")
+	viper.SetDefault("LOGIN_MAX_DELAY", "1m")
+	viper.SetDefault("LOGIN_LOCKOUT_DURATION", "15m")
+	viper.SetDefault("AUTH_RATE_LIMIT", 20)
+	viper.SetDefault("AUTH_RATE_LIMIT_WINDOW", "1m")
+	viper.SetDefault("PERSONAL_ACCESS_TOKEN_MAX_TTL", "8760h")
+	viper.SetDefault("REQUIRE_IF_MATCH", false)
+	viper.SetDefault("EMAIL_BLOCK_DISPOSABLE", true)
+	viper.SetDefault("EMAIL_DISPOSABLE_REFRESH_INTERVAL", "1h")
+	viper.SetDefault("EMAIL_CHECK_MX", false)
+	viper.SetDefault("PASSWORD_MIN_LENGTH", 8)
+	viper.SetDefault("PASSWORD_MAX_LENGTH", 64)
+	viper.SetDefault("PASSWORD_MIN_CLASSES", 0)
+	viper.SetDefault("PASSWORD_MIN_SCORE", 0)
+	viper.SetDefault("PASSWORD_REJECT_PERSONAL_INFO", true)
+	viper.SetDefault("PASSWORD_HISTORY", 0)
+	viper.SetDefault("PASSWORD_MAX_AGE", "0s")
+	viper.SetDefault("MAIL_DRIVER", "file")
+	viper.SetDefault("MAIL_FROM", "no-reply@localhost")
+	viper.SetDefault("MAIL_DROP_DIR", "mail")
+	viper.SetDefault("SMS_DRIVER", "file")
+	viper.SetDefault("SMS_DROP_DIR", "sms")
+	viper.SetDefault("SMTP_PORT", 587)
+
 	if err := viper.ReadInConfig(); err != nil {
 		log.Printf("Warning: .env file not found, trying to read from environment variables")
 	}
@@ -38,6 +173,85 @@ func LoadConfig() {
 		DBPassword: viper.GetString("DB_PASSWORD"),
 		DBName:     viper.GetString("DB_NAME"),
 		JWTSecret:  viper.GetString("JWT_SECRET"),
+
+		JWTSigningAlgorithm: viper.GetString("JWT_SIGNING_ALG"),
+		JWTKeyRotation:      viper.GetDuration("JWT_KEY_ROTATION_INTERVAL"),
+		JWTKeyGracePeriod:   viper.GetDuration("JWT_KEY_GRACE_PERIOD"),
+		JWTKeyCheckInterval: viper.GetDuration("JWT_KEY_CHECK_INTERVAL"),
+		JWTIssuer:           viper.GetString("JWT_ISSUER"),
+		JWTAudience:         viper.GetString("JWT_AUDIENCE"),
+		JWTClockSkew:        viper.GetDuration("JWT_CLOCK_SKEW"),
+
+		AccessTokenTTL:  viper.GetDuration("ACCESS_TOKEN_TTL"),
+		RefreshTokenTTL: viper.GetDuration("REFRESH_TOKEN_TTL"),
+
+		RevocationStore:         viper.GetString("REVOCATION_STORE"),
+		RevocationPruneInterval: viper.GetDuration("REVOCATION_PRUNE_INTERVAL"),
+
+		BootstrapAdminUsername: viper.GetString("BOOTSTRAP_ADMIN_USERNAME"),
+
+		MigrateOnStart: viper.GetBool("MIGRATE_ON_START"),
+
+		PasswordHashAlgorithm: viper.GetString("PASSWORD_HASH_ALGORITHM"),
+		BcryptCost:            viper.GetInt("BCRYPT_COST"),
+		Argon2Memory:          viper.GetUint32("ARGON2_MEMORY_KIB"),
+		Argon2Iterations:      viper.GetUint32("ARGON2_ITERATIONS"),
+		Argon2Parallelism:     uint8(viper.GetUint("ARGON2_PARALLELISM")),
+
+		MFAIssuer:       viper.GetString("MFA_ISSUER"),
+		MFAChallengeTTL: viper.GetDuration("MFA_CHALLENGE_TTL"),
+
+		AppBaseURL:       strings.TrimRight(viper.GetString("APP_BASE_URL"), "/"),
+		PasswordResetTTL: viper.GetDuration("PASSWORD_RESET_TTL"),
+
+		RequireVerifiedEmail:       viper.GetBool("REQUIRE_VERIFIED_EMAIL"),
+		LoginIdentifiers:           splitList(viper.GetString("LOGIN_IDENTIFIERS")),
+		NewUserStatus:              strings.ToLower(strings.TrimSpace(viper.GetString("NEW_USER_STATUS"))),
+		EmailVerificationTTL:       viper.GetDuration("EMAIL_VERIFICATION_TTL"),
+		VerificationResendInterval: viper.GetDuration("VERIFICATION_RESEND_INTERVAL"),
+		EmailChangeTTL:             viper.GetDuration("EMAIL_CHANGE_TTL"),
+		PhoneVerificationTTL:       viper.GetDuration("PHONE_VERIFICATION_TTL"),
+
+		LoginFreeAttempts:    viper.GetInt("LOGIN_FREE_ATTEMPTS"),
+		LoginMaxAttempts:     viper.GetInt("LOGIN_MAX_ATTEMPTS"),
+		LoginBaseDelay:       viper.GetDuration("LOGIN_BASE_DELAY"),
+		LoginMaxDelay:        viper.GetDuration("LOGIN_MAX_DELAY"),
+		LoginLockoutDuration: viper.GetDuration("LOGIN_LOCKOUT_DURATION"),
+		AuthRateLimit:        viper.GetInt("AUTH_RATE_LIMIT"),
+		AuthRateLimitWindow:  viper.GetDuration("AUTH_RATE_LIMIT_WINDOW"),
+
+		PersonalAccessTokenMaxTTL: viper.GetDuration("PERSONAL_ACCESS_TOKEN_MAX_TTL"),
+
+		RequireIfMatch: viper.GetBool("REQUIRE_IF_MATCH"),
+
+		EmailAllowedDomains:    splitList(viper.GetString("EMAIL_ALLOWED_DOMAINS")),
+		EmailDeniedDomains:     splitList(viper.GetString("EMAIL_DENIED_DOMAINS")),
+		EmailBlockDisposable:   viper.GetBool("EMAIL_BLOCK_DISPOSABLE"),
+		EmailDisposableFile:    viper.GetString("EMAIL_DISPOSABLE_DOMAINS_FILE"),
+		EmailDisposableRefresh: viper.GetDuration("EMAIL_DISPOSABLE_REFRESH_INTERVAL"),
+		EmailCheckMX:           viper.GetBool("EMAIL_CHECK_MX"),
+		EmailTenantPolicies:    viper.GetString("EMAIL_TENANT_POLICIES"),
+		TenantHeader:           viper.GetString("TENANT_HEADER"),
+
+		PasswordMinLength:          viper.GetInt("PASSWORD_MIN_LENGTH"),
+		PasswordMaxLength:          viper.GetInt("PASSWORD_MAX_LENGTH"),
+		PasswordMinClasses:         viper.GetInt("PASSWORD_MIN_CLASSES"),
+		PasswordMinScore:           viper.GetInt("PASSWORD_MIN_SCORE"),
+		PasswordRejectPersonalInfo: viper.GetBool("PASSWORD_REJECT_PERSONAL_INFO"),
+		PasswordBreachedDir:        viper.GetString("PASSWORD_BREACHED_DIR"),
+		PasswordHistory:            viper.GetInt("PASSWORD_HISTORY"),
+		PasswordMaxAge:             viper.GetDuration("PASSWORD_MAX_AGE"),
+
+		MailDriver:   viper.GetString("MAIL_DRIVER"),
+		MailFrom:     viper.GetString("MAIL_FROM"),
+		MailDropDir:  viper.GetString("MAIL_DROP_DIR"),
+		SMTPHost:     viper.GetString("SMTP_HOST"),
+		SMTPPort:     viper.GetInt("SMTP_PORT"),
+		SMTPUsername: viper.GetString("SMTP_USERNAME"),
+		SMTPPassword: viper.GetString("SMTP_PASSWORD"),
+
+		SmsDriver:  viper.GetString("SMS_DRIVER"),
+		SmsDropDir: viper.GetString("SMS_DROP_DIR"),
 	}
 
 	// Debugging - tampilkan hasil
@@ -49,11 +263,32 @@ func LoadConfig() {
 	fmt.Println("DB_NAME:", AppConfig.DBName)
 	fmt.Println("JWT_SECRET:", AppConfig.JWTSecret)
 
-	// Cek apakah JWT_SECRET kosong
-	if AppConfig.JWTSecret == "" {
+	// JWT_SECRET hanya wajib untuk HS256, RS256/EdDSA memakai key pair di database
+	if AppConfig.JWTSigningAlgorithm == "HS256" && AppConfig.JWTSecret == "" {
 		log.Fatalf("ERROR: JWT_SECRET is missing or empty!")
 	}
 
+	// User baru hanya bisa mulai active atau pending (aktif setelah verifikasi email)
+	if AppConfig.NewUserStatus != "active" && AppConfig.NewUserStatus != "pending" {
+		log.Fatalf("ERROR: NEW_USER_STATUS must be active or pending, got %q", AppConfig.NewUserStatus)
+	}
+
+	// Key lama harus tetap bisa memverifikasi access token terakhir yang ditandatanganinya
+	if AppConfig.JWTKeyGracePeriod < AppConfig.AccessTokenTTL+AppConfig.JWTClockSkew {
+		AppConfig.JWTKeyGracePeriod = AppConfig.AccessTokenTTL + AppConfig.JWTClockSkew
+	}
+
 	// Set env variables (optional)
 	os.Setenv("JWT_SECRET", AppConfig.JWTSecret)
 }
+
+// splitList memecah daftar yang dipisah koma, item kosong dibuang
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
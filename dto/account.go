@@ -0,0 +1,45 @@
+package dto
+
+// UpdateProfileDTO updates the profile of the authenticated user. Only the
+// fields that are present are changed.
+type UpdateProfileDTO struct {
+	FirstName *string `json:"first_name"`
+	LastName  *string `json:"last_name"`
+	Age       *int    `json:"age"`
+}
+
+// ChangePasswordRequestDTO sets a new password for the authenticated user
+type ChangePasswordRequestDTO struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
+// ChangeEmailRequestDTO starts changing the email of the authenticated user
+type ChangeEmailRequestDTO struct {
+	NewEmail        string `json:"new_email" binding:"required"`
+	CurrentPassword string `json:"current_password" binding:"required"`
+	// Tenant is set by the handler and selects the email domain policy
+	Tenant string `json:"-"`
+}
+
+// ChangePhoneRequestDTO sends a verification code to a new phone number of
+// the authenticated user
+type ChangePhoneRequestDTO struct {
+	Phone           string `json:"phone" binding:"required" example:"+628123456789"`
+	CurrentPassword string `json:"current_password" binding:"required"`
+}
+
+// VerifyPhoneRequestDTO confirms the code sent to the new phone number
+type VerifyPhoneRequestDTO struct {
+	Code string `json:"code" binding:"required" example:"123456"`
+}
+
+// RemovePhoneRequestDTO removes the phone number of the authenticated user
+type RemovePhoneRequestDTO struct {
+	Password string `json:"password" binding:"required"`
+}
+
+// DeleteAccountRequestDTO closes the account of the authenticated user
+type DeleteAccountRequestDTO struct {
+	Password string `json:"password" binding:"required"`
+}
@@ -0,0 +1,22 @@
+package dto
+
+import "time"
+
+// SuspendUserRequestDTO suspends a user, until ExpiresAt when it is set
+type SuspendUserRequestDTO struct {
+	Reason    string     `json:"reason"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// ReactivateUserRequestDTO makes a user active again
+type ReactivateUserRequestDTO struct {
+	Reason string `json:"reason"`
+}
+
+// ChangeUserStatusRequestDTO moves a user to any status the state machine
+// allows. ExpiresAt is only accepted for suspended and locked.
+type ChangeUserStatusRequestDTO struct {
+	Status    string     `json:"status" binding:"required"`
+	Reason    string     `json:"reason"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
@@ -0,0 +1,20 @@
+package dto
+
+// JWKDTO is a public key in JSON Web Key format (RFC 7517)
+type JWKDTO struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// OKP (Ed25519)
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKSetDTO is the response of GET /.well-known/jwks.json
+type JWKSetDTO struct {
+	Keys []JWKDTO `json:"keys"`
+}
@@ -0,0 +1,12 @@
+package dto
+
+import "time"
+
+// LockoutDTO shows the failed login counter of a user
+type LockoutDTO struct {
+	UserID         int        `json:"user_id"`
+	FailedAttempts int        `json:"failed_attempts"`
+	LastFailedAt   *time.Time `json:"last_failed_at"`
+	LockedUntil    *time.Time `json:"locked_until"`
+	Locked         bool       `json:"locked"`
+}
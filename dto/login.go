@@ -1,6 +1,55 @@
 package dto
 
+// LoginRequestDTO is the body of POST /auth/login. Identifier is a username,
+// an email or a verified phone number in E.164, depending on LOGIN_IDENTIFIERS.
+// Username is the older name of Identifier and is still accepted.
 type LoginRequestDTO struct {
-	Username string `json:"username" binding:"required"`
-	Password string `json:"password" binding:"required"`
+	Identifier string `json:"identifier" example:"alice@example.com"`
+	Username   string `json:"username,omitempty"`
+	Password   string `json:"password" binding:"required"`
+}
+
+// TokenPairDTO is returned after a successful login or refresh
+type TokenPairDTO struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
+
+// LoginResponseDTO is returned by POST /auth/login. When two-factor
+// authentication is enabled only the MFA fields are set and the tokens must be
+// obtained from POST /auth/login/mfa. When the password expired only the
+// password change fields are set and a new password must be sent to
+// POST /auth/login/password first.
+type LoginResponseDTO struct {
+	*TokenPairDTO
+	MFARequired             bool   `json:"mfa_required"`
+	MFAToken                string `json:"mfa_token,omitempty"`
+	MFAExpiresIn            int64  `json:"mfa_expires_in,omitempty"`
+	PasswordChangeRequired  bool   `json:"password_change_required"`
+	PasswordChangeToken     string `json:"password_change_token,omitempty"`
+	PasswordChangeExpiresIn int64  `json:"password_change_expires_in,omitempty"`
+}
+
+// LoginMFARequestDTO exchanges an mfa_pending challenge for real tokens
+type LoginMFARequestDTO struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+// LoginPasswordChangeRequestDTO exchanges a password_change challenge and a new password for a login
+type LoginPasswordChangeRequestDTO struct {
+	PasswordChangeToken string `json:"password_change_token" binding:"required"`
+	NewPassword         string `json:"new_password" binding:"required"`
+}
+
+// RefreshTokenRequestDTO is used to exchange a refresh token for a new pair
+type RefreshTokenRequestDTO struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// LogoutRequestDTO optionally carries the refresh token to revoke on logout
+type LogoutRequestDTO struct {
+	RefreshToken string `json:"refresh_token"`
 }
@@ -0,0 +1,17 @@
+package dto
+
+// MFAEnrollmentDTO is returned when a user starts TOTP enrollment
+type MFAEnrollmentDTO struct {
+	Secret     string `json:"secret"`
+	OTPAuthURL string `json:"otpauth_url"`
+}
+
+// MFACodeDTO carries a TOTP code or a recovery code
+type MFACodeDTO struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// RecoveryCodesDTO lists freshly generated recovery codes. They are shown only once.
+type RecoveryCodesDTO struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
@@ -0,0 +1,17 @@
+package dto
+
+// ForgotPasswordRequestDTO starts the password reset flow
+type ForgotPasswordRequestDTO struct {
+	Email string `json:"email" binding:"required"`
+}
+
+// ResetPasswordRequestDTO sets a new password with a reset token
+type ResetPasswordRequestDTO struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"`
+}
+
+// ResendVerificationRequestDTO requests a new email verification link
+type ResendVerificationRequestDTO struct {
+	Email string `json:"email" binding:"required"`
+}
@@ -0,0 +1,30 @@
+package dto
+
+import "time"
+
+// CreatePersonalAccessTokenDTO is the request body of POST /me/tokens.
+// Scopes are permission names, e.g. "users:read".
+type CreatePersonalAccessTokenDTO struct {
+	Name          string   `json:"name" binding:"required,max=100"`
+	Scopes        []string `json:"scopes" binding:"required,min=1"`
+	ExpiresInDays int      `json:"expires_in_days" binding:"required,min=1"`
+}
+
+// PersonalAccessTokenDTO describes a personal access token without its value
+type PersonalAccessTokenDTO struct {
+	ID         int        `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	LastUsedIP string     `json:"last_used_ip"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// CreatedPersonalAccessTokenDTO is returned once after creation. The token
+// value cannot be retrieved again.
+type CreatedPersonalAccessTokenDTO struct {
+	PersonalAccessTokenDTO
+	Token string `json:"token"`
+}
@@ -0,0 +1,17 @@
+package dto
+
+import "gin-user-app/apperrors"
+
+// ProblemContentType is the media type of error responses (RFC 7807)
+const ProblemContentType = "application/problem+json"
+
+// ProblemDTO is the body of every error response. Type is always
+// "about:blank", so Title is the standard text of Status.
+type ProblemDTO struct {
+	Type     string                 `json:"type"`
+	Title    string                 `json:"title"`
+	Status   int                    `json:"status"`
+	Detail   string                 `json:"detail,omitempty"`
+	Instance string                 `json:"instance,omitempty"`
+	Errors   []apperrors.FieldError `json:"errors,omitempty"`
+}
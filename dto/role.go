@@ -0,0 +1,21 @@
+package dto
+
+// RoleDTO is the response representation of a role
+type RoleDTO struct {
+	ID          int      `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+// CreateRoleDTO is used to create a custom role
+type CreateRoleDTO struct {
+	Name        string   `json:"name" binding:"required"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions" binding:"required"`
+}
+
+// AssignRolesDTO replaces the roles of a user
+type AssignRolesDTO struct {
+	Roles []string `json:"roles" binding:"required"`
+}
@@ -0,0 +1,14 @@
+package dto
+
+import "time"
+
+// SessionDTO describes a login session of a user
+type SessionDTO struct {
+	ID         string    `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	// Current is true for the session of the token used for the request
+	Current bool `json:"current"`
+}
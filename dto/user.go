@@ -4,6 +4,13 @@ import (
 	"time"
 )
 
+const (
+	// MergePatchContentType adalah media type JSON Merge Patch (RFC 7396)
+	MergePatchContentType = "application/merge-patch+json"
+	// JSONPatchContentType adalah media type JSON Patch (RFC 6902)
+	JSONPatchContentType = "application/json-patch+json"
+)
+
 // CreateUserDTO digunakan untuk membuat user baru
 type CreateUserDTO struct {
 	Username  string `json:"username" binding:"required"`
@@ -12,26 +19,68 @@ type CreateUserDTO struct {
 	FirstName string `json:"first_name" binding:"required"`
 	LastName  string `json:"last_name" binding:"required"`
 	Age       *int   `json:"age"`
+	// Tenant diisi handler dari request, menentukan kebijakan domain email
+	Tenant string `json:"-"`
 }
 
 // UserDTO digunakan untuk respons user
 type UserDTO struct {
-	ID        int       `json:"id"`
-	Username  string    `json:"username"`
-	Email     string    `json:"email"`
-	FirstName string    `json:"firstName"`
-	LastName  string    `json:"lastName"`
-	Age       *int      `json:"age"`
-	CreatedAt time.Time `json:"createdAt"`
-	UpdatedAt time.Time `json:"updatedAt"`
+	ID              int        `json:"id"`
+	Username        string     `json:"username"`
+	Email           string     `json:"email"`
+	FirstName       string     `json:"firstName"`
+	LastName        string     `json:"lastName"`
+	Age             *int       `json:"age"`
+	EmailVerifiedAt *time.Time `json:"emailVerifiedAt"`
+	Phone           *string    `json:"phone,omitempty"`
+	// Status adalah status efektif: suspend yang sudah expired tampil sebagai active
+	Status          string     `json:"status"`
+	StatusReason    string     `json:"statusReason,omitempty"`
+	StatusExpiresAt *time.Time `json:"statusExpiresAt,omitempty"`
+	CreatedAt       time.Time  `json:"createdAt"`
+	UpdatedAt       time.Time  `json:"updatedAt"`
+	// Version dikirim sebagai header ETag, bukan di body
+	Version int `json:"-"`
 }
 
-// UpdateUserDTO digunakan untuk memperbarui user
+// UpdateUserDTO digunakan untuk mengganti seluruh data user (PUT). Age yang
+// tidak dikirim menjadi null, password hanya diganti jika diisi.
 type UpdateUserDTO struct {
 	Username  string `json:"username" binding:"required"`
-	Password  string `json:"password" binding:"required"`
+	Password  string `json:"password"`
 	Email     string `json:"email" binding:"required"`
 	FirstName string `json:"first_name" binding:"required"`
 	LastName  string `json:"last_name" binding:"required"`
 	Age       *int   `json:"age"`
+	// Tenant diisi handler dari request, menentukan kebijakan domain email
+	Tenant string `json:"-"`
+	// CanChangeCredentials diisi handler: hanya pemegang users:update yang boleh
+	// mengganti password dan email di sini, pemilik akun memakai /me
+	CanChangeCredentials bool `json:"-"`
+}
+
+// UserListQueryDTO berisi query parameter untuk daftar user.
+// Jika offset diisi maka dipakai mode offset, selain itu keyset dengan cursor.
+type UserListQueryDTO struct {
+	Limit         int        `form:"limit"`
+	Cursor        string     `form:"cursor"`
+	Offset        *int       `form:"offset"`
+	Sort          string     `form:"sort"`
+	Username      string     `form:"username"`
+	Email         string     `form:"email"`
+	Name          string     `form:"name"`
+	AgeMin        *int       `form:"age_min"`
+	AgeMax        *int       `form:"age_max"`
+	CreatedAfter  *time.Time `form:"created_after" time_format:"2006-01-02T15:04:05Z07:00"`
+	CreatedBefore *time.Time `form:"created_before" time_format:"2006-01-02T15:04:05Z07:00"`
+	Status        string     `form:"status"`
+	IncludeTotal  bool       `form:"include_total"`
+}
+
+// UserPageDTO digunakan untuk respons daftar user per halaman
+type UserPageDTO struct {
+	Data       []UserDTO `json:"data"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+	NextOffset *int      `json:"next_offset,omitempty"`
+	Total      *int64    `json:"total,omitempty"`
 }
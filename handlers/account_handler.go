@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"net/http"
+
+	"gin-user-app/apperrors"
+	"gin-user-app/dto"
+	"gin-user-app/middleware"
+	"gin-user-app/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AccountHandler handles the self-service requests of the authenticated user.
+type AccountHandler struct {
+	accountService services.AccountService
+}
+
+// NewAccountHandler creates a new AccountHandler instance.
+func NewAccountHandler(service services.AccountService) *AccountHandler {
+	return &AccountHandler{accountService: service}
+}
+
+// GetMe godoc
+// @Summary Get my profile
+// @Description Return the full profile of the authenticated user
+// @Tags Account
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} dto.UserDTO
+// @Failure 401 {object} dto.ProblemDTO
+// @Failure 404 {object} dto.ProblemDTO
+// @Router /me [get]
+func (h *AccountHandler) GetMe(c *gin.Context) {
+	user, err := h.accountService.GetProfile(c.GetInt("user_id"))
+	if err != nil {
+		loginError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+// UpdateMe godoc
+// @Summary Update my profile
+// @Description Change the first name, last name or age of the authenticated user. Omitted fields are left unchanged.
+// @Tags Account
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param profile body dto.UpdateProfileDTO true "Profile fields"
+// @Success 200 {object} dto.UserDTO
+// @Failure 400 {object} dto.ProblemDTO
+// @Failure 401 {object} dto.ProblemDTO
+// @Router /me [patch]
+func (h *AccountHandler) UpdateMe(c *gin.Context) {
+	var req dto.UpdateProfileDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errInvalidPayload)
+		return
+	}
+
+	user, err := h.accountService.UpdateProfile(c.GetInt("user_id"), req)
+	if err != nil {
+		loginError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+// ChangePassword godoc
+// @Summary Change my password
+// @Description Set a new password after confirming the current one. All sessions, including the current one, are logged out.
+// @Tags Account
+// @Accept json
+// @Security BearerAuth
+// @Param passwordRequest body dto.ChangePasswordRequestDTO true "Change Password Request"
+// @Success 204 "No Content"
+// @Failure 400 {object} dto.ProblemDTO
+// @Failure 403 {object} dto.ProblemDTO "Current password is incorrect"
+// @Failure 423 {object} dto.ProblemDTO "Account temporarily locked"
+// @Failure 429 {object} dto.ProblemDTO "Too many attempts"
+// @Router /me/password [post]
+func (h *AccountHandler) ChangePassword(c *gin.Context) {
+	var req dto.ChangePasswordRequestDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errInvalidPayload)
+		return
+	}
+
+	if err := h.accountService.ChangePassword(c.GetInt("user_id"), req); err != nil {
+		loginError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ChangeEmail godoc
+// @Summary Change my email
+// @Description Send a confirmation link to the new address. The email changes when the link is opened.
+// @Tags Account
+// @Accept json
+// @Security BearerAuth
+// @Param emailRequest body dto.ChangeEmailRequestDTO true "Change Email Request"
+// @Success 202 "Accepted"
+// @Failure 400 {object} dto.ProblemDTO
+// @Failure 403 {object} dto.ProblemDTO "Current password is incorrect"
+// @Failure 409 {object} dto.ProblemDTO "Email already taken"
+// @Router /me/email [post]
+func (h *AccountHandler) ChangeEmail(c *gin.Context) {
+	var req dto.ChangeEmailRequestDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errInvalidPayload)
+		return
+	}
+	req.Tenant = middleware.TenantOf(c)
+
+	if err := h.accountService.RequestEmailChange(c.GetInt("user_id"), req); err != nil {
+		loginError(c, err)
+		return
+	}
+	c.Status(http.StatusAccepted)
+}
+
+// ConfirmEmailChange godoc
+// @Summary Confirm an email change
+// @Description Switch the account to the new email with the token from the confirmation email
+// @Tags Account
+// @Produce json
+// @Param token query string true "Email change token"
+// @Success 200 {object} dto.ProblemDTO "Email changed"
+// @Failure 400 {object} dto.ProblemDTO "Invalid or expired token"
+// @Failure 409 {object} dto.ProblemDTO "Email already taken"
+// @Router /auth/confirm-email-change [get]
+func (h *AccountHandler) ConfirmEmailChange(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		c.Error(apperrors.BadRequest("Email change token is required"))
+		return
+	}
+
+	if err := h.accountService.ConfirmEmailChange(token); err != nil {
+		loginError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Email changed"})
+}
+
+// DeleteMe godoc
+// @Summary Close my account
+// @Description Delete the account of the authenticated user after confirming the password. All sessions and personal access tokens stop working.
+// @Tags Account
+// @Accept json
+// @Security BearerAuth
+// @Param deleteRequest body dto.DeleteAccountRequestDTO true "Delete Account Request"
+// @Success 204 "No Content"
+// @Failure 400 {object} dto.ProblemDTO
+// @Failure 403 {object} dto.ProblemDTO "Password is incorrect"
+// @Router /me [delete]
+func (h *AccountHandler) DeleteMe(c *gin.Context) {
+	var req dto.DeleteAccountRequestDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errInvalidPayload)
+		return
+	}
+
+	if err := h.accountService.DeleteAccount(c.GetInt("user_id"), req); err != nil {
+		loginError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"gin-user-app/dto"
+	"gin-user-app/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AccountStatusHandler handles admin requests that change the status of an account.
+type AccountStatusHandler struct {
+	accountStatusService services.AccountStatusService
+}
+
+// NewAccountStatusHandler creates a new AccountStatusHandler instance.
+func NewAccountStatusHandler(service services.AccountStatusService) *AccountStatusHandler {
+	return &AccountStatusHandler{accountStatusService: service}
+}
+
+// SuspendUser godoc
+// @Summary Suspend a user
+// @Description Suspend the account with a reason, optionally until expires_at. The user is logged out everywhere and existing tokens stop working.
+// @Tags Account Status
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Param request body dto.SuspendUserRequestDTO true "Reason and optional expiry"
+// @Success 200 {object} dto.UserDTO
+// @Failure 400 {object} dto.ProblemDTO
+// @Failure 403 {object} dto.ProblemDTO
+// @Failure 404 {object} dto.ProblemDTO
+// @Failure 409 {object} dto.ProblemDTO
+// @Router /users/{id}/suspend [post]
+func (h *AccountStatusHandler) SuspendUser(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errInvalidUserID)
+		return
+	}
+
+	var req dto.SuspendUserRequestDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errInvalidPayload)
+		return
+	}
+
+	user, err := h.accountStatusService.Suspend(id, c.GetInt("user_id"), req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+// ReactivateUser godoc
+// @Summary Reactivate a user
+// @Description Make a suspended, locked, deactivated or pending account active again
+// @Tags Account Status
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Param request body dto.ReactivateUserRequestDTO true "Reason"
+// @Success 200 {object} dto.UserDTO
+// @Failure 400 {object} dto.ProblemDTO
+// @Failure 403 {object} dto.ProblemDTO
+// @Failure 404 {object} dto.ProblemDTO
+// @Failure 409 {object} dto.ProblemDTO
+// @Router /users/{id}/reactivate [post]
+func (h *AccountStatusHandler) ReactivateUser(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errInvalidUserID)
+		return
+	}
+
+	var req dto.ReactivateUserRequestDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errInvalidPayload)
+		return
+	}
+
+	user, err := h.accountStatusService.Reactivate(id, c.GetInt("user_id"), req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+// ChangeUserStatus godoc
+// @Summary Change the status of a user
+// @Description Move the account to active, pending, suspended, locked or deactivated if the transition is allowed. Only suspended and locked accept expires_at.
+// @Tags Account Status
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Param request body dto.ChangeUserStatusRequestDTO true "New status, reason and optional expiry"
+// @Success 200 {object} dto.UserDTO
+// @Failure 400 {object} dto.ProblemDTO
+// @Failure 403 {object} dto.ProblemDTO
+// @Failure 404 {object} dto.ProblemDTO
+// @Failure 409 {object} dto.ProblemDTO
+// @Router /users/{id}/status [put]
+func (h *AccountStatusHandler) ChangeUserStatus(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errInvalidUserID)
+		return
+	}
+
+	var req dto.ChangeUserStatusRequestDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errInvalidPayload)
+		return
+	}
+
+	user, err := h.accountStatusService.ChangeStatus(id, c.GetInt("user_id"), req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
@@ -1,11 +1,14 @@
 package handlers
 
 import (
+	"errors"
+	"gin-user-app/apperrors"
 	"gin-user-app/dto"
 	"gin-user-app/services"
-	"gin-user-app/utils"
 	"github.com/gin-gonic/gin"
+	"io"
 	"net/http"
+	"time"
 )
 
 // AuthHandler handles authentication-related requests.
@@ -20,75 +23,219 @@ func NewAuthHandler(service *services.AuthService) *AuthHandler {
 
 // Login godoc
 // @Summary Login to the system
-// @Description Authenticate a user and return a JWT token
+// @Description Authenticate with an identifier (username, email or verified phone number in E.164, as allowed by LOGIN_IDENTIFIERS) and a password and return a JWT token. The older "username" field is still accepted.
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param loginRequest body dto.LoginRequestDTO true "Login Request"
-// @Success 200 {object} map[string]string "Success"
-// @Failure 400 {object} map[string]string "Invalid request"
-// @Failure 401 {object} map[string]string "Unauthorized"
+// @Success 200 {object} dto.LoginResponseDTO "Tokens, a password_change challenge when the password expired, or an mfa_pending challenge when two-factor authentication is enabled"
+// @Failure 400 {object} dto.ProblemDTO "Invalid request"
+// @Failure 401 {object} dto.ProblemDTO "Unauthorized"
+// @Failure 403 {object} dto.ProblemDTO "Email address is not verified"
+// @Failure 423 {object} dto.ProblemDTO "Account temporarily locked"
+// @Failure 429 {object} dto.ProblemDTO "Too many attempts"
 // @Router /auth/login [post]
 func (h *AuthHandler) Login(c *gin.Context) {
 	var loginReq dto.LoginRequestDTO
 	if err := c.ShouldBindJSON(&loginReq); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		c.Error(errInvalidPayload)
 		return
 	}
 
-	if loginReq.Username == "" || loginReq.Password == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Username and password are required"})
+	identifier := loginReq.Identifier
+	if identifier == "" {
+		identifier = loginReq.Username
+	}
+	if identifier == "" || loginReq.Password == "" {
+		c.Error(apperrors.Validation("Identifier and password are required"))
+		return
+	}
+
+	tokens, err := h.authService.Login(identifier, loginReq.Password, clientInfo(c))
+	if err != nil {
+		loginError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// LoginMFA godoc
+// @Summary Complete two-factor login
+// @Description Exchange an mfa_pending challenge and a TOTP or recovery code for an access token and a refresh token
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param loginMFARequest body dto.LoginMFARequestDTO true "MFA Login Request"
+// @Success 200 {object} dto.TokenPairDTO "Success"
+// @Failure 400 {object} dto.ProblemDTO "Invalid request"
+// @Failure 401 {object} dto.ProblemDTO "Unauthorized"
+// @Failure 429 {object} dto.ProblemDTO "Too many invalid codes"
+// @Router /auth/login/mfa [post]
+func (h *AuthHandler) LoginMFA(c *gin.Context) {
+	var mfaReq dto.LoginMFARequestDTO
+	if err := c.ShouldBindJSON(&mfaReq); err != nil {
+		c.Error(errInvalidPayload)
+		return
+	}
+
+	tokens, err := h.authService.LoginMFA(mfaReq.MFAToken, mfaReq.Code, clientInfo(c))
+	// A challenge is no longer valid once MFA was disabled after it was issued
+	if errors.Is(err, services.ErrMFANotEnabled) {
+		err = services.ErrInvalidMFAChallenge
+	}
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, tokens)
+}
+
+// LoginPasswordChange godoc
+// @Summary Change an expired password during login
+// @Description Exchange a password_change challenge and a new password that meets the password policy for an access token and a refresh token, or an mfa_pending challenge when two-factor authentication is enabled
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param loginPasswordChangeRequest body dto.LoginPasswordChangeRequestDTO true "Password Change Login Request"
+// @Success 200 {object} dto.LoginResponseDTO "Success"
+// @Failure 400 {object} dto.ProblemDTO "Invalid request or password does not meet the password policy"
+// @Failure 401 {object} dto.ProblemDTO "Unauthorized"
+// @Router /auth/login/password [post]
+func (h *AuthHandler) LoginPasswordChange(c *gin.Context) {
+	var changeReq dto.LoginPasswordChangeRequestDTO
+	if err := c.ShouldBindJSON(&changeReq); err != nil {
+		c.Error(errInvalidPayload)
+		return
+	}
+
+	result, err := h.authService.LoginPasswordChange(changeReq.PasswordChangeToken, changeReq.NewPassword, clientInfo(c))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// Refresh godoc
+// @Summary Refresh access token
+// @Description Exchange a refresh token for a new access token and a rotated refresh token
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param refreshRequest body dto.RefreshTokenRequestDTO true "Refresh Request"
+// @Success 200 {object} dto.TokenPairDTO "Success"
+// @Failure 400 {object} dto.ProblemDTO "Invalid request"
+// @Failure 401 {object} dto.ProblemDTO "Unauthorized"
+// @Router /auth/refresh [post]
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var refreshReq dto.RefreshTokenRequestDTO
+	if err := c.ShouldBindJSON(&refreshReq); err != nil {
+		c.Error(errInvalidPayload)
 		return
 	}
 
-	token, err := h.authService.Login(loginReq.Username, loginReq.Password)
+	tokens, err := h.authService.Refresh(refreshReq.RefreshToken, clientInfo(c))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// Logout godoc
+// @Summary Logout current session
+// @Description Revoke the access token and session used for this request and optionally another refresh token
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param logoutRequest body dto.LogoutRequestDTO false "Logout Request"
+// @Success 204 "No Content"
+// @Failure 400 {object} dto.ProblemDTO "Invalid request"
+// @Failure 401 {object} dto.ProblemDTO "Unauthorized"
+// @Failure 403 {object} dto.ProblemDTO "Personal access tokens cannot logout"
+// @Router /auth/logout [post]
+func (h *AuthHandler) Logout(c *gin.Context) {
+	var logoutReq dto.LogoutRequestDTO
+	if err := c.ShouldBindJSON(&logoutReq); err != nil && !errors.Is(err, io.EOF) {
+		c.Error(errInvalidPayload)
+		return
+	}
+
+	userID := c.GetInt("user_id")
+	jti := c.GetString("jti")
+	expiresAt := c.MustGet("token_expires_at").(time.Time)
+
+	err := h.authService.Logout(userID, c.GetString("session_id"), jti, expiresAt, logoutReq.RefreshToken)
+	// The refresh token in the body is input, not the credential of this request
+	if errors.Is(err, services.ErrInvalidRefreshToken) {
+		err = apperrors.BadRequest("Invalid refresh token")
+	}
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid username or password"})
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"token": token})
+	c.Status(http.StatusNoContent)
+}
+
+// LogoutAll godoc
+// @Summary Logout all sessions
+// @Description Revoke every access and refresh token of the authenticated user
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 204 "No Content"
+// @Failure 401 {object} dto.ProblemDTO "Unauthorized"
+// @Failure 500 {object} dto.ProblemDTO "Internal server error"
+// @Router /auth/logout-all [post]
+func (h *AuthHandler) LogoutAll(c *gin.Context) {
+	if err := h.authService.LogoutAll(c.GetInt("user_id")); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
 }
 
 // VerifyToken godoc
 // @Summary Verify JWT token
-// @Description Verify the provided JWT token and return user information
+// @Description Verify the provided JWT token and return user information. Same response as GET /me.
 // @Tags auth
 // @Accept  json
 // @Produce  json
 // @Security BearerAuth
 // @Param Authorization header string true "Bearer JWT token"
 // @Success 200 {object} dto.UserDTO "User data"
-// @Failure 400 {object} map[string]string "Bad request"
-// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 400 {object} dto.ProblemDTO "Bad request"
+// @Failure 401 {object} dto.ProblemDTO "Unauthorized"
 // @Router /auth/verify [get]
 func (h *AuthHandler) VerifyToken(c *gin.Context) {
-	authHeader := c.GetHeader("Authorization")
-	if authHeader == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
-		return
-	}
-
-	token := authHeader[len("Bearer "):]
-	claims, err := utils.VerifyToken(token)
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
-		return
-	}
-
-	userID := int(claims["user_id"].(float64))
+	// Token is already verified against the signing key set by AuthMiddleware
+	userID := c.GetInt("user_id")
 	user, err := h.authService.GetUserByID(userID)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		c.Error(apperrors.Unauthorized("User not found"))
 		return
 	}
 
 	c.JSON(http.StatusOK, dto.UserDTO{
-		ID:        user.ID,
-		Username:  user.Username,
-		FirstName: user.FirstName,
-		LastName:  user.LastName,
-		Email:     user.Email,
+		ID:              user.ID,
+		Username:        user.Username,
+		Email:           user.Email,
+		FirstName:       user.FirstName,
+		LastName:        user.LastName,
+		Age:             user.Age,
+		EmailVerifiedAt: user.EmailVerifiedAt,
+		CreatedAt:       user.CreatedAt,
+		UpdatedAt:       user.UpdatedAt,
 	})
 }
+
+// clientInfo describes the client of a login for its session record.
+func clientInfo(c *gin.Context) services.ClientInfo {
+	return services.ClientInfo{UserAgent: c.Request.UserAgent(), IP: c.ClientIP()}
+}
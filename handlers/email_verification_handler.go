@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"net/http"
+
+	"gin-user-app/apperrors"
+	"gin-user-app/dto"
+	"gin-user-app/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EmailVerificationHandler handles email verification requests.
+type EmailVerificationHandler struct {
+	verificationService services.EmailVerificationService
+}
+
+// NewEmailVerificationHandler creates a new EmailVerificationHandler instance.
+func NewEmailVerificationHandler(service services.EmailVerificationService) *EmailVerificationHandler {
+	return &EmailVerificationHandler{verificationService: service}
+}
+
+// VerifyEmail godoc
+// @Summary Verify email address
+// @Description Confirm the email address with the token from the verification email
+// @Tags auth
+// @Produce json
+// @Param token query string true "Verification token"
+// @Success 200 {object} dto.ProblemDTO "Email verified"
+// @Failure 400 {object} dto.ProblemDTO "Invalid or expired token"
+// @Failure 500 {object} dto.ProblemDTO "Internal server error"
+// @Router /auth/verify-email [get]
+func (h *EmailVerificationHandler) VerifyEmail(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		c.Error(apperrors.BadRequest("Verification token is required"))
+		return
+	}
+
+	err := h.verificationService.Verify(token)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Email verified"})
+}
+
+// ResendVerification godoc
+// @Summary Resend verification email
+// @Description Send a new verification link. The response is the same whether or not the email is registered.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param resendRequest body dto.ResendVerificationRequestDTO true "Resend Verification Request"
+// @Success 202 "Accepted"
+// @Failure 400 {object} dto.ProblemDTO "Invalid request"
+// @Failure 429 {object} dto.ProblemDTO "Sent too recently"
+// @Router /auth/verify-email/resend [post]
+func (h *EmailVerificationHandler) ResendVerification(c *gin.Context) {
+	var resendReq dto.ResendVerificationRequestDTO
+	if err := c.ShouldBindJSON(&resendReq); err != nil {
+		c.Error(errInvalidPayload)
+		return
+	}
+
+	err := h.verificationService.Resend(resendReq.Email)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+}
@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"errors"
+
+	"gin-user-app/apperrors"
+	"gin-user-app/middleware"
+	"gin-user-app/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	// errInvalidPayload dicatat jika body request tidak bisa dibaca atau di-decode
+	errInvalidPayload = apperrors.BadRequest("Invalid request payload")
+	// errInvalidUserID dicatat jika parameter path user ID bukan angka
+	errInvalidUserID = apperrors.BadRequest("Invalid user ID")
+)
+
+// loginError mencatat error dari langkah yang memeriksa password. Jika login
+// sedang diblokir, header Retry-After ikut dikirim.
+func loginError(c *gin.Context, err error) {
+	var blocked *services.LoginBlockedError
+	if errors.As(err, &blocked) {
+		middleware.SetRetryAfter(c, blocked.RetryAfter)
+	}
+	c.Error(err)
+}
@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"net/http"
+
+	"gin-user-app/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JWKSHandler publishes the public keys used to verify access tokens.
+type JWKSHandler struct {
+	keys services.KeyManager
+}
+
+// NewJWKSHandler creates a new JWKSHandler instance.
+func NewJWKSHandler(keys services.KeyManager) *JWKSHandler {
+	return &JWKSHandler{keys: keys}
+}
+
+// GetJWKS godoc
+// @Summary Get the JSON Web Key Set
+// @Description Public keys that verify access tokens, including retired keys still in their grace period. Empty when tokens are signed with HS256.
+// @Tags auth
+// @Produce json
+// @Success 200 {object} dto.JWKSetDTO
+// @Router /.well-known/jwks.json [get]
+func (h *JWKSHandler) GetJWKS(c *gin.Context) {
+	// Verifiers may cache the key set briefly; new keys also appear on unknown kid
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.keys.JWKS())
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"gin-user-app/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LockoutHandler handles admin requests for failed login lockouts.
+type LockoutHandler struct {
+	lockoutService services.LockoutService
+}
+
+// NewLockoutHandler creates a new LockoutHandler instance.
+func NewLockoutHandler(service services.LockoutService) *LockoutHandler {
+	return &LockoutHandler{lockoutService: service}
+}
+
+// GetLockouts godoc
+// @Summary List failed login counters
+// @Description Retrieve every account with failed logins, including temporarily locked accounts
+// @Tags Lockouts
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} dto.LockoutDTO
+// @Failure 403 {object} dto.ProblemDTO
+// @Failure 500 {object} dto.ProblemDTO
+// @Router /lockouts [get]
+func (h *LockoutHandler) GetLockouts(c *gin.Context) {
+	lockouts, err := h.lockoutService.ListLockouts()
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, lockouts)
+}
+
+// GetUserLockout godoc
+// @Summary Get the lockout of a user
+// @Description Retrieve the failed login counter and lockout of a user
+// @Tags Lockouts
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} dto.LockoutDTO
+// @Failure 400 {object} dto.ProblemDTO
+// @Failure 403 {object} dto.ProblemDTO
+// @Router /users/{id}/lockout [get]
+func (h *LockoutHandler) GetUserLockout(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errInvalidUserID)
+		return
+	}
+
+	lockout, err := h.lockoutService.GetLockout(id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, lockout)
+}
+
+// ClearUserLockout godoc
+// @Summary Clear the lockout of a user
+// @Description Reset the failed login counter and unlock the account
+// @Tags Lockouts
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} dto.ProblemDTO
+// @Failure 403 {object} dto.ProblemDTO
+// @Router /users/{id}/lockout [delete]
+func (h *LockoutHandler) ClearUserLockout(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errInvalidUserID)
+		return
+	}
+
+	if err := h.lockoutService.ClearLockout(id); err != nil {
+		c.Error(err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"net/http"
+
+	"gin-user-app/dto"
+	"gin-user-app/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MFAHandler handles two-factor authentication management requests.
+type MFAHandler struct {
+	mfaService services.MFAService
+}
+
+// NewMFAHandler creates a new MFAHandler instance.
+func NewMFAHandler(service services.MFAService) *MFAHandler {
+	return &MFAHandler{mfaService: service}
+}
+
+// Enroll godoc
+// @Summary Start TOTP enrollment
+// @Description Generate a TOTP secret for the authenticated user. It becomes active after confirmation.
+// @Tags MFA
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} dto.MFAEnrollmentDTO
+// @Failure 401 {object} dto.ProblemDTO
+// @Failure 409 {object} dto.ProblemDTO
+// @Router /auth/mfa/enroll [post]
+func (h *MFAHandler) Enroll(c *gin.Context) {
+	enrollment, err := h.mfaService.Enroll(c.GetInt("user_id"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, enrollment)
+}
+
+// Confirm godoc
+// @Summary Confirm TOTP enrollment
+// @Description Activate two-factor authentication with a code from the authenticator app and return recovery codes
+// @Tags MFA
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param code body dto.MFACodeDTO true "TOTP code"
+// @Success 200 {object} dto.RecoveryCodesDTO
+// @Failure 400 {object} dto.ProblemDTO
+// @Failure 401 {object} dto.ProblemDTO
+// @Failure 409 {object} dto.ProblemDTO
+// @Router /auth/mfa/confirm [post]
+func (h *MFAHandler) Confirm(c *gin.Context) {
+	var codeReq dto.MFACodeDTO
+	if err := c.ShouldBindJSON(&codeReq); err != nil {
+		c.Error(errInvalidPayload)
+		return
+	}
+
+	codes, err := h.mfaService.Confirm(c.GetInt("user_id"), codeReq.Code)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, codes)
+}
+
+// Disable godoc
+// @Summary Disable two-factor authentication
+// @Description Remove the TOTP secret and recovery codes after checking a TOTP or recovery code
+// @Tags MFA
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param code body dto.MFACodeDTO true "TOTP or recovery code"
+// @Success 204 "No Content"
+// @Failure 400 {object} dto.ProblemDTO
+// @Failure 401 {object} dto.ProblemDTO
+// @Router /auth/mfa/disable [post]
+func (h *MFAHandler) Disable(c *gin.Context) {
+	var codeReq dto.MFACodeDTO
+	if err := c.ShouldBindJSON(&codeReq); err != nil {
+		c.Error(errInvalidPayload)
+		return
+	}
+
+	if err := h.mfaService.Disable(c.GetInt("user_id"), codeReq.Code); err != nil {
+		c.Error(err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// RegenerateRecoveryCodes godoc
+// @Summary Regenerate recovery codes
+// @Description Replace every recovery code after checking a TOTP or recovery code. The new codes are shown only once.
+// @Tags MFA
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param code body dto.MFACodeDTO true "TOTP or recovery code"
+// @Success 200 {object} dto.RecoveryCodesDTO
+// @Failure 400 {object} dto.ProblemDTO
+// @Failure 401 {object} dto.ProblemDTO
+// @Router /auth/mfa/recovery-codes [post]
+func (h *MFAHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	var codeReq dto.MFACodeDTO
+	if err := c.ShouldBindJSON(&codeReq); err != nil {
+		c.Error(errInvalidPayload)
+		return
+	}
+
+	codes, err := h.mfaService.RegenerateRecoveryCodes(c.GetInt("user_id"), codeReq.Code)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, codes)
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"net/http"
+
+	"gin-user-app/dto"
+	"gin-user-app/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PasswordHandler handles the forgot/reset password flow.
+type PasswordHandler struct {
+	resetService services.PasswordResetService
+}
+
+// NewPasswordHandler creates a new PasswordHandler instance.
+func NewPasswordHandler(service services.PasswordResetService) *PasswordHandler {
+	return &PasswordHandler{resetService: service}
+}
+
+// ForgotPassword godoc
+// @Summary Request a password reset
+// @Description Email a single-use reset link. The response is the same whether or not the email is registered.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param forgotRequest body dto.ForgotPasswordRequestDTO true "Forgot Password Request"
+// @Success 202 "Accepted"
+// @Failure 400 {object} dto.ProblemDTO "Invalid request"
+// @Failure 500 {object} dto.ProblemDTO "Internal server error"
+// @Router /auth/password/forgot [post]
+func (h *PasswordHandler) ForgotPassword(c *gin.Context) {
+	var forgotReq dto.ForgotPasswordRequestDTO
+	if err := c.ShouldBindJSON(&forgotReq); err != nil {
+		c.Error(errInvalidPayload)
+		return
+	}
+
+	if err := h.resetService.RequestReset(forgotReq.Email); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+}
+
+// ResetPassword godoc
+// @Summary Reset password
+// @Description Set a new password with a reset token. Every session of the user is logged out.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param resetRequest body dto.ResetPasswordRequestDTO true "Reset Password Request"
+// @Success 204 "No Content"
+// @Failure 400 {object} dto.ProblemDTO "Invalid request or token"
+// @Failure 500 {object} dto.ProblemDTO "Internal server error"
+// @Router /auth/password/reset [post]
+func (h *PasswordHandler) ResetPassword(c *gin.Context) {
+	var resetReq dto.ResetPasswordRequestDTO
+	if err := c.ShouldBindJSON(&resetReq); err != nil {
+		c.Error(errInvalidPayload)
+		return
+	}
+
+	if err := h.resetService.ResetPassword(resetReq.Token, resetReq.NewPassword); err != nil {
+		c.Error(err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"gin-user-app/apperrors"
+	"gin-user-app/dto"
+	"gin-user-app/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PersonalAccessTokenHandler handles requests for the authenticated user's personal access tokens.
+type PersonalAccessTokenHandler struct {
+	tokenService services.PersonalAccessTokenService
+}
+
+// NewPersonalAccessTokenHandler creates a new PersonalAccessTokenHandler instance.
+func NewPersonalAccessTokenHandler(service services.PersonalAccessTokenService) *PersonalAccessTokenHandler {
+	return &PersonalAccessTokenHandler{tokenService: service}
+}
+
+// CreateToken godoc
+// @Summary Create a personal access token
+// @Description Create a named, scoped and expiring token for scripts and CI jobs. Scopes are permission names the user has. The token is only returned once.
+// @Tags Tokens
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param token body dto.CreatePersonalAccessTokenDTO true "Token name, scopes and lifetime"
+// @Success 201 {object} dto.CreatedPersonalAccessTokenDTO
+// @Failure 400 {object} dto.ProblemDTO
+// @Failure 401 {object} dto.ProblemDTO
+// @Failure 403 {object} dto.ProblemDTO
+// @Router /me/tokens [post]
+func (h *PersonalAccessTokenHandler) CreateToken(c *gin.Context) {
+	var tokenReq dto.CreatePersonalAccessTokenDTO
+	if err := c.ShouldBindJSON(&tokenReq); err != nil {
+		c.Error(errInvalidPayload)
+		return
+	}
+
+	created, err := h.tokenService.Create(c.GetInt("user_id"), tokenReq)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusCreated, created)
+}
+
+// GetTokens godoc
+// @Summary List personal access tokens
+// @Description List the authenticated user's active personal access tokens with their last use
+// @Tags Tokens
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} dto.PersonalAccessTokenDTO
+// @Failure 401 {object} dto.ProblemDTO
+// @Failure 500 {object} dto.ProblemDTO
+// @Router /me/tokens [get]
+func (h *PersonalAccessTokenHandler) GetTokens(c *gin.Context) {
+	tokens, err := h.tokenService.List(c.GetInt("user_id"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, tokens)
+}
+
+// RevokeToken godoc
+// @Summary Revoke a personal access token
+// @Description Revoke one of the authenticated user's personal access tokens
+// @Tags Tokens
+// @Security BearerAuth
+// @Param id path int true "Token ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} dto.ProblemDTO
+// @Failure 404 {object} dto.ProblemDTO
+// @Router /me/tokens/{id} [delete]
+func (h *PersonalAccessTokenHandler) RevokeToken(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperrors.BadRequest("Invalid token ID"))
+		return
+	}
+
+	if err := h.tokenService.Revoke(c.GetInt("user_id"), id); err != nil {
+		c.Error(err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"net/http"
+
+	"gin-user-app/dto"
+	"gin-user-app/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PhoneHandler handles the phone number requests of the authenticated user.
+type PhoneHandler struct {
+	phoneService services.PhoneService
+}
+
+// NewPhoneHandler creates a new PhoneHandler instance.
+func NewPhoneHandler(service services.PhoneService) *PhoneHandler {
+	return &PhoneHandler{phoneService: service}
+}
+
+// ChangePhone godoc
+// @Summary Add or change my phone number
+// @Description Send a verification code by SMS to the number. The phone changes when the code is confirmed.
+// @Tags Account
+// @Accept json
+// @Security BearerAuth
+// @Param phoneRequest body dto.ChangePhoneRequestDTO true "Change Phone Request"
+// @Success 202 "Accepted"
+// @Failure 400 {object} dto.ProblemDTO
+// @Failure 403 {object} dto.ProblemDTO "Current password is incorrect"
+// @Failure 409 {object} dto.ProblemDTO "Phone already taken"
+// @Failure 429 {object} dto.ProblemDTO "Code sent recently"
+// @Router /me/phone [post]
+func (h *PhoneHandler) ChangePhone(c *gin.Context) {
+	var req dto.ChangePhoneRequestDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errInvalidPayload)
+		return
+	}
+
+	if err := h.phoneService.RequestPhoneChange(c.GetInt("user_id"), req); err != nil {
+		loginError(c, err)
+		return
+	}
+	c.Status(http.StatusAccepted)
+}
+
+// VerifyPhone godoc
+// @Summary Verify my phone number
+// @Description Confirm the code sent by SMS. The number becomes the verified phone of the account and can be used to log in.
+// @Tags Account
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param verifyRequest body dto.VerifyPhoneRequestDTO true "Verify Phone Request"
+// @Success 200 {object} dto.UserDTO
+// @Failure 400 {object} dto.ProblemDTO "Invalid or expired code"
+// @Failure 409 {object} dto.ProblemDTO "Phone already taken"
+// @Router /me/phone/verify [post]
+func (h *PhoneHandler) VerifyPhone(c *gin.Context) {
+	var req dto.VerifyPhoneRequestDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errInvalidPayload)
+		return
+	}
+
+	user, err := h.phoneService.VerifyPhone(c.GetInt("user_id"), req)
+	if err != nil {
+		loginError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+// RemovePhone godoc
+// @Summary Remove my phone number
+// @Description Remove the phone number of the authenticated user after confirming the password. The number can no longer be used to log in.
+// @Tags Account
+// @Accept json
+// @Security BearerAuth
+// @Param removeRequest body dto.RemovePhoneRequestDTO true "Remove Phone Request"
+// @Success 204 "No Content"
+// @Failure 400 {object} dto.ProblemDTO
+// @Failure 403 {object} dto.ProblemDTO "Password is incorrect"
+// @Router /me/phone [delete]
+func (h *PhoneHandler) RemovePhone(c *gin.Context) {
+	var req dto.RemovePhoneRequestDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errInvalidPayload)
+		return
+	}
+
+	if err := h.phoneService.RemovePhone(c.GetInt("user_id"), req); err != nil {
+		loginError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
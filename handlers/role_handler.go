@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"gin-user-app/dto"
+	"gin-user-app/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RoleHandler handles role and permission management requests.
+type RoleHandler struct {
+	roleService services.RoleService
+}
+
+// NewRoleHandler creates a new RoleHandler instance.
+func NewRoleHandler(service services.RoleService) *RoleHandler {
+	return &RoleHandler{roleService: service}
+}
+
+// GetRoles godoc
+// @Summary List roles
+// @Description Retrieve every role with its permissions
+// @Tags Roles
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} dto.RoleDTO
+// @Failure 403 {object} dto.ProblemDTO
+// @Failure 500 {object} dto.ProblemDTO
+// @Router /roles [get]
+func (h *RoleHandler) GetRoles(c *gin.Context) {
+	roles, err := h.roleService.GetAllRoles()
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, roles)
+}
+
+// CreateRole godoc
+// @Summary Create a custom role
+// @Description Create a role from existing permissions
+// @Tags Roles
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param role body dto.CreateRoleDTO true "Role data"
+// @Success 201 {object} dto.RoleDTO
+// @Failure 400 {object} dto.ProblemDTO
+// @Failure 403 {object} dto.ProblemDTO
+// @Router /roles [post]
+func (h *RoleHandler) CreateRole(c *gin.Context) {
+	var roleReq dto.CreateRoleDTO
+	if err := c.ShouldBindJSON(&roleReq); err != nil {
+		c.Error(errInvalidPayload)
+		return
+	}
+
+	role, err := h.roleService.CreateRole(roleReq)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, role)
+}
+
+// GetUserRoles godoc
+// @Summary Get the roles of a user
+// @Description Retrieve the effective roles of a user
+// @Tags Roles
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {array} dto.RoleDTO
+// @Failure 400 {object} dto.ProblemDTO
+// @Failure 403 {object} dto.ProblemDTO
+// @Failure 404 {object} dto.ProblemDTO
+// @Router /users/{id}/roles [get]
+func (h *RoleHandler) GetUserRoles(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errInvalidUserID)
+		return
+	}
+
+	roles, err := h.roleService.GetUserRoles(id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, roles)
+}
+
+// AssignUserRoles godoc
+// @Summary Assign roles to a user
+// @Description Replace the roles of a user. Changes apply to the user's next token refresh.
+// @Tags Roles
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Param roles body dto.AssignRolesDTO true "Role names"
+// @Success 200 {array} dto.RoleDTO
+// @Failure 400 {object} dto.ProblemDTO
+// @Failure 403 {object} dto.ProblemDTO
+// @Failure 404 {object} dto.ProblemDTO
+// @Router /users/{id}/roles [put]
+func (h *RoleHandler) AssignUserRoles(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errInvalidUserID)
+		return
+	}
+
+	var rolesReq dto.AssignRolesDTO
+	if err := c.ShouldBindJSON(&rolesReq); err != nil {
+		c.Error(errInvalidPayload)
+		return
+	}
+
+	roles, err := h.roleService.AssignRoles(id, rolesReq.Roles)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, roles)
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"gin-user-app/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SessionHandler handles requests to list and revoke login sessions.
+type SessionHandler struct {
+	sessionService services.SessionService
+}
+
+// NewSessionHandler creates a new SessionHandler instance.
+func NewSessionHandler(service services.SessionService) *SessionHandler {
+	return &SessionHandler{sessionService: service}
+}
+
+// GetMySessions godoc
+// @Summary List my sessions
+// @Description List the active login sessions of the authenticated user. The session of the current token is marked as current.
+// @Tags Sessions
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} dto.SessionDTO
+// @Failure 401 {object} dto.ProblemDTO
+// @Failure 500 {object} dto.ProblemDTO
+// @Router /me/sessions [get]
+func (h *SessionHandler) GetMySessions(c *gin.Context) {
+	sessions, err := h.sessionService.List(c.GetInt("user_id"), c.GetString("session_id"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, sessions)
+}
+
+// RevokeMySession godoc
+// @Summary Revoke one of my sessions
+// @Description Log out a session of the authenticated user. Its tokens stop working immediately.
+// @Tags Sessions
+// @Security BearerAuth
+// @Param id path string true "Session ID"
+// @Success 204 "No Content"
+// @Failure 401 {object} dto.ProblemDTO
+// @Failure 404 {object} dto.ProblemDTO
+// @Router /me/sessions/{id} [delete]
+func (h *SessionHandler) RevokeMySession(c *gin.Context) {
+	h.revoke(c, c.GetInt("user_id"), c.Param("id"))
+}
+
+// GetUserSessions godoc
+// @Summary List the sessions of a user
+// @Description List the active login sessions of any user
+// @Tags Sessions
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {array} dto.SessionDTO
+// @Failure 400 {object} dto.ProblemDTO
+// @Failure 403 {object} dto.ProblemDTO
+// @Router /users/{id}/sessions [get]
+func (h *SessionHandler) GetUserSessions(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errInvalidUserID)
+		return
+	}
+
+	sessions, err := h.sessionService.List(userID, c.GetString("session_id"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, sessions)
+}
+
+// RevokeUserSession godoc
+// @Summary Revoke a session of a user
+// @Description Log out a session of any user. Its tokens stop working immediately.
+// @Tags Sessions
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Param sessionId path string true "Session ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} dto.ProblemDTO
+// @Failure 403 {object} dto.ProblemDTO
+// @Failure 404 {object} dto.ProblemDTO
+// @Router /users/{id}/sessions/{sessionId} [delete]
+func (h *SessionHandler) RevokeUserSession(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errInvalidUserID)
+		return
+	}
+	h.revoke(c, userID, c.Param("sessionId"))
+}
+
+// revoke revokes a session and writes the response.
+func (h *SessionHandler) revoke(c *gin.Context, userID int, sessionID string) {
+	if err := h.sessionService.Revoke(userID, sessionID); err != nil {
+		c.Error(err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -4,7 +4,10 @@ import (
 	"net/http"
 	"strconv"
 
+	"gin-user-app/apperrors"
 	"gin-user-app/dto"
+	"gin-user-app/middleware"
+	"gin-user-app/models"
 	"gin-user-app/services"
 
 	"github.com/gin-gonic/gin"
@@ -22,23 +25,43 @@ func NewUserHandler(service services.UserService) *UserHandler {
 	}
 }
 
-// GetUsers mendapatkan semua user
-// @Summary Get all users
-// @Description Retrieve a list of users
+// GetUsers mendapatkan daftar user per halaman
+// @Summary List users
+// @Description Retrieve a page of users. Uses keyset pagination with cursor, or offset mode when offset is given.
 // @Tags Users
 // @Accept  json
 // @Produce  json
 // @Security BearerAuth
-// @Success 200 {array} dto.UserDTO
-// @Failure 500 {object} map[string]string
+// @Param limit query int false "Page size (1-100, default 20)"
+// @Param cursor query string false "Cursor from next_cursor of the previous page"
+// @Param offset query int false "Offset (switches to offset mode)"
+// @Param sort query string false "Sort field, prefix with - for descending (id, username, email, first_name, last_name, age, created_at)"
+// @Param username query string false "Username prefix"
+// @Param email query string false "Email prefix"
+// @Param name query string false "First or last name prefix"
+// @Param age_min query int false "Minimum age"
+// @Param age_max query int false "Maximum age"
+// @Param created_after query string false "Created at or after (RFC3339)"
+// @Param created_before query string false "Created before (RFC3339)"
+// @Param status query string false "Status (active, pending, suspended, locked, deactivated)"
+// @Param include_total query bool false "Include total count"
+// @Success 200 {object} dto.UserPageDTO
+// @Failure 400 {object} dto.ProblemDTO
+// @Failure 500 {object} dto.ProblemDTO
 // @Router /users [get]
 func (h *UserHandler) GetUsers(c *gin.Context) {
-	users, err := h.userService.GetAllUsers()
+	var query dto.UserListQueryDTO
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.Error(apperrors.BadRequest("Invalid query parameters"))
+		return
+	}
+
+	page, err := h.userService.ListUsers(query)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch users"})
+		c.Error(err)
 		return
 	}
-	c.JSON(http.StatusOK, users)
+	c.JSON(http.StatusOK, page)
 }
 
 // GetUserByID mendapatkan user berdasarkan ID
@@ -49,23 +72,33 @@ func (h *UserHandler) GetUsers(c *gin.Context) {
 // @Produce  json
 // @Security BearerAuth
 // @Param id path int true "User ID"
+// @Param If-None-Match header string false "ETag from an earlier response"
 // @Success 200 {object} dto.UserDTO
-// @Failure 400 {object} map[string]string
-// @Failure 404 {object} map[string]string
+// @Success 304 "Not Modified"
+// @Header 200,304 {string} ETag "Current version of the user"
+// @Failure 400 {object} dto.ProblemDTO
+// @Failure 404 {object} dto.ProblemDTO
 // @Router /users/{id} [get]
 func (h *UserHandler) GetUserByID(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		c.Error(errInvalidUserID)
 		return
 	}
 
 	user, err := h.userService.GetUserByID(id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		c.Error(err)
 		return
 	}
 
+	etag := middleware.ETag(user.Version)
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "private, no-cache")
+	if middleware.IfNoneMatch(c, etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
 	c.JSON(http.StatusOK, user)
 }
 
@@ -78,61 +111,124 @@ func (h *UserHandler) GetUserByID(c *gin.Context) {
 // @Security BearerAuth
 // @Param user body dto.CreateUserDTO true "User data"
 // @Success 201 {object} dto.UserDTO
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} dto.ProblemDTO
+// @Failure 409 {object} dto.ProblemDTO "Username or email already taken, field names the duplicate"
+// @Failure 500 {object} dto.ProblemDTO
 // @Router /users [post]
 func (h *UserHandler) CreateUser(c *gin.Context) {
 	var userReq dto.CreateUserDTO
 	if err := c.ShouldBindJSON(&userReq); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		c.Error(errInvalidPayload)
 		return
 	}
+	userReq.Tenant = middleware.TenantOf(c)
 
 	user, err := h.userService.CreateUser(userReq)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
 	c.JSON(http.StatusCreated, user)
 }
 
-// UpdateUser memperbarui data user berdasarkan ID
-// @Summary Update a user
-// @Description Update user details
+// UpdateUser mengganti seluruh data user berdasarkan ID
+// @Summary Replace a user
+// @Description Replace all user details. Omitted optional fields are cleared; the password is only changed when given. Changing the password or email requires users:update, account owners use /me/password and /me/email.
 // @Tags Users
 // @Accept  json
 // @Produce  json
 // @Security BearerAuth
 // @Param id path int true "User ID"
 // @Param user body dto.UpdateUserDTO true "Updated user data"
+// @Param If-Match header string false "ETag of the version being replaced"
 // @Success 200 {object} dto.UserDTO
-// @Failure 400 {object} map[string]string
-// @Failure 404 {object} map[string]string
+// @Header 200 {string} ETag "New version of the user"
+// @Failure 400 {object} dto.ProblemDTO
+// @Failure 403 {object} dto.ProblemDTO "Password or email change without users:update"
+// @Failure 404 {object} dto.ProblemDTO
+// @Failure 409 {object} dto.ProblemDTO "Username or email already taken, or modified by a concurrent request"
+// @Failure 412 {object} dto.ProblemDTO "If-Match does not match the current version"
+// @Failure 428 {object} dto.ProblemDTO "If-Match header is required"
 // @Router /users/{id} [put]
 func (h *UserHandler) UpdateUser(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		c.Error(errInvalidUserID)
 		return
 	}
 
 	var userReq dto.UpdateUserDTO
 	if err := c.ShouldBindJSON(&userReq); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		c.Error(errInvalidPayload)
+		return
+	}
+	userReq.Tenant = middleware.TenantOf(c)
+	userReq.CanChangeCredentials = middleware.HasPermission(c, models.PermissionUsersUpdate)
+
+	version, ok := middleware.IfMatchVersion(c)
+	if !ok {
+		c.Error(services.ErrPreconditionFailed)
+		return
+	}
+
+	user, err := h.userService.UpdateUser(id, userReq, version)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Header("ETag", middleware.ETag(user.Version))
+	c.JSON(http.StatusOK, user)
+}
+
+// PatchUser mengubah sebagian data user berdasarkan ID
+// @Summary Partially update a user
+// @Description Apply a JSON Merge Patch (application/merge-patch+json, RFC 7396) or a JSON Patch (application/json-patch+json, RFC 6902) to username, email, password, first_name, last_name and age. Null clears first_name, last_name and age. Only changed fields are validated. Changing the password or email requires users:update, account owners use /me/password and /me/email.
+// @Tags Users
+// @Accept application/merge-patch+json
+// @Accept application/json-patch+json
+// @Produce  json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Param patch body object true "Merge patch object or array of JSON Patch operations"
+// @Param If-Match header string false "ETag of the version being patched"
+// @Success 200 {object} dto.UserDTO
+// @Header 200 {string} ETag "New version of the user"
+// @Failure 400 {object} dto.ProblemDTO
+// @Failure 403 {object} dto.ProblemDTO "Password or email change without users:update"
+// @Failure 404 {object} dto.ProblemDTO
+// @Failure 409 {object} dto.ProblemDTO "JSON Patch test operation failed, username or email already taken, or modified by a concurrent request"
+// @Failure 412 {object} dto.ProblemDTO "If-Match does not match the current version"
+// @Failure 415 {object} dto.ProblemDTO "Unsupported Content-Type"
+// @Failure 428 {object} dto.ProblemDTO "If-Match header is required"
+// @Router /users/{id} [patch]
+func (h *UserHandler) PatchUser(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errInvalidUserID)
+		return
+	}
+
+	patch, err := c.GetRawData()
+	if err != nil {
+		c.Error(errInvalidPayload)
+		return
+	}
+
+	version, ok := middleware.IfMatchVersion(c)
+	if !ok {
+		c.Error(services.ErrPreconditionFailed)
 		return
 	}
 
-	user, err := h.userService.UpdateUser(id, userReq)
+	user, err := h.userService.PatchUser(id, middleware.TenantOf(c), middleware.HasPermission(c, models.PermissionUsersUpdate), c.ContentType(), patch, version)
 	if err != nil {
-		if err.Error() == "user not found" {
-			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
-			return
-		}
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
+	c.Header("ETag", middleware.ETag(user.Version))
 	c.JSON(http.StatusOK, user)
 }
 
@@ -144,20 +240,28 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 // @Produce  json
 // @Security BearerAuth
 // @Param id path int true "User ID"
+// @Param If-Match header string false "ETag of the version being deleted"
 // @Success 204 "No Content"
-// @Failure 400 {object} map[string]string
-// @Failure 404 {object} map[string]string
+// @Failure 400 {object} dto.ProblemDTO
+// @Failure 404 {object} dto.ProblemDTO
+// @Failure 412 {object} dto.ProblemDTO "If-Match does not match the current version"
+// @Failure 428 {object} dto.ProblemDTO "If-Match header is required"
 // @Router /users/{id} [delete]
 func (h *UserHandler) DeleteUser(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		c.Error(errInvalidUserID)
 		return
 	}
 
-	err = h.userService.DeleteUser(id)
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+	version, ok := middleware.IfMatchVersion(c)
+	if !ok {
+		c.Error(services.ErrPreconditionFailed)
+		return
+	}
+
+	if err := h.userService.DeleteUser(id, version); err != nil {
+		c.Error(err)
 		return
 	}
 
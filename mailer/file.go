@@ -0,0 +1,41 @@
+package mailer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileMailer menulis setiap email sebagai file .eml ke sebuah folder.
+// Cocok untuk development tanpa server SMTP.
+type FileMailer struct {
+	dir  string
+	from string
+}
+
+// NewFileMailer membuat FileMailer
+func NewFileMailer(dir, from string) *FileMailer {
+	return &FileMailer{dir: dir, from: from}
+}
+
+func (m *FileMailer) Send(msg Message) error {
+	msg = withDefaultFrom(msg, m.from)
+	if err := validate(msg); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(m.dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create mail drop directory: %w", err)
+	}
+
+	file, err := os.CreateTemp(m.dir, time.Now().UTC().Format("20060102T150405")+"-*.eml")
+	if err != nil {
+		return fmt.Errorf("failed to create mail file: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(render(msg)); err != nil {
+		return fmt.Errorf("failed to write mail file %s: %w", filepath.Base(file.Name()), err)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+// Package mailer mengirim email transaksional (reset password, verifikasi email)
+// lewat SMTP, file di disk, atau memori untuk testing.
+package mailer
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Nama driver mailer yang didukung
+const (
+	DriverSMTP   = "smtp"
+	DriverFile   = "file"
+	DriverMemory = "memory"
+)
+
+// Message adalah satu email plain-text
+type Message struct {
+	From    string
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer mengirim email
+type Mailer interface {
+	Send(msg Message) error
+}
+
+// Config memilih driver dan parameternya
+type Config struct {
+	Driver       string
+	From         string
+	DropDir      string
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+}
+
+// New membuat Mailer sesuai konfigurasi
+func New(cfg Config) (Mailer, error) {
+	switch cfg.Driver {
+	case DriverSMTP:
+		return NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.From), nil
+	case DriverFile, "":
+		return NewFileMailer(cfg.DropDir, cfg.From), nil
+	case DriverMemory:
+		return NewMemoryMailer(cfg.From), nil
+	default:
+		return nil, fmt.Errorf("unsupported mailer driver %q", cfg.Driver)
+	}
+}
+
+// render menyusun pesan dalam format RFC 5322 sederhana
+func render(msg Message) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", msg.From)
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", msg.Subject)
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
+
+// withDefaultFrom mengisi pengirim default jika kosong
+func withDefaultFrom(msg Message, from string) Message {
+	if msg.From == "" {
+		msg.From = from
+	}
+	return msg
+}
+
+// validate menolak header yang bisa disisipi header lain
+func validate(msg Message) error {
+	for _, value := range []string{msg.From, msg.To, msg.Subject} {
+		if strings.ContainsAny(value, "\r\n") {
+			return fmt.Errorf("invalid mail header %q", value)
+		}
+	}
+	if msg.To == "" {
+		return fmt.Errorf("mail recipient is required")
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package mailer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestFileMailer_WritesEMLUnit menguji bahwa FileMailer menulis file .eml dengan header lengkap
+func TestFileMailer_WritesEMLUnit(t *testing.T) {
+	dir := t.TempDir()
+	m := NewFileMailer(dir, "no-reply@test")
+
+	err := m.Send(Message{To: "alice@gmail.com", Subject: "Hello", Body: "line one\nline two"})
+	assert.NoError(t, err)
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.eml"))
+	assert.Len(t, files, 1)
+	content, _ := os.ReadFile(files[0])
+	assert.True(t, strings.HasPrefix(string(content), "From: no-reply@test\r\nTo: alice@gmail.com\r\nSubject: Hello\r\n"))
+	assert.True(t, strings.HasSuffix(string(content), "\r\n\r\nline one\r\nline two"))
+}
+
+// TestMemoryMailer_RejectsHeaderInjectionUnit menguji bahwa header dengan baris baru ditolak
+func TestMemoryMailer_RejectsHeaderInjectionUnit(t *testing.T) {
+	m := NewMemoryMailer("no-reply@test")
+
+	err := m.Send(Message{To: "alice@gmail.com\r\nBcc: bob@gmail.com", Subject: "Hi"})
+
+	assert.Error(t, err)
+	assert.Empty(t, m.Messages())
+}
+
+// TestNew_UnknownDriverUnit menguji bahwa driver yang tidak dikenal ditolak
+func TestNew_UnknownDriverUnit(t *testing.T) {
+	_, err := New(Config{Driver: "carrier-pigeon"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,34 @@
+package mailer
+
+import "sync"
+
+// MemoryMailer menyimpan email di memori, dipakai untuk testing
+type MemoryMailer struct {
+	mu       sync.Mutex
+	from     string
+	messages []Message
+}
+
+// NewMemoryMailer membuat MemoryMailer
+func NewMemoryMailer(from string) *MemoryMailer {
+	return &MemoryMailer{from: from}
+}
+
+func (m *MemoryMailer) Send(msg Message) error {
+	msg = withDefaultFrom(msg, m.from)
+	if err := validate(msg); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = append(m.messages, msg)
+	return nil
+}
+
+// Messages mengembalikan salinan semua email yang sudah dikirim
+func (m *MemoryMailer) Messages() []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Message(nil), m.messages...)
+}
@@ -5,12 +5,17 @@ import (
 	"gin-user-app/database"
 	"gin-user-app/dto"
 	"gin-user-app/handlers"
+	"gin-user-app/mailer"
 	"gin-user-app/middleware"
+	"gin-user-app/migrations"
 	"gin-user-app/models"
 	"gin-user-app/repositories"
 	"gin-user-app/routes"
 	"gin-user-app/services"
+	"gin-user-app/sms"
+	"gin-user-app/utils"
 	"log"
+	"os"
 
 	_ "gin-user-app/docs"
 
@@ -30,38 +35,258 @@ func main() {
 	log.Println("Reading .env file...")
 	config.LoadConfig()
 
+	// Password hashing algorithm, existing hashes are upgraded on login
+	hasher, err := utils.NewPasswordHasher(utils.PasswordHashConfig{
+		Algorithm:         config.AppConfig.PasswordHashAlgorithm,
+		BcryptCost:        config.AppConfig.BcryptCost,
+		Argon2Memory:      config.AppConfig.Argon2Memory,
+		Argon2Iterations:  config.AppConfig.Argon2Iterations,
+		Argon2Parallelism: config.AppConfig.Argon2Parallelism,
+	})
+	if err != nil {
+		log.Fatal("Invalid password hash configuration:", err)
+	}
+	utils.SetPasswordHasher(hasher)
+
 	// Initialize database
 	db := database.InitDB()
 	if db == nil {
 		log.Fatal("Failed to connect to database")
 	}
 
+	// Subcommand: go run . migrate up|down|status|redo|collisions
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := migrations.RunCommand(db, os.Args[2:], os.Stdout); err != nil {
+			log.Fatal("Migration failed:", err)
+		}
+		return
+	}
+
 	// Run database migrations
-	log.Println("Running database migrations...")
-	err := db.AutoMigrate(&models.User{})
-	if err != nil {
-		log.Fatal("Failed to migrate database:", err)
+	if config.AppConfig.MigrateOnStart {
+		log.Println("Running database migrations...")
+		applied, err := migrations.Up(db)
+		if err != nil {
+			log.Fatal("Failed to migrate database:", err)
+		}
+		log.Printf("Database migrations completed (%d applied)", applied)
+		if collisions, err := migrations.IdentityCollisions(db); err == nil && len(collisions) > 0 {
+			log.Printf("Warning: %d users share a username or email with another user, run \"migrate collisions\" for details", len(collisions))
+		}
+	}
+
+	roleRepo := repositories.NewRoleRepository(db)
+	if err := roleRepo.EnsureDefaults(); err != nil {
+		log.Fatal("Failed to seed default roles:", err)
 	}
-	log.Println("Database migrations completed")
 
 	createDummyUser(db)
+	bootstrapAdmin(db, roleRepo)
 
 	authRepo := repositories.NewAuthRepository(db)
+	if err := repositories.ValidateIdentifierTypes(config.AppConfig.LoginIdentifiers); err != nil {
+		log.Fatal("Invalid LOGIN_IDENTIFIERS:", err)
+	}
 	userRepo := repositories.NewUserRepository(db)
+	refreshTokenRepo := repositories.NewRefreshTokenRepository(db)
+	revocationStore := newRevocationStore(db)
+	stopPruner := repositories.StartRevocationPruner(revocationStore, config.AppConfig.RevocationPruneInterval)
+	defer stopPruner()
+
+	mfaRepo := repositories.NewMFARepository(db)
+	passwordResetRepo := repositories.NewPasswordResetRepository(db)
+	emailVerificationRepo := repositories.NewEmailVerificationRepository(db)
+	loginAttemptRepo := repositories.NewLoginAttemptRepository(db)
+	signingKeyRepo := repositories.NewSigningKeyRepository(db)
+	personalAccessTokenRepo := repositories.NewPersonalAccessTokenRepository(db)
+	sessionRepo := repositories.NewSessionRepository(db)
+	emailChangeRepo := repositories.NewEmailChangeRepository(db)
+	passwordHistoryRepo := repositories.NewPasswordHistoryRepository(db)
+	phoneVerificationRepo := repositories.NewPhoneVerificationRepository(db)
+
+	// JWT signing keys, rotated in the background; retired keys verify until their grace period ends
+	keyManager, err := services.NewKeyManager(signingKeyRepo, services.KeyManagerConfig{
+		Algorithm:        config.AppConfig.JWTSigningAlgorithm,
+		Secret:           config.AppConfig.JWTSecret,
+		RotationInterval: config.AppConfig.JWTKeyRotation,
+		GracePeriod:      config.AppConfig.JWTKeyGracePeriod,
+	})
+	if err != nil {
+		log.Fatal("Failed to initialize JWT signing keys:", err)
+	}
+	stopKeyRotation := services.StartKeyRotation(keyManager, config.AppConfig.JWTKeyCheckInterval)
+	defer stopKeyRotation()
+	tokenService := services.NewTokenService(keyManager, services.TokenConfig{
+		Issuer:          config.AppConfig.JWTIssuer,
+		Audience:        config.AppConfig.JWTAudience,
+		AccessTokenTTL:  config.AppConfig.AccessTokenTTL,
+		MFAChallengeTTL: config.AppConfig.MFAChallengeTTL,
+		ClockSkew:       config.AppConfig.JWTClockSkew,
+	})
+
+	mailSender, err := mailer.New(mailer.Config{
+		Driver:       config.AppConfig.MailDriver,
+		From:         config.AppConfig.MailFrom,
+		DropDir:      config.AppConfig.MailDropDir,
+		SMTPHost:     config.AppConfig.SMTPHost,
+		SMTPPort:     config.AppConfig.SMTPPort,
+		SMTPUsername: config.AppConfig.SMTPUsername,
+		SMTPPassword: config.AppConfig.SMTPPassword,
+	})
+	if err != nil {
+		log.Fatal("Invalid mailer configuration:", err)
+	}
+	smsSender, err := sms.New(sms.Config{
+		Driver:  config.AppConfig.SmsDriver,
+		DropDir: config.AppConfig.SmsDropDir,
+	})
+	if err != nil {
+		log.Fatal("Invalid sms configuration:", err)
+	}
+
+	roleService := services.NewRoleService(roleRepo)
+	mfaService := services.NewMFAService(mfaRepo, userRepo, config.AppConfig.MFAIssuer)
+	lockoutService := services.NewLockoutService(loginAttemptRepo, services.LockoutPolicy{
+		FreeAttempts:    config.AppConfig.LoginFreeAttempts,
+		MaxAttempts:     config.AppConfig.LoginMaxAttempts,
+		BaseDelay:       config.AppConfig.LoginBaseDelay,
+		MaxDelay:        config.AppConfig.LoginMaxDelay,
+		LockoutDuration: config.AppConfig.LoginLockoutDuration,
+	})
+	// Password policy: strength, personal info, breached passwords, history and maximum age
+	passwordPolicy, err := services.NewPasswordPolicy(services.PasswordPolicyConfig{
+		MinLength:          config.AppConfig.PasswordMinLength,
+		MaxLength:          config.AppConfig.PasswordMaxLength,
+		MinClasses:         config.AppConfig.PasswordMinClasses,
+		MinScore:           config.AppConfig.PasswordMinScore,
+		RejectPersonalInfo: config.AppConfig.PasswordRejectPersonalInfo,
+		BreachedDir:        config.AppConfig.PasswordBreachedDir,
+		HistorySize:        config.AppConfig.PasswordHistory,
+		MaxAge:             config.AppConfig.PasswordMaxAge,
+	}, passwordHistoryRepo)
+	if err != nil {
+		log.Fatal("Invalid password policy configuration:", err)
+	}
+	sessionService := services.NewSessionService(sessionRepo, refreshTokenRepo, config.AppConfig.RefreshTokenTTL)
+	authService := services.NewAuthService(
+		authRepo,
+		refreshTokenRepo,
+		revocationStore,
+		roleService,
+		mfaService,
+		lockoutService,
+		tokenService,
+		sessionService,
+		passwordPolicy,
+		services.AuthConfig{
+			RefreshTokenTTL:      config.AppConfig.RefreshTokenTTL,
+			RequireVerifiedEmail: config.AppConfig.RequireVerifiedEmail,
+			LoginIdentifiers:     config.AppConfig.LoginIdentifiers,
+		},
+	)
+	emailVerificationService := services.NewEmailVerificationService(
+		emailVerificationRepo,
+		userRepo,
+		mailSender,
+		config.AppConfig.AppBaseURL+"/auth/verify-email",
+		config.AppConfig.EmailVerificationTTL,
+		config.AppConfig.VerificationResendInterval,
+	)
+	// Email domain policy: allow/deny lists, disposable providers, MX check and tenant overrides
+	tenantEmailRules, err := services.ParseTenantEmailRules(config.AppConfig.EmailTenantPolicies)
+	if err != nil {
+		log.Fatal("Invalid email policy configuration:", err)
+	}
+	emailPolicy, err := services.NewEmailPolicy(services.EmailPolicyConfig{
+		Default: services.EmailDomainRules{
+			Allow:           config.AppConfig.EmailAllowedDomains,
+			Deny:            config.AppConfig.EmailDeniedDomains,
+			BlockDisposable: &config.AppConfig.EmailBlockDisposable,
+			CheckMX:         &config.AppConfig.EmailCheckMX,
+		},
+		Tenants:        tenantEmailRules,
+		DisposableFile: config.AppConfig.EmailDisposableFile,
+	}, services.DNSResolver{})
+	if err != nil {
+		log.Fatal("Invalid email policy configuration:", err)
+	}
+	if config.AppConfig.EmailDisposableFile != "" {
+		stopDisposableRefresh := services.StartDisposableRefresh(emailPolicy, config.AppConfig.EmailDisposableRefresh)
+		defer stopDisposableRefresh()
+	}
 
-	authService := services.NewAuthService(authRepo, config.AppConfig.JWTSecret)
-	userService := services.NewUserService(userRepo)
+	userService := services.NewUserService(userRepo, emailVerificationService, emailPolicy, passwordPolicy, config.AppConfig.NewUserStatus)
+	personalAccessTokenService := services.NewPersonalAccessTokenService(personalAccessTokenRepo, roleService, config.AppConfig.PersonalAccessTokenMaxTTL)
+	accountService := services.NewAccountService(
+		userRepo,
+		emailChangeRepo,
+		authService,
+		personalAccessTokenService,
+		lockoutService,
+		emailPolicy,
+		passwordPolicy,
+		mailSender,
+		config.AppConfig.AppBaseURL+"/auth/confirm-email-change",
+		config.AppConfig.EmailChangeTTL,
+	)
+	phoneService := services.NewPhoneService(
+		userRepo,
+		phoneVerificationRepo,
+		lockoutService,
+		smsSender,
+		config.AppConfig.PhoneVerificationTTL,
+		config.AppConfig.VerificationResendInterval,
+	)
+	passwordResetService := services.NewPasswordResetService(
+		passwordResetRepo,
+		userRepo,
+		authService,
+		passwordPolicy,
+		mailSender,
+		config.AppConfig.AppBaseURL+"/reset-password",
+		config.AppConfig.PasswordResetTTL,
+	)
+	accountStatusService := services.NewAccountStatusService(userRepo, authService)
 
 	authHandler := handlers.NewAuthHandler(authService)
 	userHandler := handlers.NewUserHandler(userService)
+	roleHandler := handlers.NewRoleHandler(roleService)
+	mfaHandler := handlers.NewMFAHandler(mfaService)
+	passwordHandler := handlers.NewPasswordHandler(passwordResetService)
+	emailVerificationHandler := handlers.NewEmailVerificationHandler(emailVerificationService)
+	lockoutHandler := handlers.NewLockoutHandler(lockoutService)
+	jwksHandler := handlers.NewJWKSHandler(keyManager)
+	personalAccessTokenHandler := handlers.NewPersonalAccessTokenHandler(personalAccessTokenService)
+	sessionHandler := handlers.NewSessionHandler(sessionService)
+	accountHandler := handlers.NewAccountHandler(accountService)
+	phoneHandler := handlers.NewPhoneHandler(phoneService)
+	accountStatusHandler := handlers.NewAccountStatusHandler(accountStatusService)
 
 	r := gin.Default()
 	r.SetTrustedProxies(nil)
+	// Error yang dicatat handler dengan c.Error dirender sebagai application/problem+json
+	r.Use(middleware.ErrorHandler())
+	r.Use(middleware.Tenant(config.AppConfig.TenantHeader))
 
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
-	routes.AuthRoutes(r, authHandler, middleware.AuthMiddleware(config.AppConfig.JWTSecret))
-	routes.UserRouter(r, userHandler, middleware.AuthMiddleware(config.AppConfig.JWTSecret))
+	authMiddleware := middleware.AuthMiddleware(tokenService, revocationStore, personalAccessTokenService, sessionService, accountStatusService)
+	// Batas request per IP untuk endpoint tanpa login (login, reset password, kirim ulang verifikasi)
+	authRateLimit := middleware.RateLimitByIP(middleware.NewIPRateLimiter(config.AppConfig.AuthRateLimit, config.AppConfig.AuthRateLimitWindow))
+
+	routes.AuthRoutes(r, authHandler, authMiddleware, authRateLimit)
+	routes.JWKSRoutes(r, jwksHandler)
+	routes.MFARoutes(r, mfaHandler, authMiddleware, authRateLimit)
+	routes.AccountRoutes(r, accountHandler, authMiddleware)
+	routes.PhoneRoutes(r, phoneHandler, authMiddleware)
+	routes.PersonalAccessTokenRoutes(r, personalAccessTokenHandler, authMiddleware)
+	routes.SessionRoutes(r, sessionHandler, authMiddleware)
+	routes.PasswordRoutes(r, passwordHandler, authRateLimit)
+	routes.EmailVerificationRoutes(r, emailVerificationHandler, authRateLimit)
+	routes.UserRouter(r, userHandler, authMiddleware, middleware.RequireIfMatch(config.AppConfig.RequireIfMatch))
+	routes.RoleRouter(r, roleHandler, authMiddleware)
+	routes.LockoutRouter(r, lockoutHandler, authMiddleware)
+	routes.AccountStatusRouter(r, accountStatusHandler, authMiddleware)
 
 	log.Println("Starting server on :8080")
 	err = r.Run(":8080")
@@ -70,10 +295,55 @@ func main() {
 	}
 }
 
+// newRevocationStore selects the token revocation backend from configuration.
+func newRevocationStore(db *gorm.DB) repositories.RevocationStore {
+	if config.AppConfig.RevocationStore == "memory" {
+		log.Println("Using in-memory token revocation store")
+		return repositories.NewMemoryRevocationStore()
+	}
+	return repositories.NewPostgresRevocationStore(db)
+}
+
+// bootstrapAdmin grants the admin role to the configured user so that roles can be managed.
+func bootstrapAdmin(db *gorm.DB, roleRepo repositories.RoleRepository) {
+	username := config.AppConfig.BootstrapAdminUsername
+	if username == "" {
+		return
+	}
+
+	user, err := repositories.NewUserRepository(db).FindByUsername(username)
+	if err != nil {
+		log.Println("Bootstrap admin not found:", username)
+		return
+	}
+
+	roles, err := roleRepo.GetUserRoles(user.ID)
+	if err != nil {
+		log.Println("Failed to read bootstrap admin roles:", err)
+		return
+	}
+	for _, role := range roles {
+		if role.Name == models.RoleAdmin {
+			return
+		}
+	}
+
+	adminRole, err := roleRepo.GetByName(models.RoleAdmin)
+	if err != nil {
+		log.Println("Failed to find admin role:", err)
+		return
+	}
+	if err := roleRepo.SetUserRoles(user.ID, append(roles, adminRole)); err != nil {
+		log.Println("Failed to grant admin role:", err)
+		return
+	}
+	log.Println("Admin role granted to:", username)
+}
+
 // createDummyUser creates a dummy user if it doesn't already exist.
 func createDummyUser(db *gorm.DB) {
 	userRepo := repositories.NewUserRepository(db)
-	userService := services.NewUserService(userRepo)
+	userService := services.NewUserService(userRepo, nil, nil, nil, models.UserStatusActive)
 
 	age := 25
 	dummyUserDTO := dto.CreateUserDTO{
@@ -1,68 +1,149 @@
 package middleware
 
 import (
-	"net/http"
+	"errors"
 	"strings"
 
+	"gin-user-app/repositories"
+	"gin-user-app/services"
+
+	"gin-user-app/apperrors"
+
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 )
 
-// AuthMiddleware untuk proteksi route dengan JWT
-func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
+// Nilai "auth_type" di context, diisi AuthMiddleware sesuai jenis token
+const (
+	AuthTypeAccessToken         = "access_token"
+	AuthTypePersonalAccessToken = "personal_access_token"
+)
+
+// AuthMiddleware untuk proteksi route dengan JWT atau personal access token.
+// Signature, algoritma dan claim JWT (iss, aud, exp, nbf, token_use) diverifikasi
+// oleh TokenService. Status akun dicek untuk setiap token, sehingga token milik
+// user yang tidak aktif (misalnya di-suspend) ditolak meskipun pencabutan saat
+// perubahan status gagal atau belum dijalankan.
+func AuthMiddleware(tokens services.TokenService, revocations repositories.RevocationStore, pats services.PersonalAccessTokenService, sessions services.SessionService, statuses services.AccountStatusService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Ambil token dari header Authorization
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization token required"})
-			c.Abort()
+			abortWithProblem(c, apperrors.Unauthorized("Authorization token required"))
 			return
 		}
 
 		// Format token harus "Bearer <token>"
 		tokenParts := strings.Split(authHeader, " ")
 		if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token format"})
-			c.Abort()
+			abortWithProblem(c, apperrors.Unauthorized("Invalid token format"))
 			return
 		}
 
-		tokenString := tokenParts[1]
+		// Personal access token dikenali dari prefix-nya
+		if services.IsPersonalAccessToken(tokenParts[1]) {
+			authenticatePersonalAccessToken(c, pats, statuses, tokenParts[1])
+			return
+		}
 
-		// Parse token
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.ErrSignatureInvalid
-			}
-			return []byte(jwtSecret), nil
-		})
+		// Hanya access token yang boleh dipakai; challenge mfa_pending ditolak
+		claims, err := tokens.Verify(tokenParts[1], services.TokenUseAccess)
+		if err == nil && claims.SessionID == "" {
+			err = services.ErrInvalidToken
+		}
+		if err != nil {
+			abortWithProblem(c, apperrors.Unauthorized("Invalid or expired token"))
+			return
+		}
+		userID := claims.UserID()
 
-		// Jika token tidak valid atau error parsing
-		if err != nil || !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
-			c.Abort()
+		// Tolak token yang sudah di-logout
+		revoked, err := revocations.IsRevoked(claims.ID, userID, claims.IssuedAt.Time)
+		if err != nil {
+			abortWithProblem(c, err)
+			return
+		}
+		if revoked {
+			abortWithProblem(c, apperrors.Unauthorized("Token has been revoked"))
 			return
 		}
 
-		// Ambil user_id dari token
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
-			c.Abort()
+		// Tolak token dari sesi yang sudah dicabut
+		err = sessions.Validate(claims.SessionID, userID, c.ClientIP())
+		if errors.Is(err, services.ErrSessionRevoked) {
+			abortWithProblem(c, apperrors.Unauthorized("Session has been revoked"))
+			return
+		}
+		if err != nil {
+			abortWithProblem(c, err)
 			return
 		}
 
-		// Pastikan user_id ada dalam token dan bertipe float64 sebelum dikonversi ke int
-		userIDFloat, exists := claims["user_id"].(float64)
-		if !exists {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in token"})
-			c.Abort()
+		// Tolak token milik user yang di-suspend, dikunci atau dinonaktifkan
+		if err := statuses.CheckActive(userID); err != nil {
+			abortWithProblem(c, err)
 			return
 		}
 
-		// Simpan user_id di context agar bisa digunakan di handler
-		c.Set("user_id", int(userIDFloat))
+		// Simpan user_id di context agar bisa digunakan di handler.
+		// token_expires_at memperhitungkan clock skew agar revocation saat logout
+		// disimpan selama token masih bisa diterima.
+		c.Set("user_id", userID)
+		c.Set("auth_type", AuthTypeAccessToken)
+		c.Set("jti", claims.ID)
+		c.Set("session_id", claims.SessionID)
+		c.Set("token_expires_at", claims.ExpiresAt.Add(tokens.ClockSkew()))
+		c.Set("roles", nonNil(claims.Roles))
+		c.Set("permissions", nonNil(claims.Permissions))
 
 		c.Next() // Lanjutkan ke handler berikutnya
 	}
 }
+
+// authenticatePersonalAccessToken memverifikasi personal access token dan mengisi
+// context dengan permission sesuai scope token
+func authenticatePersonalAccessToken(c *gin.Context, pats services.PersonalAccessTokenService, statuses services.AccountStatusService, token string) {
+	grant, err := pats.Authenticate(token, c.ClientIP())
+	if errors.Is(err, services.ErrInvalidPersonalAccessToken) {
+		abortWithProblem(c, apperrors.Unauthorized("Invalid or expired token"))
+		return
+	}
+	if err != nil {
+		abortWithProblem(c, err)
+		return
+	}
+	// Personal access token tidak ikut dicabut saat suspend, jadi status user
+	// dicek setiap request
+	if err := statuses.CheckActive(grant.UserID); err != nil {
+		abortWithProblem(c, err)
+		return
+	}
+
+	c.Set("user_id", grant.UserID)
+	c.Set("auth_type", AuthTypePersonalAccessToken)
+	c.Set("personal_access_token_id", grant.TokenID)
+	c.Set("roles", nonNil(grant.Roles))
+	c.Set("permissions", nonNil(grant.Permissions))
+
+	c.Next()
+}
+
+// RequireAccessToken menolak personal access token pada endpoint yang hanya
+// boleh dipakai dari sesi login, misalnya membuat token baru atau mengatur MFA.
+// Harus dipasang setelah AuthMiddleware.
+func RequireAccessToken() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("auth_type") != AuthTypeAccessToken {
+			abortWithProblem(c, apperrors.Forbidden("This endpoint requires a login session"))
+			return
+		}
+		c.Next()
+	}
+}
+
+// nonNil mengganti slice nil dengan slice kosong
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
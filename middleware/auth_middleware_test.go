@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"gin-user-app/repositories"
+	"gin-user-app/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+// stubTokens menerima setiap token sebagai access token milik userID
+type stubTokens struct {
+	services.TokenService
+	userID int
+}
+
+func (s stubTokens) Verify(tokenString, use string) (*services.TokenClaims, error) {
+	return &services.TokenClaims{
+		TokenUse:  use,
+		SessionID: "session-1",
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        "jti-1",
+			Subject:   strconv.Itoa(s.userID),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		},
+	}, nil
+}
+
+func (s stubTokens) ClockSkew() time.Duration {
+	return 0
+}
+
+// stubSessions menganggap setiap sesi masih aktif
+type stubSessions struct {
+	services.SessionService
+}
+
+func (stubSessions) Validate(sessionID string, userID int, ip string) error {
+	return nil
+}
+
+// stubStatuses mengembalikan err untuk setiap user
+type stubStatuses struct {
+	services.AccountStatusService
+	err error
+}
+
+func (s stubStatuses) CheckActive(userID int) error {
+	return s.err
+}
+
+// serveWithAuth menjalankan satu request dengan access token lewat AuthMiddleware
+func serveWithAuth(statuses services.AccountStatusService) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(AuthMiddleware(stubTokens{userID: 7}, repositories.NewMemoryRevocationStore(), nil, stubSessions{}, statuses))
+	r.GET("/me", func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	recorder := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/me", nil)
+	req.Header.Set("Authorization", "Bearer access-token")
+	r.ServeHTTP(recorder, req)
+	return recorder
+}
+
+// TestAuthMiddleware_AccessTokenOfInactiveUserUnit menguji bahwa access token yang belum dicabut tetap ditolak jika user tidak aktif
+func TestAuthMiddleware_AccessTokenOfInactiveUserUnit(t *testing.T) {
+	assert.Equal(t, http.StatusNoContent, serveWithAuth(stubStatuses{}).Code)
+	assert.Equal(t, http.StatusForbidden, serveWithAuth(stubStatuses{err: services.ErrAccountSuspended}).Code)
+}
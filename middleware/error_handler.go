@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+
+	"gin-user-app/apperrors"
+	"gin-user-app/dto"
+
+	"github.com/gin-gonic/gin"
+)
+
+// kindStatus memetakan jenis error domain ke status HTTP
+var kindStatus = map[error]int{
+	apperrors.ErrBadRequest:           http.StatusBadRequest,
+	apperrors.ErrValidation:           http.StatusBadRequest,
+	apperrors.ErrUnauthorized:         http.StatusUnauthorized,
+	apperrors.ErrForbidden:            http.StatusForbidden,
+	apperrors.ErrNotFound:             http.StatusNotFound,
+	apperrors.ErrConflict:             http.StatusConflict,
+	apperrors.ErrPreconditionFailed:   http.StatusPreconditionFailed,
+	apperrors.ErrUnsupportedMediaType: http.StatusUnsupportedMediaType,
+	apperrors.ErrLocked:               http.StatusLocked,
+	apperrors.ErrPreconditionRequired: http.StatusPreconditionRequired,
+	apperrors.ErrTooManyRequests:      http.StatusTooManyRequests,
+}
+
+// ErrorHandler merender error terakhir yang dicatat handler dengan c.Error
+// sebagai application/problem+json, kecuali handler sudah menulis response.
+func ErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		WriteProblem(c, c.Errors.Last().Err)
+	}
+}
+
+// WriteProblem menulis err sebagai problem+json. Status ditentukan dari jenis
+// error domain; error tanpa jenis dicatat di log dan dijawab 500 tanpa detail
+// supaya pesan internal (misalnya dari database) tidak bocor ke client.
+func WriteProblem(c *gin.Context, err error) {
+	problem := dto.ProblemDTO{
+		Type:     "about:blank",
+		Status:   http.StatusInternalServerError,
+		Instance: c.Request.URL.Path,
+	}
+	if status, ok := kindStatus[apperrors.KindOf(err)]; ok {
+		problem.Status = status
+		problem.Detail = err.Error()
+		problem.Errors = apperrors.Fields(err)
+	} else {
+		log.Printf("%s %s: %v", c.Request.Method, c.Request.URL.Path, err)
+	}
+	problem.Title = http.StatusText(problem.Status)
+
+	c.Header("Content-Type", dto.ProblemContentType)
+	c.JSON(problem.Status, problem)
+}
+
+// abortWithProblem menghentikan chain dan langsung merender err, dipakai
+// middleware yang menolak request sebelum sampai ke handler
+func abortWithProblem(c *gin.Context, err error) {
+	WriteProblem(c, err)
+	c.Abort()
+}
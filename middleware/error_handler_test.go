@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gin-user-app/apperrors"
+	"gin-user-app/dto"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// serveError menjalankan handler yang mencatat err lewat ErrorHandler
+func serveError(err error) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(ErrorHandler())
+	r.GET("/users/1", func(c *gin.Context) {
+		c.Error(err)
+	})
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+	return recorder
+}
+
+// TestErrorHandler_RendersProblemUnit menguji bahwa error domain dirender sebagai problem+json sesuai jenisnya
+func TestErrorHandler_RendersProblemUnit(t *testing.T) {
+	err := fmt.Errorf("create user: %w", apperrors.Conflict("email already taken", "email"))
+	recorder := serveError(err)
+
+	assert.Equal(t, http.StatusConflict, recorder.Code)
+	assert.Equal(t, dto.ProblemContentType, recorder.Header().Get("Content-Type"))
+
+	var problem dto.ProblemDTO
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &problem))
+	assert.Equal(t, "about:blank", problem.Type)
+	assert.Equal(t, "Conflict", problem.Title)
+	assert.Equal(t, "create user: email already taken", problem.Detail)
+	assert.Equal(t, "/users/1", problem.Instance)
+	assert.Equal(t, []apperrors.FieldError{{Field: "email", Code: apperrors.CodeTaken, Message: "email already taken"}}, problem.Errors)
+}
+
+// TestErrorHandler_HidesUnknownErrorsUnit menguji bahwa error tanpa jenis menjadi 500 tanpa detail
+func TestErrorHandler_HidesUnknownErrorsUnit(t *testing.T) {
+	recorder := serveError(errors.New("pq: connection refused"))
+
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+	assert.NotContains(t, recorder.Body.String(), "connection refused")
+}
@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"strconv"
+	"strings"
+
+	"gin-user-app/apperrors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireIfMatch menolak request tanpa header If-Match dengan 428 Precondition
+// Required jika required bernilai true, supaya client tidak bisa menimpa
+// perubahan orang lain tanpa sadar. "*" juga ditolak karena cocok dengan versi
+// manapun; yang diterima hanya ETag versi tertentu. Jika false, middleware ini
+// tidak melakukan apa-apa.
+func RequireIfMatch(required bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !required {
+			c.Next()
+			return
+		}
+		switch strings.TrimSpace(c.GetHeader("If-Match")) {
+		case "":
+			abortWithProblem(c, apperrors.New(apperrors.ErrPreconditionRequired, "If-Match header is required"))
+			return
+		case "*":
+			abortWithProblem(c, apperrors.New(apperrors.ErrPreconditionRequired, "If-Match must be the ETag of a version, not *"))
+			return
+		}
+		c.Next()
+	}
+}
+
+// ETag membentuk strong entity tag dari versi resource
+func ETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// IfMatchVersion membaca versi yang diharapkan dari header If-Match.
+// Header kosong atau "*" menghasilkan 0 (tanpa cek versi; "*" sudah ditolak
+// RequireIfMatch jika If-Match wajib). ok bernilai false
+// jika header tidak mungkin cocok dengan versi manapun, misalnya weak tag,
+// daftar beberapa tag atau tag yang bukan versi.
+func IfMatchVersion(c *gin.Context) (version int, ok bool) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return 0, true
+	}
+	if len(header) < 2 || header[0] != '"' || header[len(header)-1] != '"' {
+		return 0, false
+	}
+	version, err := strconv.Atoi(header[1 : len(header)-1])
+	if err != nil || version < 1 {
+		return 0, false
+	}
+	return version, true
+}
+
+// IfNoneMatch melaporkan apakah header If-None-Match cocok dengan etag.
+// Perbandingannya weak seperti yang diminta RFC 9110 untuk GET.
+func IfNoneMatch(c *gin.Context, etag string) bool {
+	header := strings.TrimSpace(c.GetHeader("If-None-Match"))
+	if header == "" {
+		return false
+	}
+	if header == "*" {
+		return true
+	}
+	for _, tag := range strings.Split(header, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(tag), "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
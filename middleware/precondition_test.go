@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// newPreconditionContext membuat gin.Context dengan header yang diberikan
+func newPreconditionContext(header, value string) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(http.MethodPut, "/users/1", nil)
+	if value != "" {
+		c.Request.Header.Set(header, value)
+	}
+	return c, recorder
+}
+
+// TestIfMatchVersion_ParsesStrongTagsUnit menguji parsing header If-Match
+func TestIfMatchVersion_ParsesStrongTagsUnit(t *testing.T) {
+	cases := []struct {
+		header  string
+		version int
+		ok      bool
+	}{
+		{"", 0, true},
+		{"*", 0, true},
+		{`"3"`, 3, true},
+		{`W/"3"`, 0, false},
+		{`"abc"`, 0, false},
+		{`"2", "3"`, 0, false},
+	}
+	for _, tc := range cases {
+		c, _ := newPreconditionContext("If-Match", tc.header)
+		version, ok := IfMatchVersion(c)
+		assert.Equal(t, tc.version, version, tc.header)
+		assert.Equal(t, tc.ok, ok, tc.header)
+	}
+}
+
+// TestIfNoneMatch_WeakComparisonUnit menguji bahwa If-None-Match memakai perbandingan weak
+func TestIfNoneMatch_WeakComparisonUnit(t *testing.T) {
+	for _, header := range []string{`"3"`, `W/"3"`, `"1", "3"`, "*"} {
+		c, _ := newPreconditionContext("If-None-Match", header)
+		assert.True(t, IfNoneMatch(c, ETag(3)), header)
+	}
+	c, _ := newPreconditionContext("If-None-Match", `"2"`)
+	assert.False(t, IfNoneMatch(c, ETag(3)))
+}
+
+// TestRequireIfMatch_RejectsMissingHeaderUnit menguji bahwa request tanpa If-Match ditolak dengan 428
+func TestRequireIfMatch_RejectsMissingHeaderUnit(t *testing.T) {
+	c, recorder := newPreconditionContext("If-Match", "")
+	RequireIfMatch(true)(c)
+	assert.True(t, c.IsAborted())
+	assert.Equal(t, http.StatusPreconditionRequired, recorder.Code)
+
+	c, _ = newPreconditionContext("If-Match", `"1"`)
+	RequireIfMatch(true)(c)
+	assert.False(t, c.IsAborted())
+
+	c, _ = newPreconditionContext("If-Match", "")
+	RequireIfMatch(false)(c)
+	assert.False(t, c.IsAborted())
+}
+
+// TestRequireIfMatch_RejectsWildcardUnit menguji bahwa "*" ditolak dengan 428 jika If-Match wajib
+func TestRequireIfMatch_RejectsWildcardUnit(t *testing.T) {
+	c, recorder := newPreconditionContext("If-Match", "*")
+	RequireIfMatch(true)(c)
+	assert.True(t, c.IsAborted())
+	assert.Equal(t, http.StatusPreconditionRequired, recorder.Code)
+
+	c, _ = newPreconditionContext("If-Match", "*")
+	RequireIfMatch(false)(c)
+	assert.False(t, c.IsAborted())
+}
@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"gin-user-app/apperrors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IPRateLimiter membatasi jumlah request per IP dalam satu jendela waktu tetap.
+// Semua counter di-reset setiap jendela baru sehingga memori tetap terbatas.
+// Counter hanya ada di memori, jadi batasnya berlaku per instance.
+type IPRateLimiter struct {
+	mu          sync.Mutex
+	limit       int
+	window      time.Duration
+	windowStart time.Time
+	counts      map[string]int
+	now         func() time.Time
+}
+
+// NewIPRateLimiter membuat limiter dengan maksimal limit request per window
+func NewIPRateLimiter(limit int, window time.Duration) *IPRateLimiter {
+	return &IPRateLimiter{
+		limit:  limit,
+		window: window,
+		counts: make(map[string]int),
+		now:    time.Now,
+	}
+}
+
+// Allow mencatat satu request dari ip dan melaporkan apakah masih di bawah batas.
+// Jika tidak, retryAfter berisi sisa waktu sampai jendela berikutnya.
+func (l *IPRateLimiter) Allow(ip string) (allowed bool, retryAfter time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if now.Sub(l.windowStart) >= l.window {
+		l.windowStart = now
+		l.counts = make(map[string]int)
+	}
+
+	if l.counts[ip] >= l.limit {
+		return false, l.windowStart.Add(l.window).Sub(now)
+	}
+	l.counts[ip]++
+	return true, 0
+}
+
+// RateLimitByIP menolak request dengan 429 jika IP client melebihi batas limiter
+func RateLimitByIP(limiter *IPRateLimiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		allowed, retryAfter := limiter.Allow(c.ClientIP())
+		if !allowed {
+			SetRetryAfter(c, retryAfter)
+			abortWithProblem(c, apperrors.New(apperrors.ErrTooManyRequests, "Too many requests, please try again later"))
+			return
+		}
+		c.Next()
+	}
+}
+
+// SetRetryAfter menulis header Retry-After dalam detik (dibulatkan ke atas)
+func SetRetryAfter(c *gin.Context, retryAfter time.Duration) {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	c.Header("Retry-After", strconv.Itoa(seconds))
+}
@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestIPRateLimiter_LimitsPerIPUnit menguji bahwa batas dihitung per IP dan di-reset di jendela berikutnya
+func TestIPRateLimiter_LimitsPerIPUnit(t *testing.T) {
+	now := time.Now()
+	limiter := NewIPRateLimiter(2, time.Minute)
+	limiter.now = func() time.Time { return now }
+
+	allowed, _ := limiter.Allow("10.0.0.1")
+	assert.True(t, allowed)
+	allowed, _ = limiter.Allow("10.0.0.1")
+	assert.True(t, allowed)
+
+	allowed, retryAfter := limiter.Allow("10.0.0.1")
+	assert.False(t, allowed)
+	assert.Equal(t, time.Minute, retryAfter)
+
+	allowed, _ = limiter.Allow("10.0.0.2")
+	assert.True(t, allowed)
+
+	now = now.Add(time.Minute)
+	allowed, _ = limiter.Allow("10.0.0.1")
+	assert.True(t, allowed)
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"strconv"
+
+	"gin-user-app/apperrors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequirePermission hanya meneruskan request jika token memiliki permission tersebut.
+// Harus dipasang setelah AuthMiddleware.
+func RequirePermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !HasPermission(c, permission) {
+			abortWithProblem(c, apperrors.Forbidden("Insufficient permissions"))
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireSelfOrPermission meneruskan request jika parameter path menunjuk ke user
+// yang sedang login, atau jika token memiliki permission tersebut. Personal access
+// token selalu dibatasi scope-nya, juga untuk data milik sendiri.
+func RequireSelfOrPermission(param, permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		targetID, err := strconv.Atoi(c.Param(param))
+		isSelf := err == nil && targetID == c.GetInt("user_id")
+		if isSelf && c.GetString("auth_type") != AuthTypePersonalAccessToken {
+			c.Next()
+			return
+		}
+		if !HasPermission(c, permission) {
+			abortWithProblem(c, apperrors.Forbidden("You can only modify your own account"))
+			return
+		}
+		c.Next()
+	}
+}
+
+// HasPermission mengecek permission yang disimpan AuthMiddleware di context
+func HasPermission(c *gin.Context, permission string) bool {
+	for _, granted := range c.GetStringSlice("permissions") {
+		if granted == permission {
+			return true
+		}
+	}
+	return false
+}
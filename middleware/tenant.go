@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Tenant membaca tenant request dari header dan menyimpannya di context dengan
+// key "tenant". Header harus diisi gateway yang dipercaya, bukan oleh client,
+// karena tenant menentukan kebijakan domain email. Jika header kosong,
+// middleware ini tidak melakukan apa-apa dan semua request memakai kebijakan default.
+func Tenant(header string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if header != "" {
+			if tenant := strings.TrimSpace(c.GetHeader(header)); tenant != "" {
+				c.Set("tenant", tenant)
+			}
+		}
+		c.Next()
+	}
+}
+
+// TenantOf mengembalikan tenant request, string kosong berarti kebijakan default
+func TenantOf(c *gin.Context) string {
+	return c.GetString("tenant")
+}
@@ -0,0 +1,34 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// IdentityCollision adalah user yang username atau email kanoniknya sudah
+// dipakai user lain yang lebih lama saat backfill migrasi 0015. Kolom
+// kanonik user tersebut dibiarkan NULL sampai username atau emailnya diganti.
+type IdentityCollision struct {
+	UserID         int
+	Field          string
+	CanonicalValue string
+	KeptUserID     int
+	DetectedAt     time.Time
+}
+
+func (IdentityCollision) TableName() string {
+	return "user_identity_collisions"
+}
+
+// IdentityCollisions mengembalikan bentrokan yang ditemukan backfill dan
+// masih belum diselesaikan, yaitu user yang kolom kanoniknya masih NULL
+func IdentityCollisions(db *gorm.DB) ([]IdentityCollision, error) {
+	var collisions []IdentityCollision
+	err := db.Model(&IdentityCollision{}).
+		Joins("JOIN users ON users.id = user_identity_collisions.user_id").
+		Where("(field = 'username' AND users.username_canonical IS NULL) OR (field = 'email' AND users.email_canonical IS NULL)").
+		Order("user_identity_collisions.id").
+		Find(&collisions).Error
+	return collisions, err
+}
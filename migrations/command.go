@@ -0,0 +1,68 @@
+package migrations
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	"gorm.io/gorm"
+)
+
+// RunCommand menjalankan subcommand "migrate up|down [n]|status|redo" dan menulis hasilnya ke out
+func RunCommand(db *gorm.DB, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: migrate up|down [n]|status|redo|collisions")
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := Up(db)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Applied %d migration(s)\n", applied)
+	case "down":
+		n := 1
+		if len(args) > 1 {
+			parsed, err := strconv.Atoi(args[1])
+			if err != nil || parsed < 1 {
+				return fmt.Errorf("invalid number of migrations to revert: %s", args[1])
+			}
+			n = parsed
+		}
+		reverted, err := Down(db, n)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Reverted %d migration(s)\n", reverted)
+	case "redo":
+		if err := Redo(db); err != nil {
+			return err
+		}
+		fmt.Fprintln(out, "Redid last migration")
+	case "status":
+		statuses, err := GetStatus(db)
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			state := "pending"
+			if status.AppliedAt != nil {
+				state = "applied " + status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(out, "%04d_%-40s %s\n", status.Version, status.Name, state)
+		}
+	case "collisions":
+		collisions, err := IdentityCollisions(db)
+		if err != nil {
+			return err
+		}
+		for _, collision := range collisions {
+			fmt.Fprintf(out, "user %d: %s %q already used by user %d\n", collision.UserID, collision.Field, collision.CanonicalValue, collision.KeptUserID)
+		}
+		fmt.Fprintf(out, "%d unresolved identity collision(s)\n", len(collisions))
+	default:
+		return fmt.Errorf("unknown migrate command %q (use up, down, status, redo or collisions)", args[0])
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package migrations
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestLoad_EmbeddedMigrationsUnit memastikan migrasi yang di-embed valid dan berurutan
+func TestLoad_EmbeddedMigrationsUnit(t *testing.T) {
+	migrations, err := Load()
+	assert.NoError(t, err)
+	assert.NotEmpty(t, migrations)
+
+	for i, migration := range migrations {
+		assert.Equal(t, int64(i+1), migration.Version, "migration versions must be contiguous")
+		assert.NotEmpty(t, migration.Up)
+		assert.NotEmpty(t, migration.Down)
+	}
+}
+
+// TestLoad_MissingDownUnit memastikan migrasi tanpa file down ditolak
+func TestLoad_MissingDownUnit(t *testing.T) {
+	fsys := fstest.MapFS{
+		"sql/0001_create_things.up.sql": {Data: []byte("CREATE TABLE things (id INT);")},
+	}
+
+	_, err := loadFrom(fsys, "sql")
+	assert.Error(t, err)
+}
+
+// TestLoad_InvalidFileNameUnit memastikan nama file yang tidak sesuai format ditolak
+func TestLoad_InvalidFileNameUnit(t *testing.T) {
+	fsys := fstest.MapFS{
+		"sql/create_things.sql": {Data: []byte("CREATE TABLE things (id INT);")},
+	}
+
+	_, err := loadFrom(fsys, "sql")
+	assert.Error(t, err)
+}
@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS users (
+    id SERIAL PRIMARY KEY,
+    username VARCHAR(50) NOT NULL,
+    email VARCHAR(100) NOT NULL,
+    password VARCHAR(255) NOT NULL,
+    first_name VARCHAR(50) NOT NULL,
+    last_name VARCHAR(50) NOT NULL,
+    age INT DEFAULT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMPTZ DEFAULT NULL
+);
+
+-- Database lama yang dibuat AutoMigrate sudah punya constraint uni_users_*
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'uni_users_username') THEN
+        ALTER TABLE users ADD CONSTRAINT uni_users_username UNIQUE (username);
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'uni_users_email') THEN
+        ALTER TABLE users ADD CONSTRAINT uni_users_email UNIQUE (email);
+    END IF;
+END $$;
+
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users (deleted_at);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_users_last_name_lower;
+DROP INDEX IF EXISTS idx_users_first_name_lower;
+DROP INDEX IF EXISTS idx_users_email_lower;
+DROP INDEX IF EXISTS idx_users_username_lower;
+DROP INDEX IF EXISTS idx_users_created_at_id;
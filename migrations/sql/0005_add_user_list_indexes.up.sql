@@ -0,0 +1,6 @@
+-- Index untuk sorting dan filter prefix pada GET /users
+CREATE INDEX IF NOT EXISTS idx_users_created_at_id ON users (created_at, id);
+CREATE INDEX IF NOT EXISTS idx_users_username_lower ON users (LOWER(username) text_pattern_ops);
+CREATE INDEX IF NOT EXISTS idx_users_email_lower ON users (LOWER(email) text_pattern_ops);
+CREATE INDEX IF NOT EXISTS idx_users_first_name_lower ON users (LOWER(first_name) text_pattern_ops);
+CREATE INDEX IF NOT EXISTS idx_users_last_name_lower ON users (LOWER(last_name) text_pattern_ops);
@@ -0,0 +1,11 @@
+CREATE TABLE signing_keys (
+    kid VARCHAR(64) PRIMARY KEY,
+    algorithm VARCHAR(16) NOT NULL,
+    private_key TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    retired_at TIMESTAMPTZ DEFAULT NULL,
+    expires_at TIMESTAMPTZ DEFAULT NULL
+);
+
+CREATE INDEX idx_signing_keys_created_at ON signing_keys (created_at);
+CREATE INDEX idx_signing_keys_expires_at ON signing_keys (expires_at);
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS user_identity_collisions;
+DROP INDEX IF EXISTS uni_users_email_canonical;
+DROP INDEX IF EXISTS uni_users_username_canonical;
+ALTER TABLE users DROP COLUMN IF EXISTS email_canonical;
+ALTER TABLE users DROP COLUMN IF EXISTS username_canonical;
@@ -0,0 +1,60 @@
+-- Bentuk kanonik username dan email, dihitung aplikasi dengan
+-- utils.CanonicalUsername dan utils.CanonicalEmail
+ALTER TABLE users ADD COLUMN username_canonical VARCHAR(50) DEFAULT NULL;
+ALTER TABLE users ADD COLUMN email_canonical VARCHAR(100) DEFAULT NULL;
+
+-- Backfill: NFKC lalu lowercase, ditambah aturan Gmail (titik dan bagian
+-- setelah "+" dibuang, googlemail.com menjadi gmail.com)
+UPDATE users SET
+    username_canonical = lower(normalize(btrim(username), NFKC)),
+    email_canonical = lower(normalize(btrim(email), NFKC));
+
+UPDATE users SET
+    email_canonical = replace(split_part(split_part(email_canonical, '@', 1), '+', 1), '.', '') || '@gmail.com'
+WHERE split_part(email_canonical, '@', 2) IN ('gmail.com', 'googlemail.com');
+
+-- User yang bentrok dengan user lain yang lebih lama dicatat di sini dan
+-- kolom kanoniknya dikosongkan; mereka tetap bisa login dengan nilai aslinya
+-- dan harus mengganti username atau email sebelum data lain bisa disimpan.
+CREATE TABLE user_identity_collisions (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    field VARCHAR(20) NOT NULL,
+    canonical_value VARCHAR(100) NOT NULL,
+    kept_user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    detected_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO user_identity_collisions (user_id, field, canonical_value, kept_user_id)
+SELECT id, 'username', username_canonical, kept_user_id
+FROM (
+    SELECT id, username_canonical, first_value(id) OVER (PARTITION BY username_canonical ORDER BY id) AS kept_user_id
+    FROM users
+) ranked
+WHERE id <> kept_user_id;
+
+INSERT INTO user_identity_collisions (user_id, field, canonical_value, kept_user_id)
+SELECT id, 'email', email_canonical, kept_user_id
+FROM (
+    SELECT id, email_canonical, first_value(id) OVER (PARTITION BY email_canonical ORDER BY id) AS kept_user_id
+    FROM users
+) ranked
+WHERE id <> kept_user_id;
+
+UPDATE users SET username_canonical = NULL
+WHERE id IN (SELECT user_id FROM user_identity_collisions WHERE field = 'username');
+UPDATE users SET email_canonical = NULL
+WHERE id IN (SELECT user_id FROM user_identity_collisions WHERE field = 'email');
+
+CREATE UNIQUE INDEX uni_users_username_canonical ON users (username_canonical);
+CREATE UNIQUE INDEX uni_users_email_canonical ON users (email_canonical);
+
+DO $$
+DECLARE
+    collisions INT;
+BEGIN
+    SELECT count(*) INTO collisions FROM user_identity_collisions;
+    IF collisions > 0 THEN
+        RAISE WARNING '% identity collision(s) found, see table user_identity_collisions or run "migrate collisions"', collisions;
+    END IF;
+END $$;
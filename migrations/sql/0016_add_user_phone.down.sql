@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS uni_users_phone;
+ALTER TABLE users DROP COLUMN IF EXISTS phone_verified_at;
+ALTER TABLE users DROP COLUMN IF EXISTS phone;
@@ -0,0 +1,6 @@
+-- Nomor telepon dalam format E.164, hanya nomor yang sudah diverifikasi yang
+-- bisa dipakai untuk login
+ALTER TABLE users ADD COLUMN phone VARCHAR(16) DEFAULT NULL;
+ALTER TABLE users ADD COLUMN phone_verified_at TIMESTAMPTZ DEFAULT NULL;
+
+CREATE UNIQUE INDEX uni_users_phone ON users (phone);
@@ -0,0 +1,14 @@
+-- Waktu password terakhir diganti untuk PASSWORD_MAX_AGE. User lama dianggap
+-- baru mengganti password agar tidak semuanya dipaksa ganti saat deploy.
+ALTER TABLE users ADD COLUMN password_changed_at TIMESTAMPTZ DEFAULT NULL;
+UPDATE users SET password_changed_at = CURRENT_TIMESTAMP;
+
+-- Hash password yang sudah diganti, untuk menolak password yang dipakai ulang
+CREATE TABLE password_histories (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    password_hash VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_password_histories_user_id ON password_histories (user_id);
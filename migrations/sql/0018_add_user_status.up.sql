@@ -0,0 +1,11 @@
+-- Status akun: hanya user active yang bisa login. suspended dan locked bisa
+-- punya waktu berakhir, setelah itu user dianggap active lagi.
+ALTER TABLE users ADD COLUMN status VARCHAR(16) NOT NULL DEFAULT 'active';
+ALTER TABLE users ADD CONSTRAINT chk_users_status
+    CHECK (status IN ('active', 'pending', 'suspended', 'locked', 'deactivated'));
+ALTER TABLE users ADD COLUMN status_reason TEXT NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN status_changed_at TIMESTAMPTZ DEFAULT NULL;
+ALTER TABLE users ADD COLUMN status_changed_by INT DEFAULT NULL REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE users ADD COLUMN status_expires_at TIMESTAMPTZ DEFAULT NULL;
+
+CREATE INDEX idx_users_status ON users (status);
@@ -0,0 +1 @@
+ALTER TABLE user_mfa DROP COLUMN IF EXISTS locked_until;
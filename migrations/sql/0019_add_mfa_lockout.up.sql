@@ -0,0 +1,2 @@
+-- Setelah terlalu banyak kode salah, verifikasi MFA ditolak sampai locked_until
+ALTER TABLE user_mfa ADD COLUMN locked_until TIMESTAMPTZ DEFAULT NULL;
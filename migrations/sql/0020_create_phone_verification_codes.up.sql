@@ -0,0 +1,14 @@
+-- Kode verifikasi nomor telepon yang dikirim lewat SMS. Nomor di users.phone
+-- hanya diisi setelah kodenya dikonfirmasi.
+CREATE TABLE phone_verification_codes (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    phone VARCHAR(16) NOT NULL,
+    code_hash VARCHAR(64) NOT NULL,
+    failed_attempts INT NOT NULL DEFAULT 0,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ DEFAULT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_phone_verification_codes_user_id ON phone_verification_codes (user_id);
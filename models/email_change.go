@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// EmailChangeToken stores the hash of a single-use token that confirms a new
+// email address. The user's email only changes when the token is used.
+type EmailChangeToken struct {
+	ID        int        `json:"id" gorm:"primaryKey"`
+	UserID    int        `json:"user_id" gorm:"index;not null"`
+	NewEmail  string     `json:"new_email" gorm:"not null"`
+	TokenHash string     `json:"-" gorm:"uniqueIndex;not null"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// EmailVerificationToken stores the hash of a single-use email verification
+// token. Email is the address the token was sent to; the token stops working
+// when the user's email changes.
+type EmailVerificationToken struct {
+	ID        int        `json:"id" gorm:"primaryKey"`
+	UserID    int        `json:"user_id" gorm:"index;not null"`
+	Email     string     `json:"email" gorm:"not null"`
+	TokenHash string     `json:"-" gorm:"uniqueIndex;not null"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// LoginAttempt counts consecutive failed logins of a user. The row is removed
+// after a successful login or when an admin clears the lockout.
+type LoginAttempt struct {
+	UserID         int        `json:"user_id" gorm:"primaryKey;autoIncrement:false"`
+	FailedAttempts int        `json:"failed_attempts" gorm:"not null;default:0"`
+	LastFailedAt   time.Time  `json:"last_failed_at" gorm:"not null"`
+	LockedUntil    *time.Time `json:"locked_until" gorm:"index"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// UserMFA holds the TOTP enrollment of a user. Two-factor authentication is
+// only enforced once ConfirmedAt is set. Codes are refused until LockedUntil
+// after too many wrong ones.
+type UserMFA struct {
+	UserID         int        `json:"user_id" gorm:"primaryKey;autoIncrement:false"`
+	Secret         string     `json:"-" gorm:"not null"`
+	ConfirmedAt    *time.Time `json:"confirmed_at"`
+	LastUsedStep   int64      `json:"-" gorm:"not null;default:0"`
+	FailedAttempts int        `json:"-" gorm:"not null;default:0"`
+	LockedUntil    *time.Time `json:"-"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// TableName keeps the table name readable instead of "user_mfas"
+func (UserMFA) TableName() string {
+	return "user_mfa"
+}
+
+// RecoveryCode is a hashed one-time code that can replace a TOTP code
+type RecoveryCode struct {
+	ID        int        `json:"id" gorm:"primaryKey"`
+	UserID    int        `json:"user_id" gorm:"index;not null"`
+	CodeHash  string     `json:"-" gorm:"not null"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// PasswordHistory keeps a replaced password hash of a user so that recent
+// passwords cannot be chosen again, see PASSWORD_HISTORY.
+type PasswordHistory struct {
+	ID           int       `json:"id" gorm:"primaryKey"`
+	UserID       int       `json:"user_id" gorm:"index;not null"`
+	PasswordHash string    `json:"-" gorm:"not null"`
+	CreatedAt    time.Time `json:"created_at"`
+}
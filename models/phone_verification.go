@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// PhoneVerificationCode stores the hash of a single-use code sent by SMS to
+// Phone. The user's phone only changes when the code is confirmed; a code is
+// burned after too many wrong guesses.
+type PhoneVerificationCode struct {
+	ID             int        `json:"id" gorm:"primaryKey"`
+	UserID         int        `json:"user_id" gorm:"index;not null"`
+	Phone          string     `json:"phone" gorm:"not null"`
+	CodeHash       string     `json:"-" gorm:"not null"`
+	FailedAttempts int        `json:"failed_attempts" gorm:"not null;default:0"`
+	ExpiresAt      time.Time  `json:"expires_at" gorm:"not null"`
+	UsedAt         *time.Time `json:"used_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// RefreshToken stores the hash of an opaque refresh token. Tokens issued from
+// the same login share a FamilyID so that the whole chain can be revoked when
+// an already-rotated token is presented again.
+type RefreshToken struct {
+	ID        int        `json:"id" gorm:"primaryKey"`
+	UserID    int        `json:"user_id" gorm:"index;not null"`
+	TokenHash string     `json:"-" gorm:"uniqueIndex;not null"`
+	FamilyID  string     `json:"family_id" gorm:"index;not null"`
+	ParentID  *int       `json:"parent_id"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null"`
+	RotatedAt *time.Time `json:"rotated_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
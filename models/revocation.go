@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// RevokedToken is a denylisted access token identified by its jti claim.
+// The row can be pruned once the token itself would have expired.
+type RevokedToken struct {
+	JTI       string    `json:"jti" gorm:"primaryKey;size:64"`
+	UserID    int       `json:"user_id" gorm:"index;not null"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"index;not null"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// UserRevocation invalidates every access token of a user issued at or
+// before RevokedBefore, used by "logout from all sessions".
+type UserRevocation struct {
+	UserID        int       `json:"user_id" gorm:"primaryKey;autoIncrement:false"`
+	RevokedBefore time.Time `json:"revoked_before" gorm:"not null"`
+	ExpiresAt     time.Time `json:"expires_at" gorm:"index;not null"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
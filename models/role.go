@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// Permission names checked by the RBAC middleware
+const (
+	PermissionUsersRead   = "users:read"
+	PermissionUsersCreate = "users:create"
+	PermissionUsersUpdate = "users:update"
+	PermissionUsersDelete = "users:delete"
+	PermissionUsersStatus = "users:status"
+	PermissionRolesRead   = "roles:read"
+	PermissionRolesManage = "roles:manage"
+	PermissionRolesAssign = "roles:assign"
+
+	PermissionLockoutsRead   = "lockouts:read"
+	PermissionLockoutsManage = "lockouts:manage"
+
+	PermissionSessionsRead   = "sessions:read"
+	PermissionSessionsManage = "sessions:manage"
+)
+
+// Built-in role names. Users without an explicit role get RoleUser.
+const (
+	RoleAdmin = "admin"
+	RoleUser  = "user"
+)
+
+// Permission is a single action that can be granted to a role
+type Permission struct {
+	ID          int    `json:"id" gorm:"primaryKey"`
+	Name        string `json:"name" gorm:"unique;not null"`
+	Description string `json:"description"`
+}
+
+// Role groups permissions and is assigned to users
+type Role struct {
+	ID          int          `json:"id" gorm:"primaryKey"`
+	Name        string       `json:"name" gorm:"unique;not null"`
+	Description string       `json:"description"`
+	Permissions []Permission `json:"permissions" gorm:"many2many:role_permissions"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// Session is created on every login and shares its ID with the refresh token
+// family of that login. Access tokens carry the ID in the sid claim, so
+// revoking a session also rejects its access tokens that are still alive.
+type Session struct {
+	ID         string     `json:"id" gorm:"primaryKey;size:32"`
+	UserID     int        `json:"user_id" gorm:"index;not null"`
+	UserAgent  string     `json:"user_agent" gorm:"size:512"`
+	IP         string     `json:"ip" gorm:"size:45"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastSeenAt time.Time  `json:"last_seen_at" gorm:"not null"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// SigningKey is a private key used to sign JWTs. The newest key that is not
+// retired signs new tokens; retired keys still verify tokens until ExpiresAt.
+type SigningKey struct {
+	KID        string     `json:"kid" gorm:"primaryKey;size:64"`
+	Algorithm  string     `json:"algorithm" gorm:"not null"`
+	PrivateKey string     `json:"-" gorm:"not null"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"index"`
+	RetiredAt  *time.Time `json:"retired_at"`
+	ExpiresAt  *time.Time `json:"expires_at" gorm:"index"`
+}
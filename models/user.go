@@ -5,15 +5,43 @@ import (
 	"time"
 )
 
+// Status akun user. Hanya user active yang bisa login dan memakai token;
+// transisi yang diperbolehkan diatur services.AccountStatusService.
+const (
+	UserStatusActive      = "active"
+	UserStatusPending     = "pending"
+	UserStatusSuspended   = "suspended"
+	UserStatusLocked      = "locked"
+	UserStatusDeactivated = "deactivated"
+)
+
+// User adalah akun pengguna. UsernameCanonical dan EmailCanonical diisi
+// repository dari utils.CanonicalUsername dan utils.CanonicalEmail, unik dan
+// dipakai untuk lookup; NULL untuk user lama yang bentrok saat backfill.
+// PasswordChangedAt dipakai untuk masa berlaku password (PASSWORD_MAX_AGE).
+// StatusExpiresAt mengakhiri status suspended atau locked secara otomatis.
 type User struct {
-	ID        int            `json:"id" gorm:"primaryKey"`
-	Username  string         `json:"username" gorm:"unique"`
-	Email     string         `json:"email" gorm:"unique"`
-	Password  string         `json:"-"`
-	FirstName string         `json:"first_name"`
-	LastName  string         `json:"last_name"`
-	Age       *int           `json:"age"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+	ID                int            `json:"id" gorm:"primaryKey"`
+	Username          string         `json:"username" gorm:"unique"`
+	Email             string         `json:"email" gorm:"unique"`
+	UsernameCanonical *string        `json:"-"`
+	EmailCanonical    *string        `json:"-"`
+	EmailVerifiedAt   *time.Time     `json:"email_verified_at"`
+	Phone             *string        `json:"phone"`
+	PhoneVerifiedAt   *time.Time     `json:"phone_verified_at"`
+	Password          string         `json:"-"`
+	PasswordChangedAt *time.Time     `json:"-"`
+	FirstName         string         `json:"first_name"`
+	LastName          string         `json:"last_name"`
+	Age               *int           `json:"age"`
+	Status            string         `json:"status" gorm:"not null;default:active"`
+	StatusReason      string         `json:"status_reason"`
+	StatusChangedAt   *time.Time     `json:"status_changed_at"`
+	StatusChangedBy   *int           `json:"status_changed_by"`
+	StatusExpiresAt   *time.Time     `json:"status_expires_at"`
+	Version           int            `json:"version" gorm:"not null;default:1"`
+	CreatedAt         time.Time      `json:"created_at" gorm:"index"`
+	UpdatedAt         time.Time      `json:"updated_at"`
+	DeletedAt         gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+	Roles             []Role         `json:"roles,omitempty" gorm:"many2many:user_roles"`
 }
@@ -1,11 +1,37 @@
 package repositories
 
 import (
+	"fmt"
 	"gin-user-app/models"
+	"gin-user-app/utils"
+	"strings"
+	"time"
 
 	"gorm.io/gorm"
 )
 
+// Login identifier types, see AuthRepository.FindByIdentifier
+const (
+	IdentifierUsername = "username"
+	IdentifierEmail    = "email"
+	IdentifierPhone    = "phone"
+)
+
+// ValidateIdentifierTypes checks that every configured login identifier type is known
+func ValidateIdentifierTypes(types []string) error {
+	if len(types) == 0 {
+		return fmt.Errorf("at least one login identifier type is required")
+	}
+	for _, identifierType := range types {
+		switch identifierType {
+		case IdentifierUsername, IdentifierEmail, IdentifierPhone:
+		default:
+			return fmt.Errorf("unknown login identifier type %q", identifierType)
+		}
+	}
+	return nil
+}
+
 // AuthRepository handles data access for authentication
 type AuthRepository struct {
 	db *gorm.DB
@@ -16,15 +42,72 @@ func NewAuthRepository(db *gorm.DB) *AuthRepository {
 	return &AuthRepository{db: db}
 }
 
-// GetUserByUsername finds a user by username
+// GetUserByUsername finds a user by the canonical form of the username, so
+// login is not case-sensitive
 func (r *AuthRepository) GetUserByUsername(username string) (*models.User, error) {
 	var user models.User
-	if err := r.db.Where("username = ?", username).First(&user).Error; err != nil {
+	if err := whereUsername(r.db, username).First(&user).Error; err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
+// ResolveIdentifier returns the type of a login identifier and whether that
+// type is in allowed. Values with "@" are emails, values starting with "+"
+// are phone numbers in E.164 and everything else is a username; the three
+// cannot be confused because usernames only contain letters and numbers.
+func ResolveIdentifier(identifier string, allowed []string) (identifierType string, ok bool) {
+	identifierType = IdentifierUsername
+	switch {
+	case strings.Contains(identifier, "@"):
+		identifierType = IdentifierEmail
+	case strings.HasPrefix(identifier, "+"):
+		identifierType = IdentifierPhone
+	}
+	return identifierType, containsType(allowed, identifierType)
+}
+
+// FindByIdentifier finds the user a login identifier belongs to, see
+// ResolveIdentifier. Only verified phone numbers match. Types that are not in
+// allowed return gorm.ErrRecordNotFound without a query, like unknown users,
+// so the response does not reveal which types are enabled.
+func (r *AuthRepository) FindByIdentifier(identifier string, allowed []string) (*models.User, error) {
+	identifier = strings.TrimSpace(identifier)
+	identifierType, ok := ResolveIdentifier(identifier, allowed)
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	var user models.User
+	var query *gorm.DB
+	switch identifierType {
+	case IdentifierEmail:
+		query = whereEmail(r.db, identifier)
+	case IdentifierPhone:
+		phone, ok := utils.CanonicalPhone(identifier)
+		if !ok {
+			return nil, gorm.ErrRecordNotFound
+		}
+		query = r.db.Where("phone = ? AND phone_verified_at IS NOT NULL", phone)
+	default:
+		query = whereUsername(r.db, identifier)
+	}
+	if err := query.First(&user).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// containsType reports whether types contains identifierType
+func containsType(types []string, identifierType string) bool {
+	for _, t := range types {
+		if t == identifierType {
+			return true
+		}
+	}
+	return false
+}
+
 // GetUserByID finds a user by ID
 func (r *AuthRepository) GetUserByID(id int) (*models.User, error) {
 	var user models.User
@@ -33,3 +116,13 @@ func (r *AuthRepository) GetUserByID(id int) (*models.User, error) {
 	}
 	return &user, nil
 }
+
+// ChangePassword stores a new password chosen by the user and when it was changed
+func (r *AuthRepository) ChangePassword(id int, hash string, at time.Time) error {
+	return r.db.Model(&models.User{}).Where("id = ?", id).Updates(map[string]interface{}{"password": hash, "password_changed_at": at, "version": gorm.Expr("version + 1")}).Error
+}
+
+// UpdatePassword replaces the stored password hash of a user
+func (r *AuthRepository) UpdatePassword(id int, hash string) error {
+	return r.db.Model(&models.User{}).Where("id = ?", id).Updates(map[string]interface{}{"password": hash, "version": gorm.Expr("version + 1")}).Error
+}
@@ -0,0 +1,52 @@
+package repositories
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// TestResolveIdentifier_TypesUnit tests which identifier type a login value resolves to
+func TestResolveIdentifier_TypesUnit(t *testing.T) {
+	all := []string{IdentifierUsername, IdentifierEmail, IdentifierPhone}
+
+	identifierType, ok := ResolveIdentifier("alice", all)
+	assert.True(t, ok)
+	assert.Equal(t, IdentifierUsername, identifierType)
+
+	identifierType, ok = ResolveIdentifier("Alice@Example.com", all)
+	assert.True(t, ok)
+	assert.Equal(t, IdentifierEmail, identifierType)
+
+	identifierType, ok = ResolveIdentifier("+628123456789", all)
+	assert.True(t, ok)
+	assert.Equal(t, IdentifierPhone, identifierType)
+}
+
+// TestResolveIdentifier_DisabledTypesUnit tests that types missing from LOGIN_IDENTIFIERS are rejected
+func TestResolveIdentifier_DisabledTypesUnit(t *testing.T) {
+	_, ok := ResolveIdentifier("alice@example.com", []string{IdentifierUsername})
+	assert.False(t, ok)
+	_, ok = ResolveIdentifier("alice", []string{IdentifierEmail})
+	assert.False(t, ok)
+	_, ok = ResolveIdentifier("+628123456789", []string{IdentifierUsername, IdentifierEmail})
+	assert.False(t, ok)
+
+	// A disabled type answers like an unknown user without querying the database
+	user, err := NewAuthRepository(nil).FindByIdentifier("alice@example.com", []string{IdentifierUsername})
+	assert.Nil(t, user)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	// So does a phone number that is not valid E.164
+	user, err = NewAuthRepository(nil).FindByIdentifier("+12", []string{IdentifierPhone})
+	assert.Nil(t, user)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+// TestValidateIdentifierTypes_UnknownUnit tests that unknown types in LOGIN_IDENTIFIERS fail at startup
+func TestValidateIdentifierTypes_UnknownUnit(t *testing.T) {
+	assert.NoError(t, ValidateIdentifierTypes([]string{IdentifierUsername, IdentifierEmail, IdentifierPhone}))
+	assert.Error(t, ValidateIdentifierTypes([]string{IdentifierUsername, "fax"}))
+	assert.Error(t, ValidateIdentifierTypes(nil))
+}
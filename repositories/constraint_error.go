@@ -0,0 +1,122 @@
+package repositories
+
+import (
+	"errors"
+	"gin-user-app/apperrors"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+var (
+	// ErrUniqueViolation is the kind of a ConstraintError for a value that is already taken.
+	ErrUniqueViolation = errors.New("unique violation")
+	// ErrForeignKeyViolation is the kind of a ConstraintError for a reference to a missing row.
+	ErrForeignKeyViolation = errors.New("foreign key violation")
+	// ErrNotNullViolation is the kind of a ConstraintError for a missing required value.
+	ErrNotNullViolation = errors.New("not null violation")
+	// ErrCheckViolation is the kind of a ConstraintError for a value rejected by a check constraint.
+	ErrCheckViolation = errors.New("check violation")
+)
+
+// sqlStateKinds maps Postgres SQLSTATE codes of integrity constraint
+// violations to the ConstraintError kind.
+var sqlStateKinds = map[string]error{
+	"23505": ErrUniqueViolation,
+	"23503": ErrForeignKeyViolation,
+	"23502": ErrNotNullViolation,
+	"23514": ErrCheckViolation,
+}
+
+// constraintFields maps constraint names to the API field they guard. Columns
+// of constraints that are not listed here are used as the field name as is.
+var constraintFields = map[string]string{
+	"uni_users_username":           "username",
+	"uni_users_email":              "email",
+	"uni_users_username_canonical": "username",
+	"uni_users_email_canonical":    "email",
+	"uni_users_phone":              "phone",
+}
+
+// ConstraintError describes a write that violated a database constraint. It
+// wraps its Kind, so callers can use errors.Is(err, ErrUniqueViolation).
+type ConstraintError struct {
+	Kind       error
+	Field      string
+	Constraint string
+}
+
+func (e *ConstraintError) Error() string {
+	field := e.Field
+	if field == "" {
+		field = "value"
+	}
+	switch e.Kind {
+	case ErrUniqueViolation:
+		return field + " already taken"
+	case ErrForeignKeyViolation:
+		return field + " refers to a record that does not exist"
+	case ErrNotNullViolation:
+		return field + " is required"
+	default:
+		return field + " is invalid"
+	}
+}
+
+// Code returns the field error code of the violation.
+func (e *ConstraintError) Code() string {
+	switch e.Kind {
+	case ErrUniqueViolation:
+		return apperrors.CodeTaken
+	case ErrNotNullViolation:
+		return apperrors.CodeRequired
+	default:
+		return apperrors.CodeInvalid
+	}
+}
+
+func (e *ConstraintError) Unwrap() error {
+	return e.Kind
+}
+
+// translateConstraintError turns a Postgres constraint violation into a
+// conflict (unique) or validation error that wraps a *ConstraintError naming
+// the field. Other errors are returned unchanged.
+func translateConstraintError(err error) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+	kind, ok := sqlStateKinds[pgErr.Code]
+	if !ok {
+		return err
+	}
+	violation := &ConstraintError{Kind: kind, Field: constraintField(pgErr), Constraint: pgErr.ConstraintName}
+
+	appErr := apperrors.Validation(violation.Error())
+	if kind == ErrUniqueViolation {
+		appErr = apperrors.Conflict(violation.Error())
+	}
+	if violation.Field != "" {
+		appErr.Fields = []apperrors.FieldError{{Field: violation.Field, Code: violation.Code(), Message: violation.Error()}}
+	}
+	appErr.Err = violation
+	return appErr
+}
+
+// constraintField finds the field of a violation from the constraint name,
+// the reported column or the "Key (column)=(value)" detail.
+func constraintField(pgErr *pgconn.PgError) string {
+	if field, ok := constraintFields[pgErr.ConstraintName]; ok {
+		return field
+	}
+	if pgErr.ColumnName != "" {
+		return pgErr.ColumnName
+	}
+	if rest, ok := strings.CutPrefix(pgErr.Detail, "Key ("); ok {
+		if end := strings.Index(rest, ")="); end > 0 && !strings.ContainsAny(rest[:end], ",(") {
+			return rest[:end]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,76 @@
+package repositories
+
+import (
+	"errors"
+	"gin-user-app/apperrors"
+	"gin-user-app/models"
+	"gin-user-app/utils"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// EmailChangeRepository handles persistence of email change confirmation tokens
+type EmailChangeRepository interface {
+	Create(token models.EmailChangeToken) error
+	FindByHash(hash string) (models.EmailChangeToken, error)
+	InvalidateForUser(userID int, at time.Time) error
+	Consume(token models.EmailChangeToken, at time.Time) (bool, error)
+}
+
+// emailChangeRepositoryImpl implements EmailChangeRepository with GORM
+type emailChangeRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewEmailChangeRepository creates a new instance of EmailChangeRepository
+func NewEmailChangeRepository(db *gorm.DB) EmailChangeRepository {
+	return &emailChangeRepositoryImpl{db: db}
+}
+
+// Create stores a new email change token
+func (r *emailChangeRepositoryImpl) Create(token models.EmailChangeToken) error {
+	return r.db.Create(&token).Error
+}
+
+// FindByHash finds an email change token by the hash of its value
+func (r *emailChangeRepositoryImpl) FindByHash(hash string) (models.EmailChangeToken, error) {
+	var token models.EmailChangeToken
+	result := r.db.Where("token_hash = ?", hash).First(&token)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return models.EmailChangeToken{}, apperrors.NotFound("email change token not found")
+	}
+	return token, result.Error
+}
+
+// InvalidateForUser marks every unused email change token of the user as used
+func (r *emailChangeRepositoryImpl) InvalidateForUser(userID int, at time.Time) error {
+	return r.db.Model(&models.EmailChangeToken{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Update("used_at", at).Error
+}
+
+// Consume marks the token as used and sets the new address as the user's
+// verified email in one transaction. It reports false when the token was
+// already used.
+func (r *emailChangeRepositoryImpl) Consume(token models.EmailChangeToken, at time.Time) (bool, error) {
+	consumed := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.EmailChangeToken{}).
+			Where("id = ? AND used_at IS NULL", token.ID).
+			Update("used_at", at)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+
+		result = tx.Model(&models.User{}).
+			Where("id = ?", token.UserID).
+			Updates(map[string]interface{}{"email": token.NewEmail, "email_canonical": utils.CanonicalEmail(token.NewEmail), "email_verified_at": at, "updated_at": at, "version": gorm.Expr("version + 1")})
+		if result.Error != nil {
+			return result.Error
+		}
+		consumed = result.RowsAffected == 1
+		return nil
+	})
+	return consumed, translateConstraintError(err)
+}
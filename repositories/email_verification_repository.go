@@ -0,0 +1,93 @@
+package repositories
+
+import (
+	"errors"
+	"gin-user-app/apperrors"
+	"gin-user-app/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// EmailVerificationRepository handles persistence of email verification tokens
+type EmailVerificationRepository interface {
+	Create(token models.EmailVerificationToken) error
+	FindByHash(hash string) (models.EmailVerificationToken, error)
+	LastSentAt(userID int) (*time.Time, error)
+	InvalidateForUser(userID int, at time.Time) error
+	Consume(token models.EmailVerificationToken, at time.Time) (bool, error)
+}
+
+// emailVerificationRepositoryImpl implements EmailVerificationRepository with GORM
+type emailVerificationRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewEmailVerificationRepository creates a new instance of EmailVerificationRepository
+func NewEmailVerificationRepository(db *gorm.DB) EmailVerificationRepository {
+	return &emailVerificationRepositoryImpl{db: db}
+}
+
+// Create stores a new verification token
+func (r *emailVerificationRepositoryImpl) Create(token models.EmailVerificationToken) error {
+	return r.db.Create(&token).Error
+}
+
+// FindByHash finds a verification token by the hash of its value
+func (r *emailVerificationRepositoryImpl) FindByHash(hash string) (models.EmailVerificationToken, error) {
+	var token models.EmailVerificationToken
+	result := r.db.Where("token_hash = ?", hash).First(&token)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return models.EmailVerificationToken{}, apperrors.NotFound("email verification token not found")
+	}
+	return token, result.Error
+}
+
+// LastSentAt returns when the newest verification token of the user was created, or nil
+func (r *emailVerificationRepositoryImpl) LastSentAt(userID int) (*time.Time, error) {
+	var token models.EmailVerificationToken
+	result := r.db.Where("user_id = ?", userID).Order("created_at DESC").Limit(1).Find(&token)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return nil, result.Error
+	}
+	return &token.CreatedAt, nil
+}
+
+// InvalidateForUser marks every unused verification token of the user as used
+func (r *emailVerificationRepositoryImpl) InvalidateForUser(userID int, at time.Time) error {
+	return r.db.Model(&models.EmailVerificationToken{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Update("used_at", at).Error
+}
+
+// Consume marks the token as used and the user's email as verified in one
+// transaction. A pending account becomes active. It reports false when the
+// token was already used or the user's email no longer matches the address
+// the token was sent to.
+func (r *emailVerificationRepositoryImpl) Consume(token models.EmailVerificationToken, at time.Time) (bool, error) {
+	consumed := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.EmailVerificationToken{}).
+			Where("id = ? AND used_at IS NULL", token.ID).
+			Update("used_at", at)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+
+		result = tx.Model(&models.User{}).
+			Where("id = ? AND email = ?", token.UserID, token.Email).
+			Updates(map[string]interface{}{"email_verified_at": at, "version": gorm.Expr("version + 1")})
+		if result.Error != nil {
+			return result.Error
+		}
+		consumed = result.RowsAffected == 1
+		if !consumed {
+			return nil
+		}
+
+		return tx.Model(&models.User{}).
+			Where("id = ? AND status = ?", token.UserID, models.UserStatusPending).
+			Updates(map[string]interface{}{"status": models.UserStatusActive, "status_changed_at": at}).Error
+	})
+	return consumed, err
+}
@@ -0,0 +1,73 @@
+package repositories
+
+import (
+	"errors"
+	"gin-user-app/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// LoginAttemptRepository handles persistence of failed login counters
+type LoginAttemptRepository interface {
+	Get(userID int) (models.LoginAttempt, error)
+	RecordFailure(userID int, at time.Time) (models.LoginAttempt, error)
+	Lock(userID int, until time.Time) error
+	Clear(userID int) error
+	List() ([]models.LoginAttempt, error)
+}
+
+// loginAttemptRepositoryImpl implements LoginAttemptRepository with GORM
+type loginAttemptRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewLoginAttemptRepository creates a new instance of LoginAttemptRepository
+func NewLoginAttemptRepository(db *gorm.DB) LoginAttemptRepository {
+	return &loginAttemptRepositoryImpl{db: db}
+}
+
+// Get returns the counter of a user, or an empty counter when there were no failures
+func (r *loginAttemptRepositoryImpl) Get(userID int) (models.LoginAttempt, error) {
+	var attempt models.LoginAttempt
+	result := r.db.Where("user_id = ?", userID).First(&attempt)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return models.LoginAttempt{UserID: userID}, nil
+	}
+	return attempt, result.Error
+}
+
+// RecordFailure atomically increments the counter and returns the updated row
+func (r *loginAttemptRepositoryImpl) RecordFailure(userID int, at time.Time) (models.LoginAttempt, error) {
+	attempt := models.LoginAttempt{UserID: userID, FailedAttempts: 1, LastFailedAt: at, UpdatedAt: at}
+	err := r.db.Clauses(
+		clause.OnConflict{
+			Columns: []clause.Column{{Name: "user_id"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"failed_attempts": gorm.Expr("login_attempts.failed_attempts + 1"),
+				"last_failed_at":  at,
+				"updated_at":      at,
+			}),
+		},
+		clause.Returning{},
+	).Create(&attempt).Error
+	return attempt, err
+}
+
+// Lock blocks logins of the user until the given time
+func (r *loginAttemptRepositoryImpl) Lock(userID int, until time.Time) error {
+	return r.db.Model(&models.LoginAttempt{}).Where("user_id = ?", userID).Update("locked_until", until).Error
+}
+
+// Clear removes the counter and any lockout of the user
+func (r *loginAttemptRepositoryImpl) Clear(userID int) error {
+	return r.db.Where("user_id = ?", userID).Delete(&models.LoginAttempt{}).Error
+}
+
+// List returns every user with failed logins, most recent first
+func (r *loginAttemptRepositoryImpl) List() ([]models.LoginAttempt, error) {
+	var attempts []models.LoginAttempt
+	err := r.db.Order("last_failed_at DESC").Find(&attempts).Error
+	return attempts, err
+}
@@ -0,0 +1,123 @@
+package repositories
+
+import (
+	"errors"
+	"gin-user-app/apperrors"
+	"gin-user-app/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MFARepository handles persistence of TOTP enrollments and recovery codes
+type MFARepository interface {
+	GetByUserID(userID int) (models.UserMFA, error)
+	Save(mfa models.UserMFA) error
+	Confirm(userID int, at time.Time) error
+	Delete(userID int) error
+	AdvanceStep(userID int, step int64) (bool, error)
+	RecordFailure(userID int) (int, error)
+	ResetFailures(userID int) error
+	Lock(userID int, until time.Time) error
+	ReplaceRecoveryCodes(userID int, hashes []string) error
+	UseRecoveryCode(userID int, hash string, at time.Time) (bool, error)
+}
+
+// mfaRepositoryImpl implements MFARepository with GORM
+type mfaRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewMFARepository creates a new instance of MFARepository
+func NewMFARepository(db *gorm.DB) MFARepository {
+	return &mfaRepositoryImpl{db: db}
+}
+
+// GetByUserID finds the TOTP enrollment of a user
+func (r *mfaRepositoryImpl) GetByUserID(userID int) (models.UserMFA, error) {
+	var mfa models.UserMFA
+	result := r.db.Where("user_id = ?", userID).First(&mfa)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return models.UserMFA{}, apperrors.NotFound("mfa not enrolled")
+	}
+	return mfa, result.Error
+}
+
+// Save creates or replaces an enrollment
+func (r *mfaRepositoryImpl) Save(mfa models.UserMFA) error {
+	return r.db.Clauses(clause.OnConflict{UpdateAll: true}).Create(&mfa).Error
+}
+
+// Confirm marks an enrollment as confirmed
+func (r *mfaRepositoryImpl) Confirm(userID int, at time.Time) error {
+	return r.db.Model(&models.UserMFA{}).Where("user_id = ?", userID).Update("confirmed_at", at).Error
+}
+
+// Delete removes the enrollment and recovery codes of a user
+func (r *mfaRepositoryImpl) Delete(userID int) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&models.UserMFA{}).Error
+	})
+}
+
+// AdvanceStep records the last accepted TOTP time-step. It reports false when
+// the step was already used, which rejects replayed codes.
+func (r *mfaRepositoryImpl) AdvanceStep(userID int, step int64) (bool, error) {
+	result := r.db.Model(&models.UserMFA{}).
+		Where("user_id = ? AND last_used_step < ?", userID, step).
+		Updates(map[string]interface{}{"last_used_step": step, "failed_attempts": 0})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// RecordFailure increments and returns the failed attempt counter
+func (r *mfaRepositoryImpl) RecordFailure(userID int) (int, error) {
+	var mfa models.UserMFA
+	result := r.db.Model(&mfa).Clauses(clause.Returning{Columns: []clause.Column{{Name: "failed_attempts"}}}).
+		Where("user_id = ?", userID).
+		Update("failed_attempts", gorm.Expr("failed_attempts + 1"))
+	return mfa.FailedAttempts, result.Error
+}
+
+// ResetFailures clears the failed attempt counter and the lock
+func (r *mfaRepositoryImpl) ResetFailures(userID int) error {
+	return r.db.Model(&models.UserMFA{}).Where("user_id = ?", userID).
+		Updates(map[string]interface{}{"failed_attempts": 0, "locked_until": nil}).Error
+}
+
+// Lock refuses codes until the given time. The failed attempt counter is kept.
+func (r *mfaRepositoryImpl) Lock(userID int, until time.Time) error {
+	return r.db.Model(&models.UserMFA{}).Where("user_id = ?", userID).Update("locked_until", until).Error
+}
+
+// ReplaceRecoveryCodes deletes the old recovery codes and stores new ones
+func (r *mfaRepositoryImpl) ReplaceRecoveryCodes(userID int, hashes []string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		codes := make([]models.RecoveryCode, 0, len(hashes))
+		for _, hash := range hashes {
+			codes = append(codes, models.RecoveryCode{UserID: userID, CodeHash: hash})
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+// UseRecoveryCode consumes an unused recovery code. It reports false when no
+// matching unused code exists.
+func (r *mfaRepositoryImpl) UseRecoveryCode(userID int, hash string, at time.Time) (bool, error) {
+	result := r.db.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hash).
+		Update("used_at", at)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
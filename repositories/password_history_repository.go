@@ -0,0 +1,42 @@
+package repositories
+
+import (
+	"gin-user-app/models"
+
+	"gorm.io/gorm"
+)
+
+// PasswordHistoryRepository handles persistence of replaced password hashes
+type PasswordHistoryRepository interface {
+	Add(entry models.PasswordHistory) error
+	Recent(userID int, limit int) ([]models.PasswordHistory, error)
+	Prune(userID int, keep int) error
+}
+
+// passwordHistoryRepositoryImpl implements PasswordHistoryRepository with GORM
+type passwordHistoryRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewPasswordHistoryRepository creates a new instance of PasswordHistoryRepository
+func NewPasswordHistoryRepository(db *gorm.DB) PasswordHistoryRepository {
+	return &passwordHistoryRepositoryImpl{db: db}
+}
+
+// Add stores a replaced password hash
+func (r *passwordHistoryRepositoryImpl) Add(entry models.PasswordHistory) error {
+	return r.db.Create(&entry).Error
+}
+
+// Recent returns the newest limit entries of the user, newest first
+func (r *passwordHistoryRepositoryImpl) Recent(userID int, limit int) ([]models.PasswordHistory, error) {
+	var entries []models.PasswordHistory
+	err := r.db.Where("user_id = ?", userID).Order("id DESC").Limit(limit).Find(&entries).Error
+	return entries, err
+}
+
+// Prune deletes every entry of the user except the newest keep entries
+func (r *passwordHistoryRepositoryImpl) Prune(userID int, keep int) error {
+	newest := r.db.Model(&models.PasswordHistory{}).Select("id").Where("user_id = ?", userID).Order("id DESC").Limit(keep)
+	return r.db.Where("user_id = ? AND id NOT IN (?)", userID, newest).Delete(&models.PasswordHistory{}).Error
+}
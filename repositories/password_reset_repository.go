@@ -0,0 +1,77 @@
+package repositories
+
+import (
+	"errors"
+	"gin-user-app/apperrors"
+	"gin-user-app/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PasswordResetRepository handles persistence of password reset tokens
+type PasswordResetRepository interface {
+	Create(token models.PasswordResetToken) error
+	FindByHash(hash string) (models.PasswordResetToken, error)
+	InvalidateForUser(userID int, at time.Time) error
+	Consume(token models.PasswordResetToken, passwordHash string, at time.Time) (bool, error)
+}
+
+// passwordResetRepositoryImpl implements PasswordResetRepository with GORM
+type passwordResetRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewPasswordResetRepository creates a new instance of PasswordResetRepository
+func NewPasswordResetRepository(db *gorm.DB) PasswordResetRepository {
+	return &passwordResetRepositoryImpl{db: db}
+}
+
+// Create stores a new reset token
+func (r *passwordResetRepositoryImpl) Create(token models.PasswordResetToken) error {
+	return r.db.Create(&token).Error
+}
+
+// FindByHash finds a reset token by the hash of its value
+func (r *passwordResetRepositoryImpl) FindByHash(hash string) (models.PasswordResetToken, error) {
+	var token models.PasswordResetToken
+	result := r.db.Where("token_hash = ?", hash).First(&token)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return models.PasswordResetToken{}, apperrors.NotFound("password reset token not found")
+	}
+	return token, result.Error
+}
+
+// InvalidateForUser marks every unused reset token of the user as used
+func (r *passwordResetRepositoryImpl) InvalidateForUser(userID int, at time.Time) error {
+	return r.db.Model(&models.PasswordResetToken{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Update("used_at", at).Error
+}
+
+// Consume marks the token as used, invalidates the user's other reset tokens
+// and stores the new password hash and its change time in one transaction. It reports false when
+// the token was already used, so a token can only reset the password once.
+func (r *passwordResetRepositoryImpl) Consume(token models.PasswordResetToken, passwordHash string, at time.Time) (bool, error) {
+	consumed := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.PasswordResetToken{}).
+			Where("id = ? AND used_at IS NULL", token.ID).
+			Update("used_at", at)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+
+		if err := tx.Model(&models.PasswordResetToken{}).
+			Where("user_id = ? AND used_at IS NULL", token.UserID).
+			Update("used_at", at).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.User{}).Where("id = ?", token.UserID).Updates(map[string]interface{}{"password": passwordHash, "password_changed_at": at, "version": gorm.Expr("version + 1")}).Error; err != nil {
+			return err
+		}
+		consumed = true
+		return nil
+	})
+	return consumed, err
+}
@@ -0,0 +1,83 @@
+package repositories
+
+import (
+	"errors"
+	"gin-user-app/apperrors"
+	"gin-user-app/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PersonalAccessTokenRepository handles persistence of personal access tokens
+type PersonalAccessTokenRepository interface {
+	Create(token models.PersonalAccessToken) (models.PersonalAccessToken, error)
+	FindByHash(hash string) (models.PersonalAccessToken, error)
+	ListByUser(userID int) ([]models.PersonalAccessToken, error)
+	Revoke(userID, id int, at time.Time) (bool, error)
+	RevokeAllForUser(userID int, at time.Time) error
+	Touch(id int, at time.Time, ip string, staleBefore time.Time) error
+}
+
+// personalAccessTokenRepositoryImpl implements PersonalAccessTokenRepository with GORM
+type personalAccessTokenRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewPersonalAccessTokenRepository creates a new instance of PersonalAccessTokenRepository
+func NewPersonalAccessTokenRepository(db *gorm.DB) PersonalAccessTokenRepository {
+	return &personalAccessTokenRepositoryImpl{db: db}
+}
+
+// Create stores a new personal access token
+func (r *personalAccessTokenRepositoryImpl) Create(token models.PersonalAccessToken) (models.PersonalAccessToken, error) {
+	result := r.db.Create(&token)
+	return token, result.Error
+}
+
+// FindByHash finds a personal access token by the hash of its value
+func (r *personalAccessTokenRepositoryImpl) FindByHash(hash string) (models.PersonalAccessToken, error) {
+	var token models.PersonalAccessToken
+	result := r.db.Where("token_hash = ?", hash).First(&token)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return models.PersonalAccessToken{}, apperrors.NotFound("personal access token not found")
+	}
+	return token, result.Error
+}
+
+// ListByUser returns the tokens of a user that are not revoked, newest first
+func (r *personalAccessTokenRepositoryImpl) ListByUser(userID int) ([]models.PersonalAccessToken, error) {
+	var tokens []models.PersonalAccessToken
+	err := r.db.Where("user_id = ? AND revoked_at IS NULL", userID).
+		Order("created_at DESC").
+		Find(&tokens).Error
+	return tokens, err
+}
+
+// Revoke revokes a token of the given user. It reports false when the token
+// does not exist, belongs to someone else or is already revoked.
+func (r *personalAccessTokenRepositoryImpl) Revoke(userID, id int, at time.Time) (bool, error) {
+	result := r.db.Model(&models.PersonalAccessToken{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", at)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// RevokeAllForUser revokes every token of the user that is not revoked yet
+func (r *personalAccessTokenRepositoryImpl) RevokeAllForUser(userID int, at time.Time) error {
+	return r.db.Model(&models.PersonalAccessToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", at).Error
+}
+
+// Touch records the last use of a token. The row is only written when the IP
+// changed or the previous use is older than staleBefore, so busy clients do
+// not update it on every request.
+func (r *personalAccessTokenRepositoryImpl) Touch(id int, at time.Time, ip string, staleBefore time.Time) error {
+	return r.db.Model(&models.PersonalAccessToken{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ? OR last_used_ip <> ?)", id, staleBefore, ip).
+		Updates(map[string]interface{}{"last_used_at": at, "last_used_ip": ip}).Error
+}
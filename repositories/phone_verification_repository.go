@@ -0,0 +1,96 @@
+package repositories
+
+import (
+	"errors"
+	"gin-user-app/apperrors"
+	"gin-user-app/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PhoneVerificationRepository handles persistence of phone verification codes
+type PhoneVerificationRepository interface {
+	Create(code models.PhoneVerificationCode) error
+	FindActive(userID int, now time.Time) (models.PhoneVerificationCode, error)
+	LastSentAt(userID int) (*time.Time, error)
+	UseAttempt(id int, maxAttempts int) (bool, error)
+	InvalidateForUser(userID int, at time.Time) error
+	Consume(code models.PhoneVerificationCode, at time.Time) (bool, error)
+}
+
+// phoneVerificationRepositoryImpl implements PhoneVerificationRepository with GORM
+type phoneVerificationRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewPhoneVerificationRepository creates a new instance of PhoneVerificationRepository
+func NewPhoneVerificationRepository(db *gorm.DB) PhoneVerificationRepository {
+	return &phoneVerificationRepositoryImpl{db: db}
+}
+
+// Create stores a new verification code
+func (r *phoneVerificationRepositoryImpl) Create(code models.PhoneVerificationCode) error {
+	return r.db.Create(&code).Error
+}
+
+// FindActive returns the newest unused and unexpired code of the user
+func (r *phoneVerificationRepositoryImpl) FindActive(userID int, now time.Time) (models.PhoneVerificationCode, error) {
+	var code models.PhoneVerificationCode
+	result := r.db.Where("user_id = ? AND used_at IS NULL AND expires_at > ?", userID, now).
+		Order("created_at DESC").First(&code)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return models.PhoneVerificationCode{}, apperrors.NotFound("phone verification code not found")
+	}
+	return code, result.Error
+}
+
+// LastSentAt returns when the newest verification code of the user was created, or nil
+func (r *phoneVerificationRepositoryImpl) LastSentAt(userID int) (*time.Time, error) {
+	var code models.PhoneVerificationCode
+	result := r.db.Where("user_id = ?", userID).Order("created_at DESC").Limit(1).Find(&code)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return nil, result.Error
+	}
+	return &code.CreatedAt, nil
+}
+
+// UseAttempt atomically counts a guess against the code. It reports false
+// once maxAttempts guesses were made or the code was used.
+func (r *phoneVerificationRepositoryImpl) UseAttempt(id int, maxAttempts int) (bool, error) {
+	result := r.db.Model(&models.PhoneVerificationCode{}).
+		Where("id = ? AND used_at IS NULL AND failed_attempts < ?", id, maxAttempts).
+		Update("failed_attempts", gorm.Expr("failed_attempts + 1"))
+	return result.RowsAffected == 1, result.Error
+}
+
+// InvalidateForUser marks every unused verification code of the user as used
+func (r *phoneVerificationRepositoryImpl) InvalidateForUser(userID int, at time.Time) error {
+	return r.db.Model(&models.PhoneVerificationCode{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Update("used_at", at).Error
+}
+
+// Consume marks the code as used and sets its phone as the user's verified
+// phone in one transaction. It reports false when the code was already used.
+func (r *phoneVerificationRepositoryImpl) Consume(code models.PhoneVerificationCode, at time.Time) (bool, error) {
+	consumed := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.PhoneVerificationCode{}).
+			Where("id = ? AND used_at IS NULL", code.ID).
+			Update("used_at", at)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+
+		result = tx.Model(&models.User{}).
+			Where("id = ?", code.UserID).
+			Updates(map[string]interface{}{"phone": code.Phone, "phone_verified_at": at, "updated_at": at, "version": gorm.Expr("version + 1")})
+		if result.Error != nil {
+			return result.Error
+		}
+		consumed = result.RowsAffected == 1
+		return nil
+	})
+	return consumed, translateConstraintError(err)
+}
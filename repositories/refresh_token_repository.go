@@ -0,0 +1,71 @@
+package repositories
+
+import (
+	"errors"
+	"gin-user-app/apperrors"
+	"gin-user-app/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RefreshTokenRepository handles persistence of refresh tokens
+type RefreshTokenRepository interface {
+	Create(token models.RefreshToken) (models.RefreshToken, error)
+	FindByHash(hash string) (models.RefreshToken, error)
+	MarkRotated(id int, at time.Time) (bool, error)
+	RevokeFamily(familyID string, at time.Time) error
+	RevokeAllForUser(userID int, at time.Time) error
+}
+
+// refreshTokenRepositoryImpl implements RefreshTokenRepository with GORM
+type refreshTokenRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewRefreshTokenRepository creates a new instance of RefreshTokenRepository
+func NewRefreshTokenRepository(db *gorm.DB) RefreshTokenRepository {
+	return &refreshTokenRepositoryImpl{db: db}
+}
+
+// Create stores a new refresh token
+func (r *refreshTokenRepositoryImpl) Create(token models.RefreshToken) (models.RefreshToken, error) {
+	result := r.db.Create(&token)
+	return token, result.Error
+}
+
+// FindByHash finds a refresh token by the hash of its value
+func (r *refreshTokenRepositoryImpl) FindByHash(hash string) (models.RefreshToken, error) {
+	var token models.RefreshToken
+	result := r.db.Where("token_hash = ?", hash).First(&token)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return models.RefreshToken{}, apperrors.NotFound("refresh token not found")
+	}
+	return token, result.Error
+}
+
+// MarkRotated marks a token as rotated. It reports false when the token was
+// already rotated or revoked, so two concurrent refreshes cannot both succeed.
+func (r *refreshTokenRepositoryImpl) MarkRotated(id int, at time.Time) (bool, error) {
+	result := r.db.Model(&models.RefreshToken{}).
+		Where("id = ? AND rotated_at IS NULL AND revoked_at IS NULL", id).
+		Update("rotated_at", at)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// RevokeFamily revokes every token that belongs to the given family
+func (r *refreshTokenRepositoryImpl) RevokeFamily(familyID string, at time.Time) error {
+	return r.db.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", at).Error
+}
+
+// RevokeAllForUser revokes every active refresh token of the user
+func (r *refreshTokenRepositoryImpl) RevokeAllForUser(userID int, at time.Time) error {
+	return r.db.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", at).Error
+}
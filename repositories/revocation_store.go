@@ -0,0 +1,180 @@
+package repositories
+
+import (
+	"errors"
+	"gin-user-app/models"
+	"log"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RevocationStore keeps track of access tokens that must be rejected before
+// their exp claim. Entries carry their own expiry so they can be pruned.
+type RevocationStore interface {
+	RevokeToken(jti string, userID int, expiresAt time.Time) error
+	RevokeUser(userID int, before time.Time, expiresAt time.Time) error
+	IsRevoked(jti string, userID int, issuedAt time.Time) (bool, error)
+	PruneExpired(now time.Time) (int64, error)
+}
+
+// postgresRevocationStore implements RevocationStore with GORM
+type postgresRevocationStore struct {
+	db *gorm.DB
+}
+
+// NewPostgresRevocationStore creates a RevocationStore backed by the database
+func NewPostgresRevocationStore(db *gorm.DB) RevocationStore {
+	return &postgresRevocationStore{db: db}
+}
+
+// RevokeToken denylists a single token
+func (s *postgresRevocationStore) RevokeToken(jti string, userID int, expiresAt time.Time) error {
+	return s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.RevokedToken{
+		JTI:       jti,
+		UserID:    userID,
+		ExpiresAt: expiresAt,
+	}).Error
+}
+
+// RevokeUser invalidates every token of the user issued at or before the given time
+func (s *postgresRevocationStore) RevokeUser(userID int, before time.Time, expiresAt time.Time) error {
+	return s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"revoked_before", "expires_at", "updated_at"}),
+	}).Create(&models.UserRevocation{
+		UserID:        userID,
+		RevokedBefore: before,
+		ExpiresAt:     expiresAt,
+	}).Error
+}
+
+// IsRevoked reports whether the token was revoked individually or by a user-wide logout
+func (s *postgresRevocationStore) IsRevoked(jti string, userID int, issuedAt time.Time) (bool, error) {
+	var count int64
+	if err := s.db.Model(&models.RevokedToken{}).Where("jti = ?", jti).Count(&count).Error; err != nil {
+		return false, err
+	}
+	if count > 0 {
+		return true, nil
+	}
+
+	var revocation models.UserRevocation
+	err := s.db.Where("user_id = ?", userID).First(&revocation).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return !issuedAt.After(revocation.RevokedBefore), nil
+}
+
+// PruneExpired removes entries whose tokens can no longer be valid anyway
+func (s *postgresRevocationStore) PruneExpired(now time.Time) (int64, error) {
+	tokens := s.db.Where("expires_at < ?", now).Delete(&models.RevokedToken{})
+	if tokens.Error != nil {
+		return 0, tokens.Error
+	}
+	users := s.db.Where("expires_at < ?", now).Delete(&models.UserRevocation{})
+	if users.Error != nil {
+		return tokens.RowsAffected, users.Error
+	}
+	return tokens.RowsAffected + users.RowsAffected, nil
+}
+
+// memoryRevocationStore implements RevocationStore in process memory.
+// It is meant for single-instance deployments and tests.
+type memoryRevocationStore struct {
+	mu     sync.RWMutex
+	tokens map[string]time.Time
+	users  map[int]models.UserRevocation
+}
+
+// NewMemoryRevocationStore creates an in-memory RevocationStore
+func NewMemoryRevocationStore() RevocationStore {
+	return &memoryRevocationStore{
+		tokens: make(map[string]time.Time),
+		users:  make(map[int]models.UserRevocation),
+	}
+}
+
+// RevokeToken denylists a single token
+func (s *memoryRevocationStore) RevokeToken(jti string, userID int, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens[jti] = expiresAt
+	return nil
+}
+
+// RevokeUser invalidates every token of the user issued at or before the given time
+func (s *memoryRevocationStore) RevokeUser(userID int, before time.Time, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users[userID] = models.UserRevocation{
+		UserID:        userID,
+		RevokedBefore: before,
+		ExpiresAt:     expiresAt,
+		UpdatedAt:     time.Now(),
+	}
+	return nil
+}
+
+// IsRevoked reports whether the token was revoked individually or by a user-wide logout
+func (s *memoryRevocationStore) IsRevoked(jti string, userID int, issuedAt time.Time) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if _, ok := s.tokens[jti]; ok {
+		return true, nil
+	}
+	if revocation, ok := s.users[userID]; ok {
+		return !issuedAt.After(revocation.RevokedBefore), nil
+	}
+	return false, nil
+}
+
+// PruneExpired removes entries whose tokens can no longer be valid anyway
+func (s *memoryRevocationStore) PruneExpired(now time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var pruned int64
+	for jti, expiresAt := range s.tokens {
+		if expiresAt.Before(now) {
+			delete(s.tokens, jti)
+			pruned++
+		}
+	}
+	for userID, revocation := range s.users {
+		if revocation.ExpiresAt.Before(now) {
+			delete(s.users, userID)
+			pruned++
+		}
+	}
+	return pruned, nil
+}
+
+// StartRevocationPruner prunes expired entries on every tick until stop is called
+func StartRevocationPruner(store RevocationStore, interval time.Duration) (stop func()) {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				pruned, err := store.PruneExpired(time.Now())
+				if err != nil {
+					log.Println("Failed to prune revoked tokens:", err)
+				} else if pruned > 0 {
+					log.Printf("Pruned %d expired revocation entries", pruned)
+				}
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() { once.Do(func() { close(done) }) }
+}
@@ -0,0 +1,80 @@
+package repositories
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestMemoryRevocationStore_RevokeTokenUnit tests that only the revoked jti is rejected
+func TestMemoryRevocationStore_RevokeTokenUnit(t *testing.T) {
+	now := time.Now()
+	store := NewMemoryRevocationStore()
+	assert.NoError(t, store.RevokeToken("jti-1", 7, now.Add(time.Hour)))
+
+	revoked, err := store.IsRevoked("jti-1", 7, now)
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+
+	revoked, err = store.IsRevoked("jti-2", 7, now)
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+}
+
+// TestMemoryRevocationStore_RevokeUserCutoffUnit tests that a user-wide revocation only rejects tokens issued at or before the cutoff
+func TestMemoryRevocationStore_RevokeUserCutoffUnit(t *testing.T) {
+	cutoff := time.Now()
+	store := NewMemoryRevocationStore()
+	assert.NoError(t, store.RevokeUser(7, cutoff, cutoff.Add(time.Hour)))
+
+	for issuedAt, want := range map[time.Time]bool{
+		cutoff.Add(-time.Minute): true,
+		cutoff:                   true,
+		cutoff.Add(time.Second):  false,
+	} {
+		revoked, err := store.IsRevoked("jti", 7, issuedAt)
+		assert.NoError(t, err)
+		assert.Equal(t, want, revoked, issuedAt)
+	}
+
+	// Other users are not affected
+	revoked, err := store.IsRevoked("jti", 8, cutoff.Add(-time.Minute))
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+
+	// A later logout-all moves the cutoff forward
+	later := cutoff.Add(time.Minute)
+	assert.NoError(t, store.RevokeUser(7, later, later.Add(time.Hour)))
+	revoked, err = store.IsRevoked("jti", 7, cutoff.Add(time.Second))
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+}
+
+// TestMemoryRevocationStore_PruneExpiredUnit tests that only expired entries are pruned
+func TestMemoryRevocationStore_PruneExpiredUnit(t *testing.T) {
+	now := time.Now()
+	store := NewMemoryRevocationStore()
+	assert.NoError(t, store.RevokeToken("expired", 7, now.Add(-time.Second)))
+	assert.NoError(t, store.RevokeToken("live", 7, now.Add(time.Hour)))
+	assert.NoError(t, store.RevokeUser(7, now.Add(-2*time.Hour), now.Add(-time.Second)))
+	assert.NoError(t, store.RevokeUser(8, now, now.Add(time.Hour)))
+
+	pruned, err := store.PruneExpired(now)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), pruned)
+
+	revoked, _ := store.IsRevoked("expired", 7, now)
+	assert.False(t, revoked)
+	revoked, _ = store.IsRevoked("live", 7, now)
+	assert.True(t, revoked)
+	revoked, _ = store.IsRevoked("jti", 7, now.Add(-3*time.Hour))
+	assert.False(t, revoked)
+	revoked, _ = store.IsRevoked("jti", 8, now.Add(-time.Minute))
+	assert.True(t, revoked)
+
+	// Pruning again finds nothing left to remove
+	pruned, err = store.PruneExpired(now)
+	assert.NoError(t, err)
+	assert.Zero(t, pruned)
+}
@@ -0,0 +1,145 @@
+package repositories
+
+import (
+	"errors"
+	"gin-user-app/apperrors"
+	"gin-user-app/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// defaultRolePermissions lists the permissions of the built-in roles
+var defaultRolePermissions = map[string][]string{
+	models.RoleAdmin: {
+		models.PermissionUsersRead,
+		models.PermissionUsersCreate,
+		models.PermissionUsersUpdate,
+		models.PermissionUsersDelete,
+		models.PermissionUsersStatus,
+		models.PermissionRolesRead,
+		models.PermissionRolesManage,
+		models.PermissionRolesAssign,
+		models.PermissionLockoutsRead,
+		models.PermissionLockoutsManage,
+		models.PermissionSessionsRead,
+		models.PermissionSessionsManage,
+	},
+	models.RoleUser: {
+		models.PermissionUsersRead,
+	},
+}
+
+// RoleRepository handles persistence of roles and permissions
+type RoleRepository interface {
+	GetAll() ([]models.Role, error)
+	GetByName(name string) (models.Role, error)
+	GetByNames(names []string) ([]models.Role, error)
+	Create(role models.Role) (models.Role, error)
+	FindPermissionsByNames(names []string) ([]models.Permission, error)
+	GetUserRoles(userID int) ([]models.Role, error)
+	SetUserRoles(userID int, roles []models.Role) error
+	EnsureDefaults() error
+}
+
+// roleRepositoryImpl implements RoleRepository with GORM
+type roleRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewRoleRepository creates a new instance of RoleRepository
+func NewRoleRepository(db *gorm.DB) RoleRepository {
+	return &roleRepositoryImpl{db: db}
+}
+
+// GetAll returns every role with its permissions
+func (r *roleRepositoryImpl) GetAll() ([]models.Role, error) {
+	var roles []models.Role
+	result := r.db.Preload("Permissions").Order("name").Find(&roles)
+	return roles, result.Error
+}
+
+// GetByName finds a role by name
+func (r *roleRepositoryImpl) GetByName(name string) (models.Role, error) {
+	var role models.Role
+	result := r.db.Preload("Permissions").Where("name = ?", name).First(&role)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return models.Role{}, apperrors.NotFound("role not found")
+	}
+	return role, result.Error
+}
+
+// GetByNames finds every role whose name is in the list
+func (r *roleRepositoryImpl) GetByNames(names []string) ([]models.Role, error) {
+	var roles []models.Role
+	result := r.db.Preload("Permissions").Where("name IN ?", names).Find(&roles)
+	return roles, result.Error
+}
+
+// Create stores a new role and links it to its permissions. A taken name is
+// returned as a conflict on the "name" field.
+func (r *roleRepositoryImpl) Create(role models.Role) (models.Role, error) {
+	result := r.db.Create(&role)
+	if result.Error != nil {
+		return models.Role{}, translateConstraintError(result.Error)
+	}
+	return role, nil
+}
+
+// FindPermissionsByNames finds every permission whose name is in the list
+func (r *roleRepositoryImpl) FindPermissionsByNames(names []string) ([]models.Permission, error) {
+	var permissions []models.Permission
+	result := r.db.Where("name IN ?", names).Find(&permissions)
+	return permissions, result.Error
+}
+
+// GetUserRoles returns the roles explicitly assigned to a user
+func (r *roleRepositoryImpl) GetUserRoles(userID int) ([]models.Role, error) {
+	var user models.User
+	result := r.db.Preload("Roles.Permissions").First(&user, userID)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, ErrUserNotFound
+	}
+	return user.Roles, result.Error
+}
+
+// SetUserRoles replaces the roles assigned to a user
+func (r *roleRepositoryImpl) SetUserRoles(userID int, roles []models.Role) error {
+	var user models.User
+	if err := r.db.First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+	return r.db.Model(&user).Association("Roles").Replace(roles)
+}
+
+// EnsureDefaults creates the built-in roles and permissions if they are missing
+func (r *roleRepositoryImpl) EnsureDefaults() error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for roleName, permissionNames := range defaultRolePermissions {
+			permissions := make([]models.Permission, 0, len(permissionNames))
+			for _, name := range permissionNames {
+				permission := models.Permission{Name: name}
+				if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&permission).Error; err != nil {
+					return err
+				}
+				if err := tx.Where("name = ?", name).First(&permission).Error; err != nil {
+					return err
+				}
+				permissions = append(permissions, permission)
+			}
+
+			role := models.Role{Name: roleName, Description: "Built-in " + roleName + " role"}
+			if err := tx.Where("name = ?", roleName).FirstOrCreate(&role).Error; err != nil {
+				return err
+			}
+			// Built-in roles only ever gain permissions here, custom edits are kept
+			if err := tx.Model(&role).Association("Permissions").Append(permissions); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
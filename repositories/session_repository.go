@@ -0,0 +1,82 @@
+package repositories
+
+import (
+	"errors"
+	"gin-user-app/apperrors"
+	"gin-user-app/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SessionRepository handles persistence of login sessions
+type SessionRepository interface {
+	Create(session models.Session) error
+	GetByID(id string) (models.Session, error)
+	ListActive(userID int, seenSince time.Time) ([]models.Session, error)
+	Revoke(userID int, id string, at time.Time) (bool, error)
+	RevokeAllForUser(userID int, at time.Time) error
+	Touch(id string, at time.Time, ip string, staleBefore time.Time) error
+}
+
+// sessionRepositoryImpl implements SessionRepository with GORM
+type sessionRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewSessionRepository creates a new instance of SessionRepository
+func NewSessionRepository(db *gorm.DB) SessionRepository {
+	return &sessionRepositoryImpl{db: db}
+}
+
+// Create stores a new session
+func (r *sessionRepositoryImpl) Create(session models.Session) error {
+	return r.db.Create(&session).Error
+}
+
+// GetByID finds a session by its ID
+func (r *sessionRepositoryImpl) GetByID(id string) (models.Session, error) {
+	var session models.Session
+	result := r.db.Where("id = ?", id).First(&session)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return models.Session{}, apperrors.NotFound("session not found")
+	}
+	return session, result.Error
+}
+
+// ListActive returns the sessions of a user that are not revoked and were seen
+// after seenSince, most recently used first
+func (r *sessionRepositoryImpl) ListActive(userID int, seenSince time.Time) ([]models.Session, error) {
+	var sessions []models.Session
+	err := r.db.Where("user_id = ? AND revoked_at IS NULL AND last_seen_at > ?", userID, seenSince).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+// Revoke revokes a session of the given user. It reports false when the
+// session does not exist, belongs to someone else or is already revoked.
+func (r *sessionRepositoryImpl) Revoke(userID int, id string, at time.Time) (bool, error) {
+	result := r.db.Model(&models.Session{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", at)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// RevokeAllForUser revokes every session of the user
+func (r *sessionRepositoryImpl) RevokeAllForUser(userID int, at time.Time) error {
+	return r.db.Model(&models.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", at).Error
+}
+
+// Touch records activity on a session. The row is only written when the IP
+// changed or the previous activity is older than staleBefore.
+func (r *sessionRepositoryImpl) Touch(id string, at time.Time, ip string, staleBefore time.Time) error {
+	return r.db.Model(&models.Session{}).
+		Where("id = ? AND (last_seen_at < ? OR ip <> ?)", id, staleBefore, ip).
+		Updates(map[string]interface{}{"last_seen_at": at, "ip": ip}).Error
+}
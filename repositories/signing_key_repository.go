@@ -0,0 +1,55 @@
+package repositories
+
+import (
+	"gin-user-app/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SigningKeyRepository handles persistence of JWT signing keys
+type SigningKeyRepository interface {
+	ListValid(now time.Time) ([]models.SigningKey, error)
+	Create(key models.SigningKey) error
+	RetireOlderThan(createdBefore time.Time, at time.Time, expiresAt time.Time) error
+	DeleteExpired(now time.Time) (int64, error)
+}
+
+// signingKeyRepositoryImpl implements SigningKeyRepository with GORM
+type signingKeyRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewSigningKeyRepository creates a new instance of SigningKeyRepository
+func NewSigningKeyRepository(db *gorm.DB) SigningKeyRepository {
+	return &signingKeyRepositoryImpl{db: db}
+}
+
+// ListValid returns every key that can still verify tokens, newest first
+func (r *signingKeyRepositoryImpl) ListValid(now time.Time) ([]models.SigningKey, error) {
+	var keys []models.SigningKey
+	err := r.db.Where("expires_at IS NULL OR expires_at > ?", now).
+		Order("created_at DESC").
+		Find(&keys).Error
+	return keys, err
+}
+
+// Create stores a new signing key
+func (r *signingKeyRepositoryImpl) Create(key models.SigningKey) error {
+	return r.db.Create(&key).Error
+}
+
+// RetireOlderThan stops signing with keys created before the given time. They
+// keep verifying tokens until expiresAt. Only older keys are retired so two
+// instances rotating at once still leave the newest key active.
+func (r *signingKeyRepositoryImpl) RetireOlderThan(createdBefore time.Time, at time.Time, expiresAt time.Time) error {
+	return r.db.Model(&models.SigningKey{}).
+		Where("retired_at IS NULL AND created_at < ?", createdBefore).
+		Updates(map[string]interface{}{"retired_at": at, "expires_at": expiresAt}).Error
+}
+
+// DeleteExpired removes keys that can no longer verify any token
+func (r *signingKeyRepositoryImpl) DeleteExpired(now time.Time) (int64, error) {
+	result := r.db.Where("expires_at IS NOT NULL AND expires_at <= ?", now).Delete(&models.SigningKey{})
+	return result.RowsAffected, result.Error
+}
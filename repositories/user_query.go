@@ -0,0 +1,123 @@
+package repositories
+
+import (
+	"fmt"
+	"gin-user-app/models"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// userSortColumns memetakan nama field yang boleh dipakai di sort= ke ekspresi SQL.
+// Age bisa NULL sehingga di-COALESCE agar urutan keyset tetap stabil.
+var userSortColumns = map[string]string{
+	"id":         "id",
+	"username":   "username",
+	"email":      "email",
+	"first_name": "first_name",
+	"last_name":  "last_name",
+	"age":        "COALESCE(age, -1)",
+	"created_at": "created_at",
+}
+
+// IsSortableUserField mengecek apakah field boleh dipakai untuk sorting
+func IsSortableUserField(field string) bool {
+	_, ok := userSortColumns[field]
+	return ok
+}
+
+// UserFilter berisi filter opsional untuk daftar user
+type UserFilter struct {
+	UsernamePrefix string
+	EmailPrefix    string
+	NamePrefix     string
+	AgeMin         *int
+	AgeMax         *int
+	CreatedAfter   *time.Time
+	CreatedBefore  *time.Time
+	// Status memfilter status efektif: suspend atau lock yang sudah lewat
+	// status_expires_at dihitung sebagai active
+	Status string
+}
+
+// UserSort menentukan urutan daftar user. ID selalu dipakai sebagai tie-breaker.
+type UserSort struct {
+	Field string
+	Desc  bool
+}
+
+// UserCursor menunjuk ke baris terakhir halaman sebelumnya untuk keyset pagination
+type UserCursor struct {
+	Value string `json:"v"`
+	ID    int    `json:"id"`
+}
+
+// UserListParams adalah parameter untuk UserRepository.List.
+// Jika Offset diisi maka mode offset dipakai, selain itu keyset dengan After.
+type UserListParams struct {
+	Filter       UserFilter
+	Sort         UserSort
+	Limit        int
+	Offset       *int
+	After        *UserCursor
+	IncludeTotal bool
+}
+
+// UserListResult adalah hasil dari UserRepository.List
+type UserListResult struct {
+	Users   []models.User
+	HasMore bool
+	Total   int64
+}
+
+// UserCursorFor membuat cursor dari user terakhir pada halaman
+func UserCursorFor(user models.User, field string) UserCursor {
+	var value string
+	switch field {
+	case "username":
+		value = user.Username
+	case "email":
+		value = user.Email
+	case "first_name":
+		value = user.FirstName
+	case "last_name":
+		value = user.LastName
+	case "age":
+		age := -1
+		if user.Age != nil {
+			age = *user.Age
+		}
+		value = strconv.Itoa(age)
+	case "created_at":
+		value = user.CreatedAt.UTC().Format(time.RFC3339Nano)
+	default:
+		value = strconv.Itoa(user.ID)
+	}
+	return UserCursor{Value: value, ID: user.ID}
+}
+
+// cursorValue mengubah nilai cursor kembali ke tipe kolomnya
+func cursorValue(field, value string) (interface{}, error) {
+	switch field {
+	case "id", "age":
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cursor value for %s", field)
+		}
+		return n, nil
+	case "created_at":
+		t, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cursor value for %s", field)
+		}
+		return t, nil
+	default:
+		return value, nil
+	}
+}
+
+// likePrefix meng-escape wildcard LIKE lalu menambahkan % di akhir
+func likePrefix(prefix string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return strings.ToLower(replacer.Replace(prefix)) + "%"
+}
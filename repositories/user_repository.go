@@ -2,21 +2,34 @@ package repositories
 
 import (
 	"errors"
+	"fmt"
+	"gin-user-app/apperrors"
 	"gin-user-app/models"
+	"gin-user-app/utils"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // UserRepository mendefinisikan operasi database untuk User
 type UserRepository interface {
-	GetAll() ([]models.User, error)
+	List(params UserListParams) (UserListResult, error)
 	GetByID(id int) (models.User, error)
 	Create(user models.User) (models.User, error)
 	Update(user models.User) (models.User, error)
-	Delete(id int) error
-	FindByUsername(username string) (models.User, error) 
+	Delete(id int, expectedVersion int) error
+	FindByUsername(username string) (models.User, error)
+	FindByEmail(email string) (models.User, error)
+	FindByPhone(phone string) (models.User, error)
 }
 
+var (
+	// ErrUserNotFound dikembalikan jika user tidak ada
+	ErrUserNotFound = apperrors.NotFound("user not found")
+	// ErrUserVersionConflict dikembalikan jika user sudah diubah sejak dibaca
+	ErrUserVersionConflict = apperrors.Conflict("user has been modified by another request")
+)
+
 // userRepositoryImpl implementasi dari UserRepository
 type userRepositoryImpl struct {
 	db *gorm.DB
@@ -27,14 +40,96 @@ func NewUserRepository(db *gorm.DB) UserRepository {
 	return &userRepositoryImpl{db: db}
 }
 
-// GetAll mengambil semua user dari database
-func (r *userRepositoryImpl) GetAll() ([]models.User, error) {
-	var users []models.User
-	result := r.db.Find(&users)
-	if result.Error != nil {
-		return nil, result.Error
+// List mengambil satu halaman user dengan filter dan sorting yang dikerjakan di database
+func (r *userRepositoryImpl) List(params UserListParams) (UserListResult, error) {
+	var result UserListResult
+
+	// Session baru supaya query dasar bisa dipakai ulang untuk count dan select
+	query := r.applyUserFilter(r.db.Model(&models.User{}), params.Filter).Session(&gorm.Session{})
+
+	if params.IncludeTotal {
+		if err := query.Count(&result.Total).Error; err != nil {
+			return UserListResult{}, err
+		}
+	}
+
+	field := params.Sort.Field
+	column, ok := userSortColumns[field]
+	if !ok {
+		field, column = "id", "id"
+	}
+	direction, operator := "ASC", ">"
+	if params.Sort.Desc {
+		direction, operator = "DESC", "<"
+	}
+
+	page := query
+	if params.Offset != nil {
+		page = page.Offset(*params.Offset)
+	} else if params.After != nil {
+		value, err := cursorValue(field, params.After.Value)
+		if err != nil {
+			return UserListResult{}, err
+		}
+		if field == "id" {
+			page = page.Where(fmt.Sprintf("id %s ?", operator), params.After.ID)
+		} else {
+			page = page.Where(fmt.Sprintf("(%s, id) %s (?, ?)", column, operator), value, params.After.ID)
+		}
+	}
+
+	if field == "id" {
+		page = page.Order("id " + direction)
+	} else {
+		page = page.Order(fmt.Sprintf("%s %s, id %s", column, direction, direction))
+	}
+
+	// Ambil satu baris ekstra untuk mengetahui apakah masih ada halaman berikutnya
+	if err := page.Limit(params.Limit + 1).Find(&result.Users).Error; err != nil {
+		return UserListResult{}, err
 	}
-	return users, nil
+	if len(result.Users) > params.Limit {
+		result.HasMore = true
+		result.Users = result.Users[:params.Limit]
+	}
+	return result, nil
+}
+
+// applyUserFilter menambahkan kondisi WHERE sesuai filter
+func (r *userRepositoryImpl) applyUserFilter(query *gorm.DB, filter UserFilter) *gorm.DB {
+	if filter.UsernamePrefix != "" {
+		query = query.Where("LOWER(username) LIKE ?", likePrefix(filter.UsernamePrefix))
+	}
+	if filter.EmailPrefix != "" {
+		query = query.Where("LOWER(email) LIKE ?", likePrefix(filter.EmailPrefix))
+	}
+	if filter.NamePrefix != "" {
+		prefix := likePrefix(filter.NamePrefix)
+		query = query.Where("(LOWER(first_name) LIKE ? OR LOWER(last_name) LIKE ?)", prefix, prefix)
+	}
+	if filter.AgeMin != nil {
+		query = query.Where("age >= ?", *filter.AgeMin)
+	}
+	if filter.AgeMax != nil {
+		query = query.Where("age <= ?", *filter.AgeMax)
+	}
+	if filter.CreatedAfter != nil {
+		query = query.Where("created_at >= ?", *filter.CreatedAfter)
+	}
+	if filter.CreatedBefore != nil {
+		query = query.Where("created_at < ?", *filter.CreatedBefore)
+	}
+	switch filter.Status {
+	case "":
+	case models.UserStatusActive:
+		query = query.Where("(status = ? OR (status IN ? AND status_expires_at <= CURRENT_TIMESTAMP))",
+			models.UserStatusActive, []string{models.UserStatusSuspended, models.UserStatusLocked})
+	case models.UserStatusSuspended, models.UserStatusLocked:
+		query = query.Where("status = ? AND (status_expires_at IS NULL OR status_expires_at > CURRENT_TIMESTAMP)", filter.Status)
+	default:
+		query = query.Where("status = ?", filter.Status)
+	}
+	return query
 }
 
 // GetByID mengambil user berdasarkan ID
@@ -42,38 +137,149 @@ func (r *userRepositoryImpl) GetByID(id int) (models.User, error) {
 	var user models.User
 	result := r.db.First(&user, id)
 	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-		return models.User{}, errors.New("user not found")
+		return models.User{}, ErrUserNotFound
 	}
 	return user, result.Error
 }
 
-// FindByUsername mencari user berdasarkan username
+// FindByUsername mencari user berdasarkan bentuk kanonik username, sehingga
+// huruf besar kecil tidak dibedakan
 func (r *userRepositoryImpl) FindByUsername(username string) (models.User, error) {
 	var user models.User
-	result := r.db.Where("username = ?", username).First(&user)
+	result := whereUsername(r.db, username).First(&user)
 	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-		return models.User{}, errors.New("user not found")
+		return models.User{}, ErrUserNotFound
 	}
 	return user, result.Error
 }
 
-// Create menambahkan user baru ke database
+// FindByEmail mencari user berdasarkan bentuk kanonik email, misalnya
+// "B.o.b@Gmail.com" menemukan user "bob@gmail.com"
+func (r *userRepositoryImpl) FindByEmail(email string) (models.User, error) {
+	var user models.User
+	result := whereEmail(r.db, email).First(&user)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return models.User{}, ErrUserNotFound
+	}
+	return user, result.Error
+}
+
+// FindByPhone mencari user berdasarkan nomor telepon E.164 yang sudah diverifikasi
+func (r *userRepositoryImpl) FindByPhone(phone string) (models.User, error) {
+	var user models.User
+	result := r.db.Where("phone = ? AND phone_verified_at IS NOT NULL", phone).First(&user)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return models.User{}, ErrUserNotFound
+	}
+	return user, result.Error
+}
+
+// Create menambahkan user baru ke database. Username atau email yang sudah
+// dipakai dikembalikan sebagai *ConstraintError dengan kind ErrUniqueViolation.
 func (r *userRepositoryImpl) Create(user models.User) (models.User, error) {
+	setCanonical(&user)
 	result := r.db.Create(&user)
-	return user, result.Error
+	if result.Error != nil {
+		return models.User{}, translateConstraintError(result.Error)
+	}
+	return user, nil
 }
 
-// Update memperbarui data user yang ada di database
+// Update memperbarui data user jika version di database masih sama dengan
+// user.Version saat dibaca, lalu menaikkan version. Jika user sudah diubah
+// request lain dikembalikan ErrUserVersionConflict, pelanggaran constraint
+// sebagai *ConstraintError. Kolom kanonik hanya dihitung ulang jika username
+// atau email berubah, sehingga user yang bentrok saat backfill tetap bisa
+// diubah selama username dan email-nya tidak diganti.
 func (r *userRepositoryImpl) Update(user models.User) (models.User, error) {
-	result := r.db.Save(&user)
-	return user, result.Error
+	expectedVersion := user.Version
+	user.Version = expectedVersion + 1
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// Dijalankan sebelum update utama agar username dan email di WHERE
+		// masih nilai lama
+		username := utils.CanonicalUsername(user.Username)
+		changed := tx.Model(&models.User{}).
+			Where("id = ? AND version = ? AND username <> ?", user.ID, expectedVersion, user.Username).
+			Update("username_canonical", username)
+		if changed.Error != nil {
+			return changed.Error
+		}
+		if changed.RowsAffected > 0 {
+			user.UsernameCanonical = &username
+		}
+		email := utils.CanonicalEmail(user.Email)
+		changed = tx.Model(&models.User{}).
+			Where("id = ? AND version = ? AND email <> ?", user.ID, expectedVersion, user.Email).
+			Update("email_canonical", email)
+		if changed.Error != nil {
+			return changed.Error
+		}
+		if changed.RowsAffected > 0 {
+			user.EmailCanonical = &email
+		}
+
+		result := tx.Model(&user).
+			Where("version = ?", expectedVersion).
+			Select("*").Omit("id", "created_at", "Roles", "username_canonical", "email_canonical").
+			Updates(&user)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrUserVersionConflict
+		}
+		return nil
+	})
+	if errors.Is(err, ErrUserVersionConflict) {
+		return models.User{}, err
+	}
+	if err != nil {
+		return models.User{}, translateConstraintError(err)
+	}
+	return user, nil
 }
 
-// Delete menghapus user berdasarkan ID
-func (r *userRepositoryImpl) Delete(id int) error {
-	result := r.db.Delete(&models.User{}, id)
+// Delete menghapus user berdasarkan ID. Jika expectedVersion bukan 0, user
+// hanya dihapus selama version-nya masih sama.
+func (r *userRepositoryImpl) Delete(id int, expectedVersion int) error {
+	query := r.db
+	if expectedVersion != 0 {
+		query = query.Where("version = ?", expectedVersion)
+	}
+	result := query.Delete(&models.User{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
 	if result.RowsAffected == 0 {
-		return errors.New("user not found")
+		if expectedVersion != 0 {
+			if _, err := r.GetByID(id); err == nil {
+				return ErrUserVersionConflict
+			}
+		}
+		return ErrUserNotFound
 	}
-	return result.Error
+	return nil
+}
+
+// whereUsername mencari user berdasarkan bentuk kanonik username atau nilai
+// aslinya. User yang bentrok saat backfill kolom kanoniknya NULL dan hanya
+// cocok lewat nilai asli; ia didahulukan agar login dengan username aslinya
+// menemukan akunnya sendiri, bukan user lama dengan bentuk kanonik yang sama.
+func whereUsername(db *gorm.DB, username string) *gorm.DB {
+	return db.Where("username_canonical = ? OR username = ?", utils.CanonicalUsername(username), username).
+		Order(clause.OrderByColumn{Column: clause.Column{Name: "username_canonical IS NULL", Raw: true}, Desc: true})
+}
+
+// whereEmail seperti whereUsername untuk email
+func whereEmail(db *gorm.DB, email string) *gorm.DB {
+	return db.Where("email_canonical = ? OR email = ?", utils.CanonicalEmail(email), email).
+		Order(clause.OrderByColumn{Column: clause.Column{Name: "email_canonical IS NULL", Raw: true}, Desc: true})
+}
+
+// setCanonical mengisi kolom kanonik user dari username dan email
+func setCanonical(user *models.User) {
+	username := utils.CanonicalUsername(user.Username)
+	email := utils.CanonicalEmail(user.Email)
+	user.UsernameCanonical = &username
+	user.EmailCanonical = &email
 }
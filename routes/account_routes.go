@@ -0,0 +1,25 @@
+package routes
+
+import (
+	"gin-user-app/handlers"
+	"gin-user-app/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AccountRoutes mengatur rute self-service untuk user yang login. Perubahan akun
+// hanya bisa dilakukan dari sesi login, bukan dengan personal access token.
+func AccountRoutes(r *gin.Engine, accountHandler *handlers.AccountHandler, authMiddleware gin.HandlerFunc) {
+	me := r.Group("/me")
+	me.Use(authMiddleware)
+	{
+		me.GET("", accountHandler.GetMe)
+		me.PATCH("", middleware.RequireAccessToken(), accountHandler.UpdateMe)
+		me.DELETE("", middleware.RequireAccessToken(), accountHandler.DeleteMe)
+		me.POST("/password", middleware.RequireAccessToken(), accountHandler.ChangePassword)
+		me.POST("/email", middleware.RequireAccessToken(), accountHandler.ChangeEmail)
+	}
+
+	// Link konfirmasi dibuka dari email, tanpa login
+	r.GET("/auth/confirm-email-change", accountHandler.ConfirmEmailChange)
+}
@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"gin-user-app/handlers"
+	"gin-user-app/middleware"
+	"gin-user-app/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AccountStatusRouter mengatur rute admin untuk suspend, reaktivasi dan perubahan status akun
+func AccountStatusRouter(r *gin.Engine, accountStatusHandler *handlers.AccountStatusHandler, authMiddleware gin.HandlerFunc) {
+	userStatus := r.Group("/users/:id")
+	userStatus.Use(authMiddleware, middleware.RequirePermission(models.PermissionUsersStatus))
+	{
+		userStatus.POST("/suspend", accountStatusHandler.SuspendUser)
+		userStatus.POST("/reactivate", accountStatusHandler.ReactivateUser)
+		userStatus.PUT("/status", accountStatusHandler.ChangeUserStatus)
+	}
+}
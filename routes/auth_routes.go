@@ -2,13 +2,19 @@ package routes
 
 import (
 	"gin-user-app/handlers"
+	"gin-user-app/middleware"
 	"github.com/gin-gonic/gin"
 )
 
-func AuthRoutes(router *gin.Engine, authHandler *handlers.AuthHandler, authMiddleware gin.HandlerFunc) {
+func AuthRoutes(router *gin.Engine, authHandler *handlers.AuthHandler, authMiddleware gin.HandlerFunc, rateLimit gin.HandlerFunc) {
 	auth := router.Group("/auth")
 	{
-		auth.POST("/login", authHandler.Login)
+		auth.POST("/login", rateLimit, authHandler.Login)
+		auth.POST("/login/mfa", rateLimit, authHandler.LoginMFA)
+		auth.POST("/login/password", rateLimit, authHandler.LoginPasswordChange)
+		auth.POST("/refresh", authHandler.Refresh)
 		auth.GET("/verify", authMiddleware, authHandler.VerifyToken) 
+		auth.POST("/logout", authMiddleware, middleware.RequireAccessToken(), authHandler.Logout)
+		auth.POST("/logout-all", authMiddleware, authHandler.LogoutAll)
 	}
 }
@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"gin-user-app/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EmailVerificationRoutes mengatur rute verifikasi email (tanpa login)
+func EmailVerificationRoutes(r *gin.Engine, verificationHandler *handlers.EmailVerificationHandler, rateLimit gin.HandlerFunc) {
+	verify := r.Group("/auth/verify-email")
+	{
+		verify.GET("", verificationHandler.VerifyEmail)
+		verify.POST("/resend", rateLimit, verificationHandler.ResendVerification)
+	}
+}
@@ -0,0 +1,12 @@
+package routes
+
+import (
+	"gin-user-app/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JWKSRoutes mengatur rute publik untuk key set verifikasi JWT
+func JWKSRoutes(r *gin.Engine, jwksHandler *handlers.JWKSHandler) {
+	r.GET("/.well-known/jwks.json", jwksHandler.GetJWKS)
+}
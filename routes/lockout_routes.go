@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"gin-user-app/handlers"
+	"gin-user-app/middleware"
+	"gin-user-app/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LockoutRouter mengatur rute admin untuk melihat dan membuka lockout login
+func LockoutRouter(r *gin.Engine, lockoutHandler *handlers.LockoutHandler, authMiddleware gin.HandlerFunc) {
+	r.GET("/lockouts", authMiddleware, middleware.RequirePermission(models.PermissionLockoutsRead), lockoutHandler.GetLockouts)
+
+	userLockout := r.Group("/users/:id/lockout")
+	userLockout.Use(authMiddleware)
+	{
+		userLockout.GET("", middleware.RequirePermission(models.PermissionLockoutsRead), lockoutHandler.GetUserLockout)
+		userLockout.DELETE("", middleware.RequirePermission(models.PermissionLockoutsManage), lockoutHandler.ClearUserLockout)
+	}
+}
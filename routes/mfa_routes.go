@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"gin-user-app/handlers"
+	"gin-user-app/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MFARoutes mengatur rute pengelolaan two-factor authentication milik user yang login.
+// Endpoint yang memeriksa kode dibatasi rateLimit agar kode tidak bisa ditebak.
+func MFARoutes(r *gin.Engine, mfaHandler *handlers.MFAHandler, authMiddleware gin.HandlerFunc, rateLimit gin.HandlerFunc) {
+	mfa := r.Group("/auth/mfa")
+	mfa.Use(authMiddleware, middleware.RequireAccessToken(), rateLimit)
+	{
+		mfa.POST("/enroll", mfaHandler.Enroll)
+		mfa.POST("/confirm", mfaHandler.Confirm)
+		mfa.POST("/disable", mfaHandler.Disable)
+		mfa.POST("/recovery-codes", mfaHandler.RegenerateRecoveryCodes)
+	}
+}
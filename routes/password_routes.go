@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"gin-user-app/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PasswordRoutes mengatur rute lupa password dan reset password (tanpa login)
+func PasswordRoutes(r *gin.Engine, passwordHandler *handlers.PasswordHandler, rateLimit gin.HandlerFunc) {
+	password := r.Group("/auth/password")
+	password.Use(rateLimit)
+	{
+		password.POST("/forgot", passwordHandler.ForgotPassword)
+		password.POST("/reset", passwordHandler.ResetPassword)
+	}
+}
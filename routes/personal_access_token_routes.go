@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"gin-user-app/handlers"
+	"gin-user-app/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PersonalAccessTokenRoutes mengatur rute personal access token milik user yang login.
+// Token hanya bisa dikelola dari sesi login, bukan dengan personal access token lain.
+func PersonalAccessTokenRoutes(r *gin.Engine, tokenHandler *handlers.PersonalAccessTokenHandler, authMiddleware gin.HandlerFunc) {
+	tokens := r.Group("/me/tokens")
+	tokens.Use(authMiddleware, middleware.RequireAccessToken())
+	{
+		tokens.POST("", tokenHandler.CreateToken)
+		tokens.GET("", tokenHandler.GetTokens)
+		tokens.DELETE("/:id", tokenHandler.RevokeToken)
+	}
+}
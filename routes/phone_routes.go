@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"gin-user-app/handlers"
+	"gin-user-app/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PhoneRoutes mengatur rute nomor telepon user yang login. Seperti perubahan
+// akun lainnya, hanya bisa dilakukan dari sesi login.
+func PhoneRoutes(r *gin.Engine, phoneHandler *handlers.PhoneHandler, authMiddleware gin.HandlerFunc) {
+	phone := r.Group("/me/phone")
+	phone.Use(authMiddleware, middleware.RequireAccessToken())
+	{
+		phone.POST("", phoneHandler.ChangePhone)
+		phone.POST("/verify", phoneHandler.VerifyPhone)
+		phone.DELETE("", phoneHandler.RemovePhone)
+	}
+}
@@ -0,0 +1,26 @@
+package routes
+
+import (
+	"gin-user-app/handlers"
+	"gin-user-app/middleware"
+	"gin-user-app/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RoleRouter mengatur rute manajemen role (khusus admin)
+func RoleRouter(r *gin.Engine, roleHandler *handlers.RoleHandler, authMiddleware gin.HandlerFunc) {
+	roles := r.Group("/roles")
+	roles.Use(authMiddleware)
+	{
+		roles.GET("", middleware.RequirePermission(models.PermissionRolesRead), roleHandler.GetRoles)
+		roles.POST("", middleware.RequirePermission(models.PermissionRolesManage), roleHandler.CreateRole)
+	}
+
+	userRoles := r.Group("/users/:id/roles")
+	userRoles.Use(authMiddleware)
+	{
+		userRoles.GET("", middleware.RequireSelfOrPermission("id", models.PermissionRolesRead), roleHandler.GetUserRoles)
+		userRoles.PUT("", middleware.RequirePermission(models.PermissionRolesAssign), roleHandler.AssignUserRoles)
+	}
+}
@@ -0,0 +1,26 @@
+package routes
+
+import (
+	"gin-user-app/handlers"
+	"gin-user-app/middleware"
+	"gin-user-app/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SessionRoutes mengatur rute sesi login milik user yang login dan rute admin untuk sesi user lain
+func SessionRoutes(r *gin.Engine, sessionHandler *handlers.SessionHandler, authMiddleware gin.HandlerFunc) {
+	mySessions := r.Group("/me/sessions")
+	mySessions.Use(authMiddleware, middleware.RequireAccessToken())
+	{
+		mySessions.GET("", sessionHandler.GetMySessions)
+		mySessions.DELETE("/:id", sessionHandler.RevokeMySession)
+	}
+
+	userSessions := r.Group("/users/:id/sessions")
+	userSessions.Use(authMiddleware)
+	{
+		userSessions.GET("", middleware.RequirePermission(models.PermissionSessionsRead), sessionHandler.GetUserSessions)
+		userSessions.DELETE("/:sessionId", middleware.RequirePermission(models.PermissionSessionsManage), sessionHandler.RevokeUserSession)
+	}
+}
@@ -2,20 +2,26 @@ package routes
 
 import (
 	"gin-user-app/handlers"
+	"gin-user-app/middleware"
+	"gin-user-app/models"
+
 	"github.com/gin-gonic/gin"
 )
 
-// UserRouter mengatur rute pengguna dengan proteksi middleware
-func UserRouter(r *gin.Engine, userHandler *handlers.UserHandler, authMiddleware gin.HandlerFunc) {
+// UserRouter mengatur rute pengguna dengan proteksi middleware.
+// ifMatch dipasang di route yang mengubah user (lihat middleware.RequireIfMatch).
+func UserRouter(r *gin.Engine, userHandler *handlers.UserHandler, authMiddleware gin.HandlerFunc, ifMatch gin.HandlerFunc) {
 	// Group untuk Protected Routes
 	protected := r.Group("/users")
 	protected.Use(authMiddleware)
 
+	// User biasa hanya boleh mengubah datanya sendiri, admin boleh semua
 	{
-		protected.GET("", userHandler.GetUsers)
-		protected.GET("/:id", userHandler.GetUserByID)
-		protected.POST("", userHandler.CreateUser)
-		protected.PUT("/:id", userHandler.UpdateUser)
-		protected.DELETE("/:id", userHandler.DeleteUser)
+		protected.GET("", middleware.RequirePermission(models.PermissionUsersRead), userHandler.GetUsers)
+		protected.GET("/:id", middleware.RequireSelfOrPermission("id", models.PermissionUsersRead), userHandler.GetUserByID)
+		protected.POST("", middleware.RequirePermission(models.PermissionUsersCreate), userHandler.CreateUser)
+		protected.PUT("/:id", middleware.RequireSelfOrPermission("id", models.PermissionUsersUpdate), ifMatch, userHandler.UpdateUser)
+		protected.PATCH("/:id", middleware.RequireSelfOrPermission("id", models.PermissionUsersUpdate), ifMatch, userHandler.PatchUser)
+		protected.DELETE("/:id", middleware.RequirePermission(models.PermissionUsersDelete), ifMatch, userHandler.DeleteUser)
 	}
 }
@@ -0,0 +1,325 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"gin-user-app/apperrors"
+	"gin-user-app/dto"
+	"gin-user-app/mailer"
+	"gin-user-app/models"
+	"gin-user-app/repositories"
+	"gin-user-app/utils"
+	"log"
+	"strings"
+	"time"
+)
+
+// emailChangeTokenSize is the number of random bytes in an email change token.
+const emailChangeTokenSize = 32
+
+var (
+	// ErrInvalidProfile is returned when a profile field fails validation.
+	ErrInvalidProfile = apperrors.Validation("invalid profile")
+	// ErrInvalidCurrentPassword is returned when the confirmation password is wrong.
+	ErrInvalidCurrentPassword = apperrors.Forbidden("current password is incorrect")
+	// ErrInvalidEmail is returned for a new email that is malformed or not
+	// allowed by the email policy. The returned error also lists the failed rule.
+	ErrInvalidEmail = apperrors.Invalid("new_email", "email is not an allowed address")
+	// ErrEmailUnchanged is returned when the new email equals the current one.
+	ErrEmailUnchanged = apperrors.Invalid("new_email", "new email is the same as the current email")
+	// ErrEmailTaken is returned when another user already has the email.
+	ErrEmailTaken = apperrors.Conflict("email already taken", "email")
+	// ErrInvalidEmailChangeToken is returned for unknown, expired or used email change tokens.
+	ErrInvalidEmailChangeToken = apperrors.BadRequest("invalid or expired email change token")
+)
+
+// AccountService lets the authenticated user manage their own account
+type AccountService interface {
+	GetProfile(userID int) (dto.UserDTO, error)
+	UpdateProfile(userID int, req dto.UpdateProfileDTO) (dto.UserDTO, error)
+	ChangePassword(userID int, req dto.ChangePasswordRequestDTO) error
+	RequestEmailChange(userID int, req dto.ChangeEmailRequestDTO) error
+	ConfirmEmailChange(token string) error
+	DeleteAccount(userID int, req dto.DeleteAccountRequestDTO) error
+}
+
+// AccountServiceImpl implements AccountService
+type AccountServiceImpl struct {
+	userRepo        repositories.UserRepository
+	emailChangeRepo repositories.EmailChangeRepository
+	authService     *AuthService
+	pats            PersonalAccessTokenService
+	lockouts        LockoutService
+	emailPolicy     EmailPolicy
+	passwordPolicy  PasswordPolicy
+	mailer          mailer.Mailer
+	confirmURL      string
+	emailChangeTTL  time.Duration
+	now             func() time.Time
+}
+
+// NewAccountService creates a new instance of AccountService. confirmURL
+// receives the email change token as the "token" query parameter. emailPolicy
+// may be nil to accept every email domain, passwordPolicy to only check the
+// minimum password length.
+func NewAccountService(userRepo repositories.UserRepository, emailChangeRepo repositories.EmailChangeRepository, authService *AuthService, pats PersonalAccessTokenService, lockouts LockoutService, emailPolicy EmailPolicy, passwordPolicy PasswordPolicy, m mailer.Mailer, confirmURL string, emailChangeTTL time.Duration) AccountService {
+	return &AccountServiceImpl{
+		userRepo:        userRepo,
+		emailChangeRepo: emailChangeRepo,
+		authService:     authService,
+		pats:            pats,
+		lockouts:        lockouts,
+		emailPolicy:     emailPolicy,
+		passwordPolicy:  passwordPolicy,
+		mailer:          m,
+		confirmURL:      confirmURL,
+		emailChangeTTL:  emailChangeTTL,
+		now:             time.Now,
+	}
+}
+
+// GetProfile returns the full profile of the user
+func (s *AccountServiceImpl) GetProfile(userID int) (dto.UserDTO, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return dto.UserDTO{}, err
+	}
+	return toUserDTO(user), nil
+}
+
+// UpdateProfile changes the name and age of the user. Username, email and
+// password have their own flows and cannot be changed here.
+func (s *AccountServiceImpl) UpdateProfile(userID int, req dto.UpdateProfileDTO) (dto.UserDTO, error) {
+	if err := validateProfile(req); err != nil {
+		return dto.UserDTO{}, err
+	}
+
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return dto.UserDTO{}, err
+	}
+	if req.FirstName != nil {
+		user.FirstName = *req.FirstName
+	}
+	if req.LastName != nil {
+		user.LastName = *req.LastName
+	}
+	if req.Age != nil {
+		user.Age = req.Age
+	}
+	user.UpdatedAt = s.now()
+
+	updated, err := s.userRepo.Update(user)
+	if err != nil {
+		return dto.UserDTO{}, err
+	}
+	return toUserDTO(updated), nil
+}
+
+// ChangePassword sets a new password after checking the current one. The new
+// password must meet the password policy, including the history. Every
+// session of the user, including the current one, is logged out.
+func (s *AccountServiceImpl) ChangePassword(userID int, req dto.ChangePasswordRequestDTO) error {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return err
+	}
+	if err := s.checkPassword(user, req.CurrentPassword); err != nil {
+		return err
+	}
+	if err := validateNewPassword(s.passwordPolicy, "new_password", user, req.NewPassword); err != nil {
+		return err
+	}
+
+	previous := user
+	hash, err := utils.HashPassword(req.NewPassword)
+	if err != nil {
+		return err
+	}
+	now := s.now()
+	user.Password = hash
+	user.PasswordChangedAt = &now
+	user.UpdatedAt = now
+	if _, err := s.userRepo.Update(user); err != nil {
+		return err
+	}
+	rememberPassword(s.passwordPolicy, previous)
+	return s.authService.LogoutAll(userID)
+}
+
+// RequestEmailChange emails a confirmation link to the new address. The
+// current email stays in place until the link is opened, and the current
+// address is told about the request.
+func (s *AccountServiceImpl) RequestEmailChange(userID int, req dto.ChangeEmailRequestDTO) error {
+	newEmail := strings.TrimSpace(req.NewEmail)
+	rules := utils.RuleSet{{Field: "new_email", Label: "email", Rules: emailRules()}}
+	if s.emailPolicy != nil {
+		rules = rules.With("new_email", s.emailPolicy.Rules(req.Tenant)...)
+	}
+	if err := withCause(rules.Validate(map[string]interface{}{"new_email": newEmail}), ErrInvalidEmail); err != nil {
+		return err
+	}
+
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return err
+	}
+	if err := s.checkPassword(user, req.CurrentPassword); err != nil {
+		return err
+	}
+	if newEmail == user.Email {
+		return ErrEmailUnchanged
+	}
+	if err := s.ensureEmailAvailable(newEmail, userID); err != nil {
+		return err
+	}
+
+	token, err := utils.GenerateOpaqueToken(emailChangeTokenSize)
+	if err != nil {
+		return err
+	}
+	now := s.now()
+	if err := s.emailChangeRepo.InvalidateForUser(userID, now); err != nil {
+		return err
+	}
+	if err := s.emailChangeRepo.Create(models.EmailChangeToken{
+		UserID:    userID,
+		NewEmail:  newEmail,
+		TokenHash: utils.HashToken(token),
+		ExpiresAt: now.Add(s.emailChangeTTL),
+	}); err != nil {
+		return err
+	}
+
+	if err := s.mailer.Send(mailer.Message{
+		To:      newEmail,
+		Subject: "Confirm your new email address",
+		Body: fmt.Sprintf("Hi %s,\n\nOpen the link below to use this address for your account. It expires in %s.\n\n%s\n",
+			user.Username, s.emailChangeTTL, appendToken(s.confirmURL, token)),
+	}); err != nil {
+		return err
+	}
+
+	// The notice only informs the old address, a failure must not block the change
+	err = s.mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Your email address is being changed",
+		Body: fmt.Sprintf("Hi %s,\n\nA change of your account email to %s was requested. If this was not you, change your password and log out all sessions.\n",
+			user.Username, newEmail),
+	})
+	if err != nil {
+		log.Println("Failed to send email change notice:", err)
+	}
+	return nil
+}
+
+// ConfirmEmailChange switches the user to the email the token was sent to.
+// The new address counts as verified.
+func (s *AccountServiceImpl) ConfirmEmailChange(token string) error {
+	change, err := s.emailChangeRepo.FindByHash(utils.HashToken(token))
+	if err != nil {
+		return ErrInvalidEmailChangeToken
+	}
+	now := s.now()
+	if change.UsedAt != nil || now.After(change.ExpiresAt) {
+		return ErrInvalidEmailChangeToken
+	}
+	// The address may have been registered by someone else since the request
+	if err := s.ensureEmailAvailable(change.NewEmail, change.UserID); err != nil {
+		return err
+	}
+
+	consumed, err := s.emailChangeRepo.Consume(change, now)
+	if errors.Is(err, ErrDuplicate) {
+		return ErrEmailTaken
+	}
+	if err != nil {
+		return err
+	}
+	if !consumed {
+		return ErrInvalidEmailChangeToken
+	}
+	return nil
+}
+
+// DeleteAccount closes the account after checking the password. All sessions
+// and personal access tokens stop working.
+func (s *AccountServiceImpl) DeleteAccount(userID int, req dto.DeleteAccountRequestDTO) error {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return err
+	}
+	if err := s.checkPassword(user, req.Password); err != nil {
+		return err
+	}
+
+	if err := s.pats.RevokeAll(userID); err != nil {
+		return err
+	}
+	if err := s.authService.LogoutAll(userID); err != nil {
+		return err
+	}
+	return s.userRepo.Delete(userID, 0)
+}
+
+// checkPassword verifies a confirmation password of the user
+func (s *AccountServiceImpl) checkPassword(user models.User, password string) error {
+	return confirmPassword(s.lockouts, user, password)
+}
+
+// confirmPassword verifies a confirmation password. Failures count towards the
+// login lockout so a stolen access token cannot be used to guess the password.
+func confirmPassword(lockouts LockoutService, user models.User, password string) error {
+	if err := lockouts.Check(user.ID); err != nil {
+		return err
+	}
+	if ok, _ := utils.VerifyPassword(password, user.Password); !ok {
+		if err := lockouts.RecordFailure(user.ID); err != nil {
+			return err
+		}
+		return ErrInvalidCurrentPassword
+	}
+	return lockouts.RecordSuccess(user.ID)
+}
+
+// ensureEmailAvailable returns ErrEmailTaken when another user has the email
+func (s *AccountServiceImpl) ensureEmailAvailable(email string, userID int) error {
+	existing, err := s.userRepo.FindByEmail(email)
+	if err != nil {
+		if errors.Is(err, repositories.ErrUserNotFound) {
+			return nil
+		}
+		return err
+	}
+	if existing.ID != userID {
+		return ErrEmailTaken
+	}
+	return nil
+}
+
+// validateProfile checks the profile fields that are present with the same
+// rules as the user endpoints. All failing fields are reported together and
+// the error also matches ErrInvalidProfile.
+func validateProfile(req dto.UpdateProfileDTO) error {
+	values := map[string]interface{}{}
+	if req.FirstName != nil {
+		values["first_name"] = *req.FirstName
+	}
+	if req.LastName != nil {
+		values["last_name"] = *req.LastName
+	}
+	if req.Age != nil {
+		values["age"] = req.Age
+	}
+	return withCause(userRules.Validate(values), ErrInvalidProfile)
+}
+
+// withCause sets cause on a validation error so that callers can match it
+// with errors.Is while the field errors stay intact.
+func withCause(err error, cause error) error {
+	var invalid *apperrors.Error
+	if errors.As(err, &invalid) {
+		invalid.Err = cause
+	}
+	return err
+}
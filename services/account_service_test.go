@@ -0,0 +1,238 @@
+package services
+
+import (
+	"gin-user-app/apperrors"
+	"gin-user-app/dto"
+	"gin-user-app/mailer"
+	"gin-user-app/models"
+	"gin-user-app/repositories"
+	"gin-user-app/utils"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockEmailChangeRepository adalah mock untuk EmailChangeRepository
+type MockEmailChangeRepository struct {
+	mock.Mock
+}
+
+func (m *MockEmailChangeRepository) Create(token models.EmailChangeToken) error {
+	args := m.Called(token)
+	return args.Error(0)
+}
+
+func (m *MockEmailChangeRepository) FindByHash(hash string) (models.EmailChangeToken, error) {
+	args := m.Called(hash)
+	return args.Get(0).(models.EmailChangeToken), args.Error(1)
+}
+
+func (m *MockEmailChangeRepository) InvalidateForUser(userID int, at time.Time) error {
+	args := m.Called(userID, at)
+	return args.Error(0)
+}
+
+func (m *MockEmailChangeRepository) Consume(token models.EmailChangeToken, at time.Time) (bool, error) {
+	args := m.Called(token, at)
+	return args.Bool(0), args.Error(1)
+}
+
+// accountTestDeps holds the mocks behind a test AccountService
+type accountTestDeps struct {
+	userRepo        *MockUserRepository
+	emailChangeRepo *MockEmailChangeRepository
+	attemptRepo     *MockLoginAttemptRepository
+	patRepo         *MockPersonalAccessTokenRepository
+	sessions        *MockSessionService
+	outbox          *mailer.MemoryMailer
+}
+
+// newTestAccountService returns an AccountService for user 1 "alice" whose password is "current-password"
+func newTestAccountService(t *testing.T, now time.Time) (*AccountServiceImpl, accountTestDeps) {
+	hash, err := utils.HashPassword("current-password")
+	assert.NoError(t, err)
+
+	deps := accountTestDeps{
+		userRepo:        new(MockUserRepository),
+		emailChangeRepo: new(MockEmailChangeRepository),
+		attemptRepo:     new(MockLoginAttemptRepository),
+		patRepo:         new(MockPersonalAccessTokenRepository),
+		sessions:        new(MockSessionService),
+		outbox:          mailer.NewMemoryMailer("no-reply@test"),
+	}
+	deps.userRepo.On("GetByID", 1).Return(models.User{ID: 1, Username: "alice", Email: "alice@gmail.com", Password: hash, FirstName: "Alice"}, nil)
+	deps.attemptRepo.On("Get", 1).Return(models.LoginAttempt{}, nil).Maybe()
+	deps.attemptRepo.On("Clear", 1).Return(nil).Maybe()
+
+	pats := newTestPersonalAccessTokenService(deps.patRepo, now)
+	authService := NewAuthService(nil, nil, repositories.NewMemoryRevocationStore(), nil, nil, nil, newTestTokenService(), deps.sessions, nil, testAuthTokens)
+
+	service := NewAccountService(deps.userRepo, deps.emailChangeRepo, authService, pats, newTestLockoutService(deps.attemptRepo, now), newGmailOnlyPolicy(t), nil, deps.outbox, "http://localhost/auth/confirm-email-change", time.Hour).(*AccountServiceImpl)
+	service.now = func() time.Time { return now }
+	return service, deps
+}
+
+// TestUpdateProfile_OnlyProfileFieldsUnit tests that only the given profile fields change
+func TestUpdateProfile_OnlyProfileFieldsUnit(t *testing.T) {
+	now := time.Now()
+	service, deps := newTestAccountService(t, now)
+
+	var saved models.User
+	deps.userRepo.On("Update", mock.Anything).Run(func(args mock.Arguments) {
+		saved = args.Get(0).(models.User)
+	}).Return(models.User{ID: 1, Username: "alice", LastName: "Smith"}, nil)
+
+	lastName := "Smith"
+	user, err := service.UpdateProfile(1, dto.UpdateProfileDTO{LastName: &lastName})
+	assert.NoError(t, err)
+	assert.Equal(t, "Smith", user.LastName)
+	assert.Equal(t, "Alice", saved.FirstName)
+	assert.Equal(t, "Smith", saved.LastName)
+	assert.Equal(t, "alice@gmail.com", saved.Email)
+	assert.Equal(t, now, saved.UpdatedAt)
+
+	short := "Al"
+	_, err = service.UpdateProfile(1, dto.UpdateProfileDTO{FirstName: &short})
+	assert.ErrorIs(t, err, ErrInvalidProfile)
+	deps.userRepo.AssertNumberOfCalls(t, "Update", 1)
+}
+
+// TestChangePassword_WrongCurrentPasswordUnit tests that a wrong current password is counted as a failed login
+func TestChangePassword_WrongCurrentPasswordUnit(t *testing.T) {
+	now := time.Now()
+	service, deps := newTestAccountService(t, now)
+	deps.attemptRepo.On("RecordFailure", 1, now).Return(models.LoginAttempt{UserID: 1, FailedAttempts: 1}, nil)
+
+	err := service.ChangePassword(1, dto.ChangePasswordRequestDTO{CurrentPassword: "wrong-password", NewPassword: "new-password-123"})
+	assert.ErrorIs(t, err, ErrInvalidCurrentPassword)
+	deps.attemptRepo.AssertExpectations(t)
+	deps.userRepo.AssertNotCalled(t, "Update", mock.Anything)
+	deps.sessions.AssertNotCalled(t, "RevokeAll", mock.Anything)
+}
+
+// TestChangePassword_LogsOutEverywhereUnit tests that a changed password ends every session
+func TestChangePassword_LogsOutEverywhereUnit(t *testing.T) {
+	service, deps := newTestAccountService(t, time.Now())
+
+	var saved models.User
+	deps.userRepo.On("Update", mock.Anything).Run(func(args mock.Arguments) {
+		saved = args.Get(0).(models.User)
+	}).Return(models.User{}, nil)
+	deps.sessions.On("RevokeAll", 1).Return(nil)
+
+	err := service.ChangePassword(1, dto.ChangePasswordRequestDTO{CurrentPassword: "current-password", NewPassword: "new-password-123"})
+	assert.NoError(t, err)
+	assert.True(t, utils.CheckPasswordHash("new-password-123", saved.Password))
+	deps.sessions.AssertExpectations(t)
+}
+
+// TestChangePassword_EnforcesPasswordPolicyUnit tests that the current password and personal information are rejected
+func TestChangePassword_EnforcesPasswordPolicyUnit(t *testing.T) {
+	service, deps := newTestAccountService(t, time.Now())
+	policy, err := NewPasswordPolicy(PasswordPolicyConfig{RejectPersonalInfo: true, HistorySize: 1}, nil)
+	assert.NoError(t, err)
+	service.passwordPolicy = policy
+
+	err = service.ChangePassword(1, dto.ChangePasswordRequestDTO{CurrentPassword: "current-password", NewPassword: "current-password"})
+	assert.ErrorIs(t, err, ErrInvalidPassword)
+	assert.Equal(t, "reused", apperrors.Fields(err)[0].Code)
+
+	err = service.ChangePassword(1, dto.ChangePasswordRequestDTO{CurrentPassword: "current-password", NewPassword: "alice-2024-secret"})
+	assert.ErrorIs(t, err, ErrInvalidPassword)
+	assert.Equal(t, "personal_info", apperrors.Fields(err)[0].Code)
+	deps.userRepo.AssertNotCalled(t, "Update", mock.Anything)
+}
+
+// TestRequestEmailChange_ConfirmsNewAddressUnit tests that the email is not changed before the new address confirms it
+func TestRequestEmailChange_ConfirmsNewAddressUnit(t *testing.T) {
+	now := time.Now()
+	service, deps := newTestAccountService(t, now)
+
+	var stored models.EmailChangeToken
+	deps.userRepo.On("FindByEmail", "bob@gmail.com").Return(models.User{}, repositories.ErrUserNotFound)
+	deps.emailChangeRepo.On("InvalidateForUser", 1, now).Return(nil)
+	deps.emailChangeRepo.On("Create", mock.Anything).Run(func(args mock.Arguments) {
+		stored = args.Get(0).(models.EmailChangeToken)
+	}).Return(nil)
+
+	err := service.RequestEmailChange(1, dto.ChangeEmailRequestDTO{NewEmail: " bob@gmail.com ", CurrentPassword: "current-password"})
+	assert.NoError(t, err)
+	deps.userRepo.AssertNotCalled(t, "Update", mock.Anything)
+
+	messages := deps.outbox.Messages()
+	assert.Len(t, messages, 2)
+	assert.Equal(t, "bob@gmail.com", messages[0].To)
+	assert.Equal(t, "alice@gmail.com", messages[1].To)
+
+	body := messages[0].Body
+	start := strings.Index(body, "token=") + len("token=")
+	token := strings.Fields(body[start:])[0]
+	assert.Equal(t, utils.HashToken(token), stored.TokenHash)
+	assert.Equal(t, "bob@gmail.com", stored.NewEmail)
+	assert.Equal(t, now.Add(time.Hour), stored.ExpiresAt)
+}
+
+// TestRequestEmailChange_RejectsTakenEmailUnit tests that an email of another user cannot be requested
+func TestRequestEmailChange_RejectsTakenEmailUnit(t *testing.T) {
+	service, deps := newTestAccountService(t, time.Now())
+	deps.userRepo.On("FindByEmail", "bob@gmail.com").Return(models.User{ID: 2}, nil)
+
+	err := service.RequestEmailChange(1, dto.ChangeEmailRequestDTO{NewEmail: "bob@gmail.com", CurrentPassword: "current-password"})
+	assert.ErrorIs(t, err, ErrEmailTaken)
+
+	err = service.RequestEmailChange(1, dto.ChangeEmailRequestDTO{NewEmail: "alice@gmail.com", CurrentPassword: "current-password"})
+	assert.ErrorIs(t, err, ErrEmailUnchanged)
+
+	err = service.RequestEmailChange(1, dto.ChangeEmailRequestDTO{NewEmail: "bob@example.com", CurrentPassword: "current-password"})
+	assert.ErrorIs(t, err, ErrInvalidEmail)
+	deps.emailChangeRepo.AssertNotCalled(t, "Create", mock.Anything)
+	assert.Empty(t, deps.outbox.Messages())
+}
+
+// TestConfirmEmailChange_RejectsExpiredAndTakenUnit tests that expired tokens and addresses taken in the meantime are rejected
+func TestConfirmEmailChange_RejectsExpiredAndTakenUnit(t *testing.T) {
+	now := time.Now()
+	service, deps := newTestAccountService(t, now)
+
+	deps.emailChangeRepo.On("FindByHash", utils.HashToken("expired")).Return(models.EmailChangeToken{UserID: 1, NewEmail: "bob@gmail.com", ExpiresAt: now.Add(-time.Second)}, nil)
+	deps.emailChangeRepo.On("FindByHash", utils.HashToken("taken")).Return(models.EmailChangeToken{UserID: 1, NewEmail: "carol@gmail.com", ExpiresAt: now.Add(time.Hour)}, nil)
+	deps.emailChangeRepo.On("FindByHash", utils.HashToken("valid")).Return(models.EmailChangeToken{ID: 5, UserID: 1, NewEmail: "bob@gmail.com", ExpiresAt: now.Add(time.Hour)}, nil)
+	deps.userRepo.On("FindByEmail", "carol@gmail.com").Return(models.User{ID: 3}, nil)
+	deps.userRepo.On("FindByEmail", "bob@gmail.com").Return(models.User{}, repositories.ErrUserNotFound)
+	deps.emailChangeRepo.On("Consume", mock.MatchedBy(func(token models.EmailChangeToken) bool { return token.ID == 5 }), now).Return(true, nil)
+
+	assert.ErrorIs(t, service.ConfirmEmailChange("expired"), ErrInvalidEmailChangeToken)
+	assert.ErrorIs(t, service.ConfirmEmailChange("taken"), ErrEmailTaken)
+	assert.NoError(t, service.ConfirmEmailChange("valid"))
+	deps.emailChangeRepo.AssertNumberOfCalls(t, "Consume", 1)
+}
+
+// TestConfirmEmailChange_LostRaceUnit tests that an address taken between the check and the write is reported as taken
+func TestConfirmEmailChange_LostRaceUnit(t *testing.T) {
+	now := time.Now()
+	service, deps := newTestAccountService(t, now)
+
+	deps.emailChangeRepo.On("FindByHash", utils.HashToken("valid")).Return(models.EmailChangeToken{ID: 5, UserID: 1, NewEmail: "bob@gmail.com", ExpiresAt: now.Add(time.Hour)}, nil)
+	deps.userRepo.On("FindByEmail", "bob@gmail.com").Return(models.User{}, repositories.ErrUserNotFound)
+	deps.emailChangeRepo.On("Consume", mock.Anything, now).Return(false, &repositories.ConstraintError{Kind: repositories.ErrUniqueViolation, Field: "email"})
+
+	assert.ErrorIs(t, service.ConfirmEmailChange("valid"), ErrEmailTaken)
+}
+
+// TestDeleteAccount_RevokesEverythingUnit tests that closing an account revokes sessions and personal access tokens
+func TestDeleteAccount_RevokesEverythingUnit(t *testing.T) {
+	now := time.Now()
+	service, deps := newTestAccountService(t, now)
+
+	deps.patRepo.On("RevokeAllForUser", 1, now).Return(nil)
+	deps.sessions.On("RevokeAll", 1).Return(nil)
+	deps.userRepo.On("Delete", 1, 0).Return(nil)
+
+	assert.NoError(t, service.DeleteAccount(1, dto.DeleteAccountRequestDTO{Password: "current-password"}))
+	deps.patRepo.AssertExpectations(t)
+	deps.sessions.AssertExpectations(t)
+	deps.userRepo.AssertExpectations(t)
+}
@@ -0,0 +1,181 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"gin-user-app/apperrors"
+	"gin-user-app/dto"
+	"gin-user-app/models"
+	"gin-user-app/repositories"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrAccountSuspended is returned for users whose account is suspended.
+	ErrAccountSuspended = apperrors.Forbidden("account is suspended")
+	// ErrAccountStatusLocked is returned for users whose account was locked by an administrator.
+	ErrAccountStatusLocked = apperrors.New(apperrors.ErrLocked, "account is locked")
+	// ErrAccountPending is returned for users whose account is not activated yet.
+	ErrAccountPending = apperrors.Forbidden("account is not activated")
+	// ErrAccountDeactivated is returned for users whose account is deactivated.
+	ErrAccountDeactivated = apperrors.Forbidden("account is deactivated")
+	// ErrAccountGone is returned for tokens of users that no longer exist.
+	ErrAccountGone = apperrors.Unauthorized("account no longer exists")
+	// ErrInvalidStatusTransition is returned when the state machine does not allow a status change.
+	ErrInvalidStatusTransition = apperrors.New(apperrors.ErrConflict, "account status change is not allowed")
+	// ErrInvalidAccountStatus is returned for an unknown status.
+	ErrInvalidAccountStatus = apperrors.Invalid("status", "status must be one of active, pending, suspended, locked or deactivated")
+	// ErrStatusReasonRequired is returned when a status change has no reason.
+	ErrStatusReasonRequired = apperrors.Invalid("reason", "reason is required")
+	// ErrInvalidStatusExpiry is returned for an expiry in the past or on a status that cannot expire.
+	ErrInvalidStatusExpiry = apperrors.Invalid("expires_at", "expires_at must be in the future and is only allowed for suspended and locked")
+	// ErrOwnAccountStatus is returned when an administrator changes the status of their own account.
+	ErrOwnAccountStatus = apperrors.Forbidden("you cannot change the status of your own account")
+)
+
+// accountStatusTransitions is the account state machine: the statuses each
+// status may change to. Suspending or locking again replaces the reason and
+// the expiry.
+var accountStatusTransitions = map[string][]string{
+	models.UserStatusPending:     {models.UserStatusActive, models.UserStatusDeactivated},
+	models.UserStatusActive:      {models.UserStatusSuspended, models.UserStatusLocked, models.UserStatusDeactivated},
+	models.UserStatusSuspended:   {models.UserStatusActive, models.UserStatusSuspended, models.UserStatusLocked, models.UserStatusDeactivated},
+	models.UserStatusLocked:      {models.UserStatusActive, models.UserStatusSuspended, models.UserStatusLocked, models.UserStatusDeactivated},
+	models.UserStatusDeactivated: {models.UserStatusActive},
+}
+
+// AccountStatusService manages the account status lifecycle
+type AccountStatusService interface {
+	Suspend(userID, actorID int, req dto.SuspendUserRequestDTO) (dto.UserDTO, error)
+	Reactivate(userID, actorID int, req dto.ReactivateUserRequestDTO) (dto.UserDTO, error)
+	ChangeStatus(userID, actorID int, req dto.ChangeUserStatusRequestDTO) (dto.UserDTO, error)
+	// CheckActive returns an error unless the user exists and is active
+	CheckActive(userID int) error
+}
+
+// AccountStatusServiceImpl implements AccountStatusService
+type AccountStatusServiceImpl struct {
+	userRepo    repositories.UserRepository
+	authService *AuthService
+	now         func() time.Time
+}
+
+// NewAccountStatusService creates a new instance of AccountStatusService
+func NewAccountStatusService(userRepo repositories.UserRepository, authService *AuthService) AccountStatusService {
+	return &AccountStatusServiceImpl{
+		userRepo:    userRepo,
+		authService: authService,
+		now:         time.Now,
+	}
+}
+
+// Suspend suspends the user, until ExpiresAt when given
+func (s *AccountStatusServiceImpl) Suspend(userID, actorID int, req dto.SuspendUserRequestDTO) (dto.UserDTO, error) {
+	return s.ChangeStatus(userID, actorID, dto.ChangeUserStatusRequestDTO{
+		Status:    models.UserStatusSuspended,
+		Reason:    req.Reason,
+		ExpiresAt: req.ExpiresAt,
+	})
+}
+
+// Reactivate makes a suspended, locked, deactivated or pending user active
+func (s *AccountStatusServiceImpl) Reactivate(userID, actorID int, req dto.ReactivateUserRequestDTO) (dto.UserDTO, error) {
+	return s.ChangeStatus(userID, actorID, dto.ChangeUserStatusRequestDTO{
+		Status: models.UserStatusActive,
+		Reason: req.Reason,
+	})
+}
+
+// ChangeStatus moves the user to another status if the state machine allows
+// it and records the reason and the administrator. Leaving the active status
+// logs the user out everywhere; personal access tokens are refused while the
+// user is not active and work again after a reactivation.
+func (s *AccountStatusServiceImpl) ChangeStatus(userID, actorID int, req dto.ChangeUserStatusRequestDTO) (dto.UserDTO, error) {
+	if _, ok := accountStatusTransitions[req.Status]; !ok {
+		return dto.UserDTO{}, ErrInvalidAccountStatus
+	}
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		return dto.UserDTO{}, ErrStatusReasonRequired
+	}
+	now := s.now()
+	if req.ExpiresAt != nil && (!canExpire(req.Status) || !req.ExpiresAt.After(now)) {
+		return dto.UserDTO{}, ErrInvalidStatusExpiry
+	}
+	if userID == actorID {
+		return dto.UserDTO{}, ErrOwnAccountStatus
+	}
+
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return dto.UserDTO{}, err
+	}
+	current := EffectiveStatus(user, now)
+	if !containsString(accountStatusTransitions[current], req.Status) {
+		return dto.UserDTO{}, fmt.Errorf("%w: cannot change from %s to %s", ErrInvalidStatusTransition, current, req.Status)
+	}
+
+	user.Status = req.Status
+	user.StatusReason = reason
+	user.StatusChangedAt = &now
+	user.StatusChangedBy = &actorID
+	user.StatusExpiresAt = req.ExpiresAt
+	user.UpdatedAt = now
+	updated, err := s.userRepo.Update(user)
+	if err != nil {
+		return dto.UserDTO{}, err
+	}
+
+	if req.Status != models.UserStatusActive {
+		if err := s.authService.LogoutAll(userID); err != nil {
+			return dto.UserDTO{}, err
+		}
+	}
+	return toUserDTO(updated), nil
+}
+
+// CheckActive loads the user and reports its status as an error
+func (s *AccountStatusServiceImpl) CheckActive(userID int) error {
+	user, err := s.userRepo.GetByID(userID)
+	if errors.Is(err, repositories.ErrUserNotFound) {
+		return ErrAccountGone
+	}
+	if err != nil {
+		return err
+	}
+	return accountStatusError(user, s.now())
+}
+
+// EffectiveStatus returns the status of user at now. A suspension or lock
+// whose expiry has passed counts as active, users without a status are active.
+func EffectiveStatus(user models.User, now time.Time) string {
+	if user.Status == "" {
+		return models.UserStatusActive
+	}
+	if canExpire(user.Status) && user.StatusExpiresAt != nil && !now.Before(*user.StatusExpiresAt) {
+		return models.UserStatusActive
+	}
+	return user.Status
+}
+
+// accountStatusError returns nil for active users and the matching error otherwise
+func accountStatusError(user models.User, now time.Time) error {
+	switch EffectiveStatus(user, now) {
+	case models.UserStatusActive:
+		return nil
+	case models.UserStatusSuspended:
+		return ErrAccountSuspended
+	case models.UserStatusLocked:
+		return ErrAccountStatusLocked
+	case models.UserStatusPending:
+		return ErrAccountPending
+	default:
+		return ErrAccountDeactivated
+	}
+}
+
+// canExpire reports whether status may have an expiry
+func canExpire(status string) bool {
+	return status == models.UserStatusSuspended || status == models.UserStatusLocked
+}
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"gin-user-app/apperrors"
+	"gin-user-app/dto"
+	"gin-user-app/models"
+	"gin-user-app/repositories"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// newTestAccountStatusService returns the service with a fixed clock and the auth service it logs users out with
+func newTestAccountStatusService(userRepo *MockUserRepository, now time.Time) (*AccountStatusServiceImpl, *AuthService) {
+	refreshRepo := new(MockRefreshTokenRepository)
+	refreshRepo.On("RevokeAllForUser", mock.Anything, mock.Anything).Return(nil).Maybe()
+	authService := newTestAuthService(refreshRepo)
+	service := NewAccountStatusService(userRepo, authService).(*AccountStatusServiceImpl)
+	service.now = func() time.Time { return now }
+	return service, authService
+}
+
+// TestAccountStatus_SuspendAndTransitionsUnit tests suspending, the state machine and the request checks
+func TestAccountStatus_SuspendAndTransitionsUnit(t *testing.T) {
+	now := time.Now()
+	until := now.Add(72 * time.Hour)
+	userRepo := new(MockUserRepository)
+	service, authService := newTestAccountStatusService(userRepo, now)
+
+	userRepo.On("GetByID", 7).Return(models.User{ID: 7, Status: models.UserStatusActive, Version: 3}, nil)
+	userRepo.On("Update", mock.MatchedBy(func(user models.User) bool {
+		return user.Status == models.UserStatusSuspended && user.StatusReason == "chargeback" &&
+			*user.StatusChangedBy == 1 && user.StatusExpiresAt.Equal(until)
+	})).Return(models.User{ID: 7, Status: models.UserStatusSuspended, StatusReason: "chargeback", StatusExpiresAt: &until}, nil)
+
+	user, err := service.Suspend(7, 1, dto.SuspendUserRequestDTO{Reason: " chargeback ", ExpiresAt: &until})
+	assert.NoError(t, err)
+	assert.Equal(t, models.UserStatusSuspended, user.Status)
+	assert.Equal(t, "chargeback", user.StatusReason)
+	userRepo.AssertExpectations(t)
+
+	// Access tokens issued before the suspension stop working
+	revoked, err := authService.revocations.IsRevoked("jti", 7, time.Now().Add(-time.Minute))
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+
+	userRepo.On("GetByID", 8).Return(models.User{ID: 8, Status: models.UserStatusDeactivated}, nil)
+	_, err = service.Suspend(8, 1, dto.SuspendUserRequestDTO{Reason: "spam"})
+	assert.ErrorIs(t, err, ErrInvalidStatusTransition)
+	_, err = service.ChangeStatus(8, 1, dto.ChangeUserStatusRequestDTO{Status: models.UserStatusPending, Reason: "reopen"})
+	assert.ErrorIs(t, err, ErrInvalidStatusTransition)
+
+	past := now.Add(-time.Hour)
+	_, err = service.Suspend(7, 1, dto.SuspendUserRequestDTO{Reason: "spam", ExpiresAt: &past})
+	assert.ErrorIs(t, err, ErrInvalidStatusExpiry)
+	_, err = service.ChangeStatus(7, 1, dto.ChangeUserStatusRequestDTO{Status: models.UserStatusDeactivated, Reason: "spam", ExpiresAt: &until})
+	assert.ErrorIs(t, err, ErrInvalidStatusExpiry)
+	_, err = service.Suspend(7, 1, dto.SuspendUserRequestDTO{Reason: "  "})
+	assert.ErrorIs(t, err, ErrStatusReasonRequired)
+	_, err = service.ChangeStatus(7, 1, dto.ChangeUserStatusRequestDTO{Status: "banned", Reason: "spam"})
+	assert.ErrorIs(t, err, ErrInvalidAccountStatus)
+	_, err = service.Suspend(1, 1, dto.SuspendUserRequestDTO{Reason: "oops"})
+	assert.ErrorIs(t, err, ErrOwnAccountStatus)
+}
+
+// TestAccountStatus_ReactivateUnit tests that reactivating keeps existing sessions revoked but lets the user back in
+func TestAccountStatus_ReactivateUnit(t *testing.T) {
+	now := time.Now()
+	userRepo := new(MockUserRepository)
+	service, _ := newTestAccountStatusService(userRepo, now)
+
+	userRepo.On("GetByID", 7).Return(models.User{ID: 7, Status: models.UserStatusLocked, StatusReason: "fraud"}, nil).Once()
+	userRepo.On("Update", mock.MatchedBy(func(user models.User) bool {
+		return user.Status == models.UserStatusActive && user.StatusReason == "cleared" && user.StatusExpiresAt == nil
+	})).Return(models.User{ID: 7, Status: models.UserStatusActive, StatusReason: "cleared"}, nil)
+
+	user, err := service.Reactivate(7, 1, dto.ReactivateUserRequestDTO{Reason: "cleared"})
+	assert.NoError(t, err)
+	assert.Equal(t, models.UserStatusActive, user.Status)
+	assert.Empty(t, user.StatusReason)
+
+	userRepo.On("GetByID", 7).Return(models.User{ID: 7, Status: models.UserStatusActive}, nil)
+	assert.NoError(t, service.CheckActive(7))
+	userRepo.AssertExpectations(t)
+}
+
+// TestAccountStatus_CheckActiveAndExpiryUnit tests the status errors and expired suspensions
+func TestAccountStatus_CheckActiveAndExpiryUnit(t *testing.T) {
+	now := time.Now()
+	expired := now.Add(-time.Second)
+	later := now.Add(time.Hour)
+	userRepo := new(MockUserRepository)
+	service, _ := newTestAccountStatusService(userRepo, now)
+
+	userRepo.On("GetByID", 1).Return(models.User{ID: 1, Status: models.UserStatusSuspended, StatusExpiresAt: &later}, nil)
+	userRepo.On("GetByID", 2).Return(models.User{ID: 2, Status: models.UserStatusSuspended, StatusExpiresAt: &expired}, nil)
+	userRepo.On("GetByID", 3).Return(models.User{ID: 3, Status: models.UserStatusLocked}, nil)
+	userRepo.On("GetByID", 4).Return(models.User{ID: 4, Status: models.UserStatusPending}, nil)
+	userRepo.On("GetByID", 5).Return(models.User{ID: 5, Status: models.UserStatusDeactivated}, nil)
+	userRepo.On("GetByID", 6).Return(models.User{ID: 6}, nil)
+	userRepo.On("GetByID", 9).Return(models.User{}, repositories.ErrUserNotFound)
+
+	assert.ErrorIs(t, service.CheckActive(1), ErrAccountSuspended)
+	assert.NoError(t, service.CheckActive(2))
+	assert.Equal(t, apperrors.ErrLocked, apperrors.KindOf(service.CheckActive(3)))
+	assert.ErrorIs(t, service.CheckActive(4), ErrAccountPending)
+	assert.ErrorIs(t, service.CheckActive(5), ErrAccountDeactivated)
+	assert.NoError(t, service.CheckActive(6))
+	assert.ErrorIs(t, service.CheckActive(9), ErrAccountGone)
+
+	// An expired suspension can be suspended again directly
+	assert.Equal(t, models.UserStatusActive, EffectiveStatus(models.User{Status: models.UserStatusSuspended, StatusExpiresAt: &expired}, now))
+	assert.Equal(t, models.UserStatusDeactivated, EffectiveStatus(models.User{Status: models.UserStatusDeactivated, StatusExpiresAt: &expired}, now))
+
+	_, err := buildUserListParams(dto.UserListQueryDTO{Status: "banned"})
+	assert.ErrorIs(t, err, ErrInvalidListQuery)
+	params, err := buildUserListParams(dto.UserListQueryDTO{Status: models.UserStatusSuspended})
+	assert.NoError(t, err)
+	assert.Equal(t, models.UserStatusSuspended, params.Filter.Status)
+}
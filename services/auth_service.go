@@ -2,26 +2,92 @@ package services
 
 import (
 	"errors"
+	"gin-user-app/apperrors"
+	"gin-user-app/dto"
 	"gin-user-app/models"
 	"gin-user-app/repositories"
+	"gin-user-app/utils"
+	"log"
 	"time"
+)
+
+// refreshTokenSize is the number of random bytes in an opaque refresh token.
+const refreshTokenSize = 32
+
+// Values of the token_use claim. AuthMiddleware only accepts access tokens.
+const (
+	TokenUseAccess         = "access"
+	TokenUseMFAPending     = "mfa_pending"
+	TokenUsePasswordChange = "password_change"
+)
 
-	"github.com/golang-jwt/jwt/v5"
-	"golang.org/x/crypto/bcrypt"
+var (
+	// ErrInvalidRefreshToken is returned for unknown, expired or revoked refresh tokens.
+	ErrInvalidRefreshToken = apperrors.Unauthorized("invalid refresh token")
+	// ErrRefreshTokenReused is returned when an already-rotated refresh token is presented.
+	ErrRefreshTokenReused = apperrors.Unauthorized("refresh token reuse detected")
+	// ErrInvalidCredentials is returned by Login for an unknown username or a wrong password.
+	ErrInvalidCredentials = apperrors.Unauthorized("invalid credentials")
+	// ErrEmailNotVerified is returned by Login when RequireVerifiedEmail is set and the email is not verified.
+	ErrEmailNotVerified = apperrors.Forbidden("email address is not verified")
+	// ErrInvalidMFAChallenge is returned for unknown, expired or already used mfa_pending tokens.
+	ErrInvalidMFAChallenge = apperrors.Unauthorized("invalid or expired mfa challenge")
+	// ErrInvalidPasswordChallenge is returned for unknown, expired or already used password_change tokens.
+	ErrInvalidPasswordChallenge = apperrors.Unauthorized("invalid or expired password change challenge")
 )
 
+// AuthConfig groups the refresh token lifetime and login policy used by AuthService.
+// Access token and mfa challenge lifetimes are configured on TokenService.
+type AuthConfig struct {
+	RefreshTokenTTL time.Duration
+	// RequireVerifiedEmail rejects logins of users whose email is not verified
+	RequireVerifiedEmail bool
+	// LoginIdentifiers are the identifier types accepted by Login, see
+	// repositories.IdentifierUsername. Empty means username only.
+	LoginIdentifiers []string
+}
+
 // AuthService handles authentication logic.
 type AuthService struct {
-	authRepo  *repositories.AuthRepository
-	jwtSecret string
+	authRepo        *repositories.AuthRepository
+	refreshRepo     repositories.RefreshTokenRepository
+	revocations     repositories.RevocationStore
+	roleService     RoleService
+	mfaService      MFAService
+	lockouts        LockoutService
+	tokens          TokenService
+	sessions        SessionService
+	passwords       PasswordPolicy
+	refreshTokenTTL time.Duration
+	requireVerified bool
+	identifiers     []string
 }
 
-// NewAuthService creates a new instance of AuthService.
-func NewAuthService(repo *repositories.AuthRepository, jwtSecret string) *AuthService {
+// NewAuthService creates a new instance of AuthService. passwords may be nil,
+// passwords then never expire.
+func NewAuthService(repo *repositories.AuthRepository, refreshRepo repositories.RefreshTokenRepository, revocations repositories.RevocationStore, roleService RoleService, mfaService MFAService, lockouts LockoutService, tokens TokenService, sessions SessionService, passwords PasswordPolicy, cfg AuthConfig) *AuthService {
 	return &AuthService{
-		authRepo:  repo,
-		jwtSecret: jwtSecret,
+		authRepo:        repo,
+		refreshRepo:     refreshRepo,
+		revocations:     revocations,
+		roleService:     roleService,
+		mfaService:      mfaService,
+		lockouts:        lockouts,
+		tokens:          tokens,
+		sessions:        sessions,
+		passwords:       passwords,
+		refreshTokenTTL: cfg.RefreshTokenTTL,
+		requireVerified: cfg.RequireVerifiedEmail,
+		identifiers:     loginIdentifiers(cfg.LoginIdentifiers),
+	}
+}
+
+// loginIdentifiers returns the configured identifier types, username by default
+func loginIdentifiers(types []string) []string {
+	if len(types) == 0 {
+		return []string{repositories.IdentifierUsername}
 	}
+	return types
 }
 
 // GetUserByID retrieves user by ID
@@ -29,55 +95,321 @@ func (s *AuthService) GetUserByID(userID int) (*models.User, error) {
 	return s.authRepo.GetUserByID(userID)
 }
 
-// Login authenticates a user and returns a JWT token.
-func (s *AuthService) Login(username, password string) (string, error) {
-	user, err := s.authRepo.GetUserByUsername(username)
+// Login authenticates a user by username, email or verified phone number and
+// returns an access token and a refresh token. Users whose password expired
+// get a password_change challenge instead, users with two-factor
+// authentication an mfa_pending challenge. Failed passwords are counted per
+// account, see LockoutService; unknown identifiers get the same delays and
+// lockout so the responses do not reveal which accounts exist. Users that are
+// not active are refused once the password is correct, see
+// AccountStatusService.
+func (s *AuthService) Login(identifier, password string, client ClientInfo) (dto.LoginResponseDTO, error) {
+	user, err := s.authRepo.FindByIdentifier(identifier, s.identifiers)
 	if err != nil {
-		return "", errors.New("user not found")
+		// Hash anyway so unknown identifiers take as long as wrong passwords
+		utils.VerifyDummyPassword(password)
+		if err := s.lockouts.CheckUnknown(identifier); err != nil {
+			return dto.LoginResponseDTO{}, err
+		}
+		s.lockouts.RecordUnknownFailure(identifier)
+		return dto.LoginResponseDTO{}, ErrInvalidCredentials
 	}
 
-	// Verify password using bcrypt
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
-		return "", errors.New("invalid username or password")
+	if err := s.lockouts.Check(user.ID); err != nil {
+		// The password is not checked while locked, hash anyway so locked
+		// accounts answer as slowly as unknown identifiers
+		utils.VerifyDummyPassword(password)
+		return dto.LoginResponseDTO{}, err
 	}
 
-	// Generate JWT token
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"user_id": user.ID,
-		"exp":     time.Now().Add(24 * time.Hour).Unix(),
-	})
+	ok, needsRehash := utils.VerifyPassword(password, user.Password)
+	if !ok {
+		if err := s.lockouts.RecordFailure(user.ID); err != nil {
+			return dto.LoginResponseDTO{}, err
+		}
+		return dto.LoginResponseDTO{}, ErrInvalidCredentials
+	}
+	if err := s.lockouts.RecordSuccess(user.ID); err != nil {
+		return dto.LoginResponseDTO{}, err
+	}
+	if needsRehash {
+		s.rehashPassword(user.ID, password)
+	}
+	if err := accountStatusError(*user, time.Now()); err != nil {
+		return dto.LoginResponseDTO{}, err
+	}
+	if s.requireVerified && user.EmailVerifiedAt == nil {
+		return dto.LoginResponseDTO{}, ErrEmailNotVerified
+	}
+
+	if s.passwords != nil && s.passwords.Expired(*user, time.Now()) {
+		challenge, err := s.tokens.IssuePasswordChangeChallenge(user.ID)
+		if err != nil {
+			return dto.LoginResponseDTO{}, err
+		}
+		return dto.LoginResponseDTO{
+			PasswordChangeRequired:  true,
+			PasswordChangeToken:     challenge,
+			PasswordChangeExpiresIn: int64(s.tokens.MFAChallengeTTL().Seconds()),
+		}, nil
+	}
 
-	tokenString, err := token.SignedString([]byte(s.jwtSecret))
+	return s.completeLogin(user.ID, client)
+}
+
+// completeLogin issues an mfa_pending challenge when two-factor
+// authentication is enabled and starts a session otherwise
+func (s *AuthService) completeLogin(userID int, client ClientInfo) (dto.LoginResponseDTO, error) {
+	mfaEnabled, err := s.mfaService.IsEnabled(userID)
 	if err != nil {
-		return "", err
+		return dto.LoginResponseDTO{}, err
+	}
+	if mfaEnabled {
+		challenge, err := s.tokens.IssueMFAChallenge(userID)
+		if err != nil {
+			return dto.LoginResponseDTO{}, err
+		}
+		return dto.LoginResponseDTO{
+			MFARequired:  true,
+			MFAToken:     challenge,
+			MFAExpiresIn: int64(s.tokens.MFAChallengeTTL().Seconds()),
+		}, nil
 	}
 
-	return tokenString, nil
+	tokens, err := s.startSession(userID, client)
+	if err != nil {
+		return dto.LoginResponseDTO{}, err
+	}
+	return dto.LoginResponseDTO{TokenPairDTO: &tokens}, nil
 }
 
-// VerifyUser verifies a JWT token and returns user data.
-func (s *AuthService) VerifyUser(tokenString string) (*models.User, error) {
-	claims := jwt.MapClaims{}
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		return []byte(s.jwtSecret), nil
-	})
+// LoginPasswordChange exchanges a password_change challenge and a new
+// password for the result of a normal login: tokens, or an mfa_pending
+// challenge when two-factor authentication is enabled. The new password must
+// meet the password policy, including the history. A challenge can only be used once.
+func (s *AuthService) LoginPasswordChange(challenge, newPassword string, client ClientInfo) (dto.LoginResponseDTO, error) {
+	claims, err := s.tokens.Verify(challenge, TokenUsePasswordChange)
+	if err != nil {
+		return dto.LoginResponseDTO{}, ErrInvalidPasswordChallenge
+	}
+	userID, jti := claims.UserID(), claims.ID
+	burnUntil := claims.ExpiresAt.Add(s.tokens.ClockSkew())
 
-	// Token invalid or error
-	if err != nil || !token.Valid {
-		return nil, errors.New("invalid token")
+	revoked, err := s.revocations.IsRevoked(jti, userID, claims.IssuedAt.Time)
+	if err != nil {
+		return dto.LoginResponseDTO{}, err
+	}
+	if revoked {
+		return dto.LoginResponseDTO{}, ErrInvalidPasswordChallenge
 	}
 
-	// Extract user ID from claims
-	userID, ok := claims["user_id"].(float64)
-	if !ok {
-		return nil, errors.New("invalid token data")
+	user, err := s.authRepo.GetUserByID(userID)
+	if err != nil {
+		return dto.LoginResponseDTO{}, ErrInvalidPasswordChallenge
+	}
+	if err := accountStatusError(*user, time.Now()); err != nil {
+		return dto.LoginResponseDTO{}, err
+	}
+	if err := validateNewPassword(s.passwords, "new_password", *user, newPassword); err != nil {
+		return dto.LoginResponseDTO{}, err
+	}
+
+	hash, err := utils.HashPassword(newPassword)
+	if err != nil {
+		return dto.LoginResponseDTO{}, err
+	}
+	if err := s.authRepo.ChangePassword(userID, hash, time.Now()); err != nil {
+		return dto.LoginResponseDTO{}, err
+	}
+	rememberPassword(s.passwords, *user)
+
+	if err := s.revocations.RevokeToken(jti, userID, burnUntil); err != nil {
+		return dto.LoginResponseDTO{}, err
+	}
+	return s.completeLogin(userID, client)
+}
+
+// LoginMFA exchanges an mfa_pending challenge and a TOTP or recovery code for
+// real tokens. A challenge can only be used once.
+func (s *AuthService) LoginMFA(challenge, code string, client ClientInfo) (dto.TokenPairDTO, error) {
+	claims, err := s.tokens.Verify(challenge, TokenUseMFAPending)
+	if err != nil {
+		return dto.TokenPairDTO{}, ErrInvalidMFAChallenge
 	}
+	userID, jti := claims.UserID(), claims.ID
+	// The challenge is accepted until exp plus clock skew, so it stays revoked that long
+	burnUntil := claims.ExpiresAt.Add(s.tokens.ClockSkew())
 
-	// Retrieve user from repository
-	user, err := s.authRepo.GetUserByID(int(userID))
+	revoked, err := s.revocations.IsRevoked(jti, userID, claims.IssuedAt.Time)
 	if err != nil {
-		return nil, errors.New("user not found")
+		return dto.TokenPairDTO{}, err
+	}
+	if revoked {
+		return dto.TokenPairDTO{}, ErrInvalidMFAChallenge
 	}
 
-	return user, nil
+	if err := s.mfaService.Verify(userID, code); err != nil {
+		if errors.Is(err, ErrMFATooManyAttempts) {
+			if revokeErr := s.revocations.RevokeToken(jti, userID, burnUntil); revokeErr != nil {
+				return dto.TokenPairDTO{}, revokeErr
+			}
+		}
+		return dto.TokenPairDTO{}, err
+	}
+
+	if err := s.revocations.RevokeToken(jti, userID, burnUntil); err != nil {
+		return dto.TokenPairDTO{}, err
+	}
+	// The account may have been suspended since the password was checked
+	user, err := s.authRepo.GetUserByID(userID)
+	if err != nil {
+		return dto.TokenPairDTO{}, ErrInvalidMFAChallenge
+	}
+	if err := accountStatusError(*user, time.Now()); err != nil {
+		return dto.TokenPairDTO{}, err
+	}
+	return s.startSession(userID, client)
+}
+
+// startSession records a new session and issues the first token pair of its
+// refresh token family. The session ID doubles as the family ID.
+func (s *AuthService) startSession(userID int, client ClientInfo) (dto.TokenPairDTO, error) {
+	sessionID, err := s.sessions.Start(userID, client)
+	if err != nil {
+		return dto.TokenPairDTO{}, err
+	}
+	return s.issueTokenPair(userID, sessionID, nil)
+}
+
+// Refresh rotates a refresh token and returns a new token pair. Presenting a
+// token that was already rotated revokes every token in its family.
+func (s *AuthService) Refresh(refreshToken string, client ClientInfo) (dto.TokenPairDTO, error) {
+	stored, err := s.refreshRepo.FindByHash(utils.HashToken(refreshToken))
+	if err != nil {
+		return dto.TokenPairDTO{}, ErrInvalidRefreshToken
+	}
+
+	now := time.Now()
+	if stored.RevokedAt != nil {
+		return dto.TokenPairDTO{}, ErrInvalidRefreshToken
+	}
+	if stored.RotatedAt != nil {
+		return dto.TokenPairDTO{}, s.revokeFamily(stored.FamilyID, now)
+	}
+	if now.After(stored.ExpiresAt) {
+		return dto.TokenPairDTO{}, ErrInvalidRefreshToken
+	}
+
+	// Only one concurrent refresh may rotate the token; the loser is treated as reuse
+	rotated, err := s.refreshRepo.MarkRotated(stored.ID, now)
+	if err != nil {
+		return dto.TokenPairDTO{}, err
+	}
+	if !rotated {
+		return dto.TokenPairDTO{}, s.revokeFamily(stored.FamilyID, now)
+	}
+
+	user, err := s.authRepo.GetUserByID(stored.UserID)
+	if err != nil {
+		return dto.TokenPairDTO{}, ErrInvalidRefreshToken
+	}
+	if err := accountStatusError(*user, now); err != nil {
+		return dto.TokenPairDTO{}, err
+	}
+	if err := s.sessions.Validate(stored.FamilyID, stored.UserID, client.IP); err != nil {
+		if errors.Is(err, ErrSessionRevoked) {
+			return dto.TokenPairDTO{}, ErrInvalidRefreshToken
+		}
+		return dto.TokenPairDTO{}, err
+	}
+
+	return s.issueTokenPair(stored.UserID, stored.FamilyID, &stored.ID)
+}
+
+// Logout revokes the current access token and its session and, when given,
+// the family of another refresh token of the same user.
+func (s *AuthService) Logout(userID int, sessionID, jti string, expiresAt time.Time, refreshToken string) error {
+	if err := s.revocations.RevokeToken(jti, userID, expiresAt); err != nil {
+		return err
+	}
+	if err := s.sessions.Revoke(userID, sessionID); err != nil && !errors.Is(err, ErrSessionNotFound) {
+		return err
+	}
+
+	if refreshToken == "" {
+		return nil
+	}
+
+	stored, err := s.refreshRepo.FindByHash(utils.HashToken(refreshToken))
+	if err != nil || stored.UserID != userID {
+		return ErrInvalidRefreshToken
+	}
+	return s.refreshRepo.RevokeFamily(stored.FamilyID, time.Now())
+}
+
+// LogoutAll revokes every session, access token and refresh token of the user.
+func (s *AuthService) LogoutAll(userID int) error {
+	now := time.Now()
+	// Access tokens issued before now are accepted at most TTL plus clock skew longer
+	if err := s.revocations.RevokeUser(userID, now, now.Add(s.tokens.AccessTokenTTL()+s.tokens.ClockSkew())); err != nil {
+		return err
+	}
+	return s.sessions.RevokeAll(userID)
+}
+
+// issueTokenPair signs a new access token and stores a new refresh token in the given family.
+func (s *AuthService) issueTokenPair(userID int, familyID string, parentID *int) (dto.TokenPairDTO, error) {
+	// Roles are re-resolved on every refresh so role changes apply within one access token lifetime
+	roles, permissions, err := s.roleService.ResolveAccess(userID)
+	if err != nil {
+		return dto.TokenPairDTO{}, err
+	}
+
+	accessToken, err := s.tokens.IssueAccessToken(userID, familyID, roles, permissions)
+	if err != nil {
+		return dto.TokenPairDTO{}, err
+	}
+
+	refreshToken, err := utils.GenerateOpaqueToken(refreshTokenSize)
+	if err != nil {
+		return dto.TokenPairDTO{}, err
+	}
+
+	_, err = s.refreshRepo.Create(models.RefreshToken{
+		UserID:    userID,
+		TokenHash: utils.HashToken(refreshToken),
+		FamilyID:  familyID,
+		ParentID:  parentID,
+		ExpiresAt: time.Now().Add(s.refreshTokenTTL),
+	})
+	if err != nil {
+		return dto.TokenPairDTO{}, err
+	}
+
+	return dto.TokenPairDTO{
+		Token:        accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(s.tokens.AccessTokenTTL().Seconds()),
+	}, nil
+}
+
+// rehashPassword upgrades a hash made with an outdated algorithm or parameters.
+// Failures are only logged because the login itself already succeeded.
+func (s *AuthService) rehashPassword(userID int, password string) {
+	hash, err := utils.HashPassword(password)
+	if err == nil {
+		err = s.authRepo.UpdatePassword(userID, hash)
+	}
+	if err != nil {
+		log.Printf("Failed to rehash password for user %d: %v", userID, err)
+	}
+}
+
+// revokeFamily revokes a compromised token family and reports the reuse.
+func (s *AuthService) revokeFamily(familyID string, at time.Time) error {
+	if err := s.refreshRepo.RevokeFamily(familyID, at); err != nil {
+		return err
+	}
+	return ErrRefreshTokenReused
 }
@@ -0,0 +1,225 @@
+package services
+
+import (
+	"errors"
+	"gin-user-app/models"
+	"gin-user-app/repositories"
+	"gin-user-app/utils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockRefreshTokenRepository adalah mock untuk RefreshTokenRepository
+type MockRefreshTokenRepository struct {
+	mock.Mock
+}
+
+func (m *MockRefreshTokenRepository) Create(token models.RefreshToken) (models.RefreshToken, error) {
+	args := m.Called(token)
+	return args.Get(0).(models.RefreshToken), args.Error(1)
+}
+
+func (m *MockRefreshTokenRepository) FindByHash(hash string) (models.RefreshToken, error) {
+	args := m.Called(hash)
+	return args.Get(0).(models.RefreshToken), args.Error(1)
+}
+
+func (m *MockRefreshTokenRepository) MarkRotated(id int, at time.Time) (bool, error) {
+	args := m.Called(id, at)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRefreshTokenRepository) RevokeFamily(familyID string, at time.Time) error {
+	args := m.Called(familyID, at)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenRepository) RevokeAllForUser(userID int, at time.Time) error {
+	args := m.Called(userID, at)
+	return args.Error(0)
+}
+
+var testAuthTokens = AuthConfig{
+	RefreshTokenTTL: time.Hour,
+}
+
+func newTestAuthService(refreshRepo *MockRefreshTokenRepository) *AuthService {
+	sessionRepo := new(MockSessionRepository)
+	sessionRepo.On("RevokeAllForUser", mock.Anything, mock.Anything).Return(nil).Maybe()
+	sessions := NewSessionService(sessionRepo, refreshRepo, time.Hour)
+	return NewAuthService(nil, refreshRepo, repositories.NewMemoryRevocationStore(), nil, nil, nil, newTestTokenService(), sessions, nil, testAuthTokens)
+}
+
+// TestRefresh_UnknownTokenUnit tests refreshing with a token that was never issued
+func TestRefresh_UnknownTokenUnit(t *testing.T) {
+	mockRepo := new(MockRefreshTokenRepository)
+	service := newTestAuthService(mockRepo)
+
+	mockRepo.On("FindByHash", utils.HashToken("unknown")).Return(models.RefreshToken{}, errors.New("refresh token not found"))
+
+	_, err := service.Refresh("unknown", ClientInfo{})
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "MarkRotated")
+}
+
+// TestRefresh_ExpiredTokenUnit tests refreshing with an expired token
+func TestRefresh_ExpiredTokenUnit(t *testing.T) {
+	mockRepo := new(MockRefreshTokenRepository)
+	service := newTestAuthService(mockRepo)
+
+	mockRepo.On("FindByHash", utils.HashToken("expired")).Return(models.RefreshToken{
+		ID:        1,
+		UserID:    1,
+		FamilyID:  "family",
+		ExpiresAt: time.Now().Add(-time.Minute),
+	}, nil)
+
+	_, err := service.Refresh("expired", ClientInfo{})
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+	mockRepo.AssertNotCalled(t, "MarkRotated")
+	mockRepo.AssertNotCalled(t, "RevokeFamily")
+}
+
+// TestRefresh_ReusedTokenRevokesFamilyUnit tests that presenting a rotated token revokes its family
+func TestRefresh_ReusedTokenRevokesFamilyUnit(t *testing.T) {
+	mockRepo := new(MockRefreshTokenRepository)
+	service := newTestAuthService(mockRepo)
+	rotatedAt := time.Now().Add(-time.Minute)
+
+	mockRepo.On("FindByHash", utils.HashToken("rotated")).Return(models.RefreshToken{
+		ID:        1,
+		UserID:    1,
+		FamilyID:  "family",
+		ExpiresAt: time.Now().Add(time.Hour),
+		RotatedAt: &rotatedAt,
+	}, nil)
+	mockRepo.On("RevokeFamily", "family", mock.Anything).Return(nil)
+
+	_, err := service.Refresh("rotated", ClientInfo{})
+	assert.ErrorIs(t, err, ErrRefreshTokenReused)
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "MarkRotated")
+	mockRepo.AssertNotCalled(t, "Create")
+}
+
+// TestRefresh_ConcurrentRotationUnit tests that losing a rotation race is treated as reuse
+func TestRefresh_ConcurrentRotationUnit(t *testing.T) {
+	mockRepo := new(MockRefreshTokenRepository)
+	service := newTestAuthService(mockRepo)
+
+	mockRepo.On("FindByHash", utils.HashToken("raced")).Return(models.RefreshToken{
+		ID:        1,
+		UserID:    1,
+		FamilyID:  "family",
+		ExpiresAt: time.Now().Add(time.Hour),
+	}, nil)
+	mockRepo.On("MarkRotated", 1, mock.Anything).Return(false, nil)
+	mockRepo.On("RevokeFamily", "family", mock.Anything).Return(nil)
+
+	_, err := service.Refresh("raced", ClientInfo{})
+	assert.ErrorIs(t, err, ErrRefreshTokenReused)
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "Create")
+}
+
+// TestLogoutAll_RevokesEarlierTokensUnit tests that logout-all revokes tokens issued before it
+func TestLogoutAll_RevokesEarlierTokensUnit(t *testing.T) {
+	sessions := new(MockSessionService)
+	store := repositories.NewMemoryRevocationStore()
+	service := NewAuthService(nil, new(MockRefreshTokenRepository), store, nil, nil, nil, newTestTokenService(), sessions, nil, testAuthTokens)
+	issuedAt := time.Now().Add(-time.Minute)
+
+	sessions.On("RevokeAll", 1).Return(nil)
+
+	err := service.LogoutAll(1)
+	assert.NoError(t, err)
+	sessions.AssertExpectations(t)
+
+	revoked, err := store.IsRevoked("some-jti", 1, issuedAt)
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+
+	revoked, err = store.IsRevoked("other-jti", 2, issuedAt)
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+}
+
+// TestLogout_RevokesCurrentTokenUnit tests that logout denylists the current token
+func TestLogout_RevokesCurrentTokenUnit(t *testing.T) {
+	mockRepo := new(MockRefreshTokenRepository)
+	sessions := new(MockSessionService)
+	store := repositories.NewMemoryRevocationStore()
+	service := NewAuthService(nil, mockRepo, store, nil, nil, nil, newTestTokenService(), sessions, nil, testAuthTokens)
+
+	sessions.On("Revoke", 1, "current-session").Return(nil)
+
+	err := service.Logout(1, "current-session", "current-jti", time.Now().Add(time.Minute), "")
+	assert.NoError(t, err)
+	sessions.AssertExpectations(t)
+
+	revoked, err := store.IsRevoked("current-jti", 1, time.Now())
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+
+	pruned, err := store.PruneExpired(time.Now().Add(2 * time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), pruned)
+}
+
+// TestLoginMFA_RejectsAccessTokenUnit tests that an access token cannot be used as an mfa challenge
+func TestLoginMFA_RejectsAccessTokenUnit(t *testing.T) {
+	service := newTestAuthService(new(MockRefreshTokenRepository))
+	accessToken, err := service.tokens.IssueAccessToken(1, "session", []string{"user"}, nil)
+	assert.NoError(t, err)
+
+	_, err = service.LoginMFA(accessToken, "123456", ClientInfo{})
+	assert.ErrorIs(t, err, ErrInvalidMFAChallenge)
+}
+
+// TestLoginMFA_TooManyAttemptsBurnsChallengeUnit tests that the challenge is revoked after too many wrong codes
+func TestLoginMFA_TooManyAttemptsBurnsChallengeUnit(t *testing.T) {
+	mfaRepo := new(MockMFARepository)
+	confirmedAt := time.Now()
+	mfaRepo.On("GetByUserID", 1).Return(models.UserMFA{UserID: 1, Secret: testTOTPSecret, ConfirmedAt: &confirmedAt}, nil)
+	mfaRepo.On("UseRecoveryCode", 1, mock.Anything, mock.Anything).Return(false, nil)
+	mfaRepo.On("RecordFailure", 1).Return(maxMFAAttempts, nil)
+	mfaRepo.On("Lock", 1, mock.Anything).Return(nil)
+
+	service := NewAuthService(nil, new(MockRefreshTokenRepository), repositories.NewMemoryRevocationStore(), nil, NewMFAService(mfaRepo, nil, "test"), nil, newTestTokenService(), new(MockSessionService), nil, testAuthTokens)
+	challenge, err := service.tokens.IssueMFAChallenge(1)
+	assert.NoError(t, err)
+
+	_, err = service.LoginMFA(challenge, "wrong-recovery", ClientInfo{})
+	assert.ErrorIs(t, err, ErrMFATooManyAttempts)
+
+	_, err = service.LoginMFA(challenge, "wrong-recovery", ClientInfo{})
+	assert.ErrorIs(t, err, ErrInvalidMFAChallenge)
+}
+
+// TestLogin_UnknownIdentifierThrottledUnit tests that an unknown identifier
+// gets the same 429 and 423 responses as an account after repeated failures
+func TestLogin_UnknownIdentifierThrottledUnit(t *testing.T) {
+	lockouts := newTestLockoutService(new(MockLoginAttemptRepository), time.Now())
+	service := NewAuthService(repositories.NewAuthRepository(nil), nil, nil, nil, nil, lockouts, nil, nil, nil, testAuthTokens)
+
+	for i := 0; i < testLockoutPolicy.FreeAttempts; i++ {
+		_, err := service.Login("ghost@example.com", "wrong-password", ClientInfo{})
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+	}
+	_, err := service.Login("ghost@example.com", "wrong-password", ClientInfo{})
+	assert.ErrorIs(t, err, ErrLoginThrottled)
+
+	// Wait out each delay until MaxAttempts locks the identifier
+	for i := testLockoutPolicy.FreeAttempts; i < testLockoutPolicy.MaxAttempts; i++ {
+		later := time.Now().Add(time.Duration(i) * time.Minute)
+		lockouts.now = func() time.Time { return later }
+		_, err = service.Login("ghost@example.com", "wrong-password", ClientInfo{})
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+	}
+	_, err = service.Login("ghost@example.com", "wrong-password", ClientInfo{})
+	assert.ErrorIs(t, err, ErrAccountLocked)
+}
@@ -0,0 +1,339 @@
+package services
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gin-user-app/utils"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// bundledDisposableDomains is the disposable provider list shipped with the binary.
+//
+//go:embed data/disposable_domains.txt
+var bundledDisposableDomains string
+
+// EmailDomainRules is the email domain policy of a tenant. A pattern is a
+// domain such as "example.com" or a wildcard such as "*.example.com", which
+// matches every subdomain but not example.com itself. An empty Allow list
+// allows every domain; Deny wins over Allow. In a tenant override nil fields
+// inherit the default policy.
+type EmailDomainRules struct {
+	Allow           []string `json:"allow"`
+	Deny            []string `json:"deny"`
+	BlockDisposable *bool    `json:"block_disposable"`
+	CheckMX         *bool    `json:"check_mx"`
+}
+
+// EmailPolicyConfig configures the default policy, the tenant overrides and
+// an optional file with more disposable domains, one per line.
+type EmailPolicyConfig struct {
+	Default        EmailDomainRules
+	Tenants        map[string]EmailDomainRules
+	DisposableFile string
+}
+
+// MXResolver reports whether a domain publishes MX records
+type MXResolver interface {
+	HasMX(domain string) (bool, error)
+}
+
+// defaultMXLookupTimeout bounds an MX lookup when DNSResolver.Timeout is not set
+const defaultMXLookupTimeout = 3 * time.Second
+
+// DNSResolver looks up MX records with the system resolver. A lookup that
+// takes longer than Timeout fails, which does not reject the email.
+type DNSResolver struct {
+	Timeout time.Duration
+}
+
+// HasMX returns false for domains without MX records or with a null MX (RFC 7505)
+func (r DNSResolver) HasMX(domain string) (bool, error) {
+	timeout := r.Timeout
+	if timeout <= 0 {
+		timeout = defaultMXLookupTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	records, err := net.DefaultResolver.LookupMX(ctx, domain)
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	for _, record := range records {
+		if record.Host != "." {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// EmailPolicy decides which email domains may be used for an account
+type EmailPolicy interface {
+	// Rules returns the validation rules for an email address used in tenant.
+	// An empty tenant or a tenant without overrides gets the default policy.
+	Rules(tenant string) []utils.Rule
+	// ReloadDisposable reads the bundled list and the disposable domain file again
+	ReloadDisposable() error
+}
+
+// EmailPolicyImpl implements EmailPolicy
+type EmailPolicyImpl struct {
+	defaults       EmailDomainRules
+	tenants        map[string]EmailDomainRules
+	disposableFile string
+	resolver       MXResolver
+
+	mu         sync.RWMutex
+	disposable map[string]struct{}
+}
+
+// NewEmailPolicy creates an EmailPolicy. resolver may be nil, MX checks are
+// then skipped even if enabled.
+func NewEmailPolicy(cfg EmailPolicyConfig, resolver MXResolver) (EmailPolicy, error) {
+	defaults, err := normalizeDomainRules(cfg.Default)
+	if err != nil {
+		return nil, err
+	}
+	tenants := make(map[string]EmailDomainRules, len(cfg.Tenants))
+	for tenant, rules := range cfg.Tenants {
+		if tenants[tenant], err = normalizeDomainRules(rules); err != nil {
+			return nil, fmt.Errorf("tenant %q: %w", tenant, err)
+		}
+	}
+
+	policy := &EmailPolicyImpl{
+		defaults:       defaults,
+		tenants:        tenants,
+		disposableFile: cfg.DisposableFile,
+		resolver:       resolver,
+	}
+	if err := policy.ReloadDisposable(); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// ParseTenantEmailRules decodes tenant overrides from JSON, for example
+// {"acme": {"allow": ["acme.com", "*.acme.com"]}}. Empty input means no overrides.
+func ParseTenantEmailRules(raw string) (map[string]EmailDomainRules, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+	var tenants map[string]EmailDomainRules
+	if err := json.Unmarshal([]byte(raw), &tenants); err != nil {
+		return nil, fmt.Errorf("invalid tenant email policies: %w", err)
+	}
+	return tenants, nil
+}
+
+// Rules returns the checks of the tenant policy in the order allow list, deny
+// list, disposable providers and MX records. Values without a domain pass,
+// the email format rule reports them.
+func (p *EmailPolicyImpl) Rules(tenant string) []utils.Rule {
+	rules := p.rulesFor(tenant)
+
+	var result []utils.Rule
+	if len(rules.Allow) > 0 {
+		result = append(result, utils.Rule{
+			Code:    "domain_not_allowed",
+			Message: "{field} domain is not allowed",
+			Params:  map[string]interface{}{"allowed": rules.Allow},
+			Check:   checkDomain(func(domain string) bool { return matchDomain(rules.Allow, domain) }),
+		})
+	}
+	if len(rules.Deny) > 0 {
+		result = append(result, utils.Rule{
+			Code:    "domain_denied",
+			Message: "{field} domain is not allowed",
+			Check:   checkDomain(func(domain string) bool { return !matchDomain(rules.Deny, domain) }),
+		})
+	}
+	if rules.BlockDisposable != nil && *rules.BlockDisposable {
+		result = append(result, utils.Rule{
+			Code:    "disposable_domain",
+			Message: "{field} must not use a disposable email provider",
+			Check:   checkDomain(func(domain string) bool { return !p.isDisposable(domain) }),
+		})
+	}
+	if rules.CheckMX != nil && *rules.CheckMX && p.resolver != nil {
+		result = append(result, utils.Rule{
+			Code:    "no_mx_record",
+			Message: "{field} domain cannot receive email",
+			Check:   checkDomain(p.hasMX),
+		})
+	}
+	return result
+}
+
+// ReloadDisposable replaces the disposable domain set with the bundled list
+// plus the configured file. On error the current set stays in use.
+func (p *EmailPolicyImpl) ReloadDisposable() error {
+	domains := parseDomainList(bundledDisposableDomains)
+	if p.disposableFile != "" {
+		data, err := os.ReadFile(p.disposableFile)
+		if err != nil {
+			return fmt.Errorf("read disposable domains: %w", err)
+		}
+		for domain := range parseDomainList(string(data)) {
+			domains[domain] = struct{}{}
+		}
+	}
+
+	p.mu.Lock()
+	p.disposable = domains
+	p.mu.Unlock()
+	return nil
+}
+
+// rulesFor merges the overrides of tenant into the default policy
+func (p *EmailPolicyImpl) rulesFor(tenant string) EmailDomainRules {
+	rules := p.defaults
+	override, ok := p.tenants[tenant]
+	if !ok {
+		return rules
+	}
+	if override.Allow != nil {
+		rules.Allow = override.Allow
+	}
+	if override.Deny != nil {
+		rules.Deny = override.Deny
+	}
+	if override.BlockDisposable != nil {
+		rules.BlockDisposable = override.BlockDisposable
+	}
+	if override.CheckMX != nil {
+		rules.CheckMX = override.CheckMX
+	}
+	return rules
+}
+
+// isDisposable reports whether domain or one of its parents is a disposable provider
+func (p *EmailPolicyImpl) isDisposable(domain string) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	for {
+		if _, ok := p.disposable[domain]; ok {
+			return true
+		}
+		dot := strings.IndexByte(domain, '.')
+		if dot < 0 {
+			return false
+		}
+		domain = domain[dot+1:]
+	}
+}
+
+// hasMX checks the MX records of domain. A failed lookup lets the address
+// through, a DNS outage must not block sign ups.
+func (p *EmailPolicyImpl) hasMX(domain string) bool {
+	ok, err := p.resolver.HasMX(domain)
+	if err != nil {
+		log.Printf("MX lookup for %s failed: %v", domain, err)
+		return true
+	}
+	return ok
+}
+
+// checkDomain turns a check on the lower-cased domain of an address into a rule check
+func checkDomain(check func(domain string) bool) func(value interface{}) bool {
+	return func(value interface{}) bool {
+		email, ok := value.(string)
+		at := strings.LastIndexByte(email, '@')
+		if !ok || at < 0 {
+			return true
+		}
+		return check(strings.ToLower(email[at+1:]))
+	}
+}
+
+// matchDomain reports whether domain matches one of the patterns
+func matchDomain(patterns []string, domain string) bool {
+	for _, pattern := range patterns {
+		if pattern == "*" || pattern == domain {
+			return true
+		}
+		if strings.HasPrefix(pattern, "*.") && strings.HasSuffix(domain, pattern[1:]) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeDomainRules lower-cases the patterns and rejects malformed ones
+func normalizeDomainRules(rules EmailDomainRules) (EmailDomainRules, error) {
+	var err error
+	if rules.Allow, err = normalizePatterns(rules.Allow); err != nil {
+		return rules, err
+	}
+	if rules.Deny, err = normalizePatterns(rules.Deny); err != nil {
+		return rules, err
+	}
+	return rules, nil
+}
+
+// normalizePatterns keeps nil lists nil so that tenant overrides can inherit them
+func normalizePatterns(patterns []string) ([]string, error) {
+	if patterns == nil {
+		return nil, nil
+	}
+	normalized := make([]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if pattern == "" {
+			continue
+		}
+		// "*" may only stand alone or as the leftmost label
+		misplacedWildcard := pattern != "*" && strings.Contains(strings.TrimPrefix(pattern, "*."), "*")
+		if strings.Contains(pattern, "@") || misplacedWildcard {
+			return nil, fmt.Errorf("invalid email domain pattern %q", pattern)
+		}
+		normalized = append(normalized, pattern)
+	}
+	return normalized, nil
+}
+
+// parseDomainList reads one domain per line, skipping blank lines and # comments
+func parseDomainList(list string) map[string]struct{} {
+	domains := map[string]struct{}{}
+	for _, line := range strings.Split(list, "\n") {
+		line = strings.ToLower(strings.TrimSpace(line))
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		domains[line] = struct{}{}
+	}
+	return domains
+}
+
+// StartDisposableRefresh reloads the disposable domain list on every tick until stop is called
+func StartDisposableRefresh(policy EmailPolicy, interval time.Duration) (stop func()) {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				if err := policy.ReloadDisposable(); err != nil {
+					log.Println("Failed to reload disposable email domains:", err)
+				}
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() { once.Do(func() { close(done) }) }
+}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gin-user-app/apperrors"
+	"gin-user-app/utils"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// stubResolver answers MX lookups from a map, unknown domains fail the lookup
+type stubResolver map[string]bool
+
+func (r stubResolver) HasMX(domain string) (bool, error) {
+	ok, found := r[domain]
+	if !found {
+		return false, errors.New("lookup failed")
+	}
+	return ok, nil
+}
+
+// newGmailOnlyPolicy returns an EmailPolicy that only allows gmail.com
+func newGmailOnlyPolicy(t *testing.T) EmailPolicy {
+	policy, err := NewEmailPolicy(EmailPolicyConfig{Default: EmailDomainRules{Allow: []string{"gmail.com"}}}, nil)
+	assert.NoError(t, err)
+	return policy
+}
+
+// checkEmail validates email against the policy of tenant and returns the failed rule code
+func checkEmail(policy EmailPolicy, tenant, email string) string {
+	err := utils.RuleSet{{Field: "email", Rules: policy.Rules(tenant)}}.Validate(map[string]interface{}{"email": email})
+	if fields := apperrors.Fields(err); len(fields) > 0 {
+		return fields[0].Code
+	}
+	return ""
+}
+
+// TestEmailPolicy_AllowAndDenyListsUnit tests exact and wildcard patterns, deny winning over allow and tenant overrides
+func TestEmailPolicy_AllowAndDenyListsUnit(t *testing.T) {
+	enabled := true
+	policy, err := NewEmailPolicy(EmailPolicyConfig{
+		Default: EmailDomainRules{
+			Allow: []string{"Example.com", "*.corp.example.com"},
+			Deny:  []string{"legacy.corp.example.com"},
+		},
+		Tenants: map[string]EmailDomainRules{
+			"acme": {Allow: []string{"acme.io", "*.corp.example.com"}, BlockDisposable: &enabled},
+		},
+	}, nil)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "", checkEmail(policy, "", "alice@EXAMPLE.com"))
+	assert.Equal(t, "", checkEmail(policy, "", "alice@eu.corp.example.com"))
+	assert.Equal(t, "domain_not_allowed", checkEmail(policy, "", "alice@corp.example.com"))
+	assert.Equal(t, "domain_not_allowed", checkEmail(policy, "", "alice@gmail.com"))
+	assert.Equal(t, "domain_denied", checkEmail(policy, "", "alice@legacy.corp.example.com"))
+
+	assert.Equal(t, "", checkEmail(policy, "acme", "alice@acme.io"))
+	assert.Equal(t, "domain_not_allowed", checkEmail(policy, "acme", "alice@example.com"))
+	// The deny list is inherited from the default policy
+	assert.Equal(t, "domain_denied", checkEmail(policy, "acme", "alice@legacy.corp.example.com"))
+	assert.Equal(t, "", checkEmail(policy, "unknown", "alice@example.com"))
+
+	_, err = NewEmailPolicy(EmailPolicyConfig{Default: EmailDomainRules{Allow: []string{"mail.*.com"}}}, nil)
+	assert.Error(t, err)
+}
+
+// TestEmailPolicy_DisposableDomainsUnit tests the bundled list, subdomains and refreshing from a file
+func TestEmailPolicy_DisposableDomainsUnit(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "disposable.txt")
+	assert.NoError(t, os.WriteFile(file, []byte("# extra\nthrowaway.test\n"), 0o600))
+
+	enabled := true
+	policy, err := NewEmailPolicy(EmailPolicyConfig{Default: EmailDomainRules{BlockDisposable: &enabled}, DisposableFile: file}, nil)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "disposable_domain", checkEmail(policy, "", "alice@mailinator.com"))
+	assert.Equal(t, "disposable_domain", checkEmail(policy, "", "alice@eu.mailinator.com"))
+	assert.Equal(t, "disposable_domain", checkEmail(policy, "", "alice@throwaway.test"))
+	assert.Equal(t, "", checkEmail(policy, "", "alice@fresh.test"))
+
+	assert.NoError(t, os.WriteFile(file, []byte("fresh.test\n"), 0o600))
+	assert.NoError(t, policy.ReloadDisposable())
+	assert.Equal(t, "disposable_domain", checkEmail(policy, "", "alice@fresh.test"))
+	assert.Equal(t, "", checkEmail(policy, "", "alice@throwaway.test"))
+	assert.Equal(t, "disposable_domain", checkEmail(policy, "", "alice@mailinator.com"))
+
+	// A failed reload keeps the current list
+	assert.NoError(t, os.Remove(file))
+	assert.Error(t, policy.ReloadDisposable())
+	assert.Equal(t, "disposable_domain", checkEmail(policy, "", "alice@fresh.test"))
+}
+
+// TestEmailPolicy_MXCheckUnit tests that domains without MX records are rejected and lookup failures are not
+func TestEmailPolicy_MXCheckUnit(t *testing.T) {
+	enabled := true
+	resolver := stubResolver{"example.com": true, "nomail.example": false}
+	policy, err := NewEmailPolicy(EmailPolicyConfig{Default: EmailDomainRules{CheckMX: &enabled}}, resolver)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "", checkEmail(policy, "", "alice@example.com"))
+	assert.Equal(t, "no_mx_record", checkEmail(policy, "", "alice@nomail.example"))
+	assert.Equal(t, "", checkEmail(policy, "", "alice@unreachable.example"))
+}
+
+// TestDNSResolver_TimeoutUnit tests that a lookup slower than Timeout fails instead of blocking the request
+func TestDNSResolver_TimeoutUnit(t *testing.T) {
+	start := time.Now()
+	ok, err := DNSResolver{Timeout: time.Nanosecond}.HasMX("example.com")
+	assert.Error(t, err)
+	assert.False(t, ok)
+	assert.Less(t, time.Since(start), time.Second)
+}
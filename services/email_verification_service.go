@@ -0,0 +1,143 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"gin-user-app/apperrors"
+	"gin-user-app/mailer"
+	"gin-user-app/models"
+	"gin-user-app/repositories"
+	"gin-user-app/utils"
+	"log"
+	"strings"
+	"time"
+)
+
+// verificationTokenSize is the number of random bytes in an email verification token.
+const verificationTokenSize = 32
+
+var (
+	// ErrInvalidVerificationToken is returned for unknown, expired, used or stale verification tokens.
+	ErrInvalidVerificationToken = apperrors.BadRequest("invalid or expired email verification token")
+	// ErrVerificationThrottled is returned when a verification email was sent too recently.
+	ErrVerificationThrottled = apperrors.New(apperrors.ErrTooManyRequests, "verification email was sent recently, please wait before requesting another")
+)
+
+// EmailVerificationService sends and checks email verification tokens
+type EmailVerificationService interface {
+	SendVerification(user models.User) error
+	Verify(token string) error
+	Resend(email string) error
+}
+
+// EmailVerificationServiceImpl implements EmailVerificationService
+type EmailVerificationServiceImpl struct {
+	verificationRepo repositories.EmailVerificationRepository
+	userRepo         repositories.UserRepository
+	mailer           mailer.Mailer
+	verifyURL        string
+	ttl              time.Duration
+	resendInterval   time.Duration
+	now              func() time.Time
+}
+
+// NewEmailVerificationService creates a new instance of EmailVerificationService.
+// verifyURL receives the token as the "token" query parameter.
+func NewEmailVerificationService(verificationRepo repositories.EmailVerificationRepository, userRepo repositories.UserRepository, m mailer.Mailer, verifyURL string, ttl, resendInterval time.Duration) EmailVerificationService {
+	return &EmailVerificationServiceImpl{
+		verificationRepo: verificationRepo,
+		userRepo:         userRepo,
+		mailer:           m,
+		verifyURL:        verifyURL,
+		ttl:              ttl,
+		resendInterval:   resendInterval,
+		now:              time.Now,
+	}
+}
+
+// SendVerification emails a new verification link to the user's current
+// address. Older unused tokens of the user stop working.
+func (s *EmailVerificationServiceImpl) SendVerification(user models.User) error {
+	token, err := utils.GenerateOpaqueToken(verificationTokenSize)
+	if err != nil {
+		return err
+	}
+
+	now := s.now()
+	if err := s.verificationRepo.InvalidateForUser(user.ID, now); err != nil {
+		return err
+	}
+	if err := s.verificationRepo.Create(models.EmailVerificationToken{
+		UserID:    user.ID,
+		Email:     user.Email,
+		TokenHash: utils.HashToken(token),
+		ExpiresAt: now.Add(s.ttl),
+	}); err != nil {
+		return err
+	}
+
+	return s.mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Hi %s,\n\nPlease confirm your email address by opening the link below. It expires in %s.\n\n%s\n",
+			user.Username, s.ttl, appendToken(s.verifyURL, token)),
+	})
+}
+
+// Verify marks the email the token was sent to as verified
+func (s *EmailVerificationServiceImpl) Verify(token string) error {
+	verification, err := s.verificationRepo.FindByHash(utils.HashToken(token))
+	if err != nil {
+		return ErrInvalidVerificationToken
+	}
+	now := s.now()
+	if verification.UsedAt != nil || now.After(verification.ExpiresAt) {
+		return ErrInvalidVerificationToken
+	}
+
+	consumed, err := s.verificationRepo.Consume(verification, now)
+	if err != nil {
+		return err
+	}
+	if !consumed {
+		return ErrInvalidVerificationToken
+	}
+	return nil
+}
+
+// Resend sends a new verification email at most once per resendInterval.
+// Unknown and already verified addresses are ignored without an error.
+func (s *EmailVerificationServiceImpl) Resend(email string) error {
+	user, err := s.userRepo.FindByEmail(strings.TrimSpace(email))
+	if err != nil {
+		if errors.Is(err, repositories.ErrUserNotFound) {
+			return nil
+		}
+		return err
+	}
+	if user.EmailVerifiedAt != nil {
+		return nil
+	}
+
+	lastSentAt, err := s.verificationRepo.LastSentAt(user.ID)
+	if err != nil {
+		return err
+	}
+	if lastSentAt != nil && s.now().Sub(*lastSentAt) < s.resendInterval {
+		return ErrVerificationThrottled
+	}
+
+	if err := s.SendVerification(user); err != nil {
+		log.Println("Failed to resend verification email:", err)
+	}
+	return nil
+}
+
+// appendToken appends the token as a query parameter to a link
+func appendToken(link, token string) string {
+	separator := "?"
+	if strings.Contains(link, "?") {
+		separator = "&"
+	}
+	return link + separator + "token=" + token
+}
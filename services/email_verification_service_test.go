@@ -0,0 +1,159 @@
+package services
+
+import (
+	"errors"
+	"gin-user-app/dto"
+	"gin-user-app/mailer"
+	"gin-user-app/models"
+	"gin-user-app/utils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockEmailVerificationRepository adalah mock untuk EmailVerificationRepository
+type MockEmailVerificationRepository struct {
+	mock.Mock
+}
+
+func (m *MockEmailVerificationRepository) Create(token models.EmailVerificationToken) error {
+	args := m.Called(token)
+	return args.Error(0)
+}
+
+func (m *MockEmailVerificationRepository) FindByHash(hash string) (models.EmailVerificationToken, error) {
+	args := m.Called(hash)
+	return args.Get(0).(models.EmailVerificationToken), args.Error(1)
+}
+
+func (m *MockEmailVerificationRepository) LastSentAt(userID int) (*time.Time, error) {
+	args := m.Called(userID)
+	return args.Get(0).(*time.Time), args.Error(1)
+}
+
+func (m *MockEmailVerificationRepository) InvalidateForUser(userID int, at time.Time) error {
+	args := m.Called(userID, at)
+	return args.Error(0)
+}
+
+func (m *MockEmailVerificationRepository) Consume(token models.EmailVerificationToken, at time.Time) (bool, error) {
+	args := m.Called(token, at)
+	return args.Bool(0), args.Error(1)
+}
+
+// MockEmailVerificationService adalah mock untuk EmailVerificationService
+type MockEmailVerificationService struct {
+	mock.Mock
+}
+
+func (m *MockEmailVerificationService) SendVerification(user models.User) error {
+	args := m.Called(user)
+	return args.Error(0)
+}
+
+func (m *MockEmailVerificationService) Verify(token string) error {
+	args := m.Called(token)
+	return args.Error(0)
+}
+
+func (m *MockEmailVerificationService) Resend(email string) error {
+	args := m.Called(email)
+	return args.Error(0)
+}
+
+func newTestEmailVerificationService(repo *MockEmailVerificationRepository, userRepo *MockUserRepository, outbox *mailer.MemoryMailer) EmailVerificationService {
+	return NewEmailVerificationService(repo, userRepo, outbox, "http://localhost/auth/verify-email", time.Hour, time.Minute)
+}
+
+// TestVerifyEmail_StaleTokenUnit tests that a token for a previous email address is rejected
+func TestVerifyEmail_StaleTokenUnit(t *testing.T) {
+	repo := new(MockEmailVerificationRepository)
+	token := models.EmailVerificationToken{ID: 1, UserID: 7, Email: "old@gmail.com", ExpiresAt: time.Now().Add(time.Hour)}
+	repo.On("FindByHash", utils.HashToken("stale")).Return(token, nil)
+	repo.On("Consume", token, mock.Anything).Return(false, nil)
+
+	err := newTestEmailVerificationService(repo, nil, nil).Verify("stale")
+
+	assert.ErrorIs(t, err, ErrInvalidVerificationToken)
+}
+
+// TestVerifyEmail_ExpiredTokenUnit tests that expired tokens are rejected without consuming them
+func TestVerifyEmail_ExpiredTokenUnit(t *testing.T) {
+	repo := new(MockEmailVerificationRepository)
+	repo.On("FindByHash", utils.HashToken("expired")).Return(models.EmailVerificationToken{ID: 1, ExpiresAt: time.Now().Add(-time.Minute)}, nil)
+
+	err := newTestEmailVerificationService(repo, nil, nil).Verify("expired")
+
+	assert.ErrorIs(t, err, ErrInvalidVerificationToken)
+	repo.AssertNotCalled(t, "Consume", mock.Anything, mock.Anything)
+}
+
+// TestResendVerification_ThrottledUnit tests that resending too soon is rejected
+func TestResendVerification_ThrottledUnit(t *testing.T) {
+	repo := new(MockEmailVerificationRepository)
+	userRepo := new(MockUserRepository)
+	outbox := mailer.NewMemoryMailer("no-reply@test")
+	lastSentAt := time.Now().Add(-10 * time.Second)
+	userRepo.On("FindByEmail", "alice@gmail.com").Return(models.User{ID: 7, Email: "alice@gmail.com"}, nil)
+	repo.On("LastSentAt", 7).Return(&lastSentAt, nil)
+
+	err := newTestEmailVerificationService(repo, userRepo, outbox).Resend("alice@gmail.com")
+
+	assert.ErrorIs(t, err, ErrVerificationThrottled)
+	assert.Empty(t, outbox.Messages())
+}
+
+// TestResendVerification_SendsAfterIntervalUnit tests that a new link is sent once the interval passed
+func TestResendVerification_SendsAfterIntervalUnit(t *testing.T) {
+	repo := new(MockEmailVerificationRepository)
+	userRepo := new(MockUserRepository)
+	outbox := mailer.NewMemoryMailer("no-reply@test")
+	lastSentAt := time.Now().Add(-2 * time.Minute)
+	userRepo.On("FindByEmail", "alice@gmail.com").Return(models.User{ID: 7, Email: "alice@gmail.com"}, nil)
+	repo.On("LastSentAt", 7).Return(&lastSentAt, nil)
+	repo.On("InvalidateForUser", 7, mock.Anything).Return(nil)
+	repo.On("Create", mock.MatchedBy(func(token models.EmailVerificationToken) bool {
+		return token.UserID == 7 && token.Email == "alice@gmail.com"
+	})).Return(nil)
+
+	err := newTestEmailVerificationService(repo, userRepo, outbox).Resend("alice@gmail.com")
+
+	assert.NoError(t, err)
+	assert.Len(t, outbox.Messages(), 1)
+	assert.Contains(t, outbox.Messages()[0].Body, "http://localhost/auth/verify-email?token=")
+}
+
+// TestResendVerification_AlreadyVerifiedUnit tests that verified addresses are ignored
+func TestResendVerification_AlreadyVerifiedUnit(t *testing.T) {
+	userRepo := new(MockUserRepository)
+	outbox := mailer.NewMemoryMailer("no-reply@test")
+	verifiedAt := time.Now()
+	userRepo.On("FindByEmail", "alice@gmail.com").Return(models.User{ID: 7, EmailVerifiedAt: &verifiedAt}, nil)
+
+	err := newTestEmailVerificationService(new(MockEmailVerificationRepository), userRepo, outbox).Resend("alice@gmail.com")
+
+	assert.NoError(t, err)
+	assert.Empty(t, outbox.Messages())
+}
+
+// TestUpdateUser_EmailChangeResetsVerificationUnit tests that a new email must be verified again
+func TestUpdateUser_EmailChangeResetsVerificationUnit(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	verifier := new(MockEmailVerificationService)
+	service := NewUserService(mockRepo, verifier, nil, nil, "")
+	verifiedAt := time.Now()
+
+	mockRepo.On("GetByID", 1).Return(models.User{ID: 1, Username: "alice", Email: "old@gmail.com", EmailVerifiedAt: &verifiedAt}, nil)
+	mockRepo.On("Update", mock.MatchedBy(func(user models.User) bool {
+		return user.Email == "new@gmail.com" && user.EmailVerifiedAt == nil
+	})).Return(models.User{ID: 1, Username: "alice", Email: "new@gmail.com"}, nil)
+	verifier.On("SendVerification", mock.Anything).Return(errors.New("smtp down"))
+
+	user, err := service.UpdateUser(1, dto.UpdateUserDTO{Username: "alice", Email: "new@gmail.com", CanChangeCredentials: true}, 0)
+
+	assert.NoError(t, err)
+	assert.Nil(t, user.EmailVerifiedAt)
+	verifier.AssertCalled(t, "SendVerification", models.User{ID: 1, Username: "alice", Email: "new@gmail.com"})
+}
@@ -0,0 +1,376 @@
+package services
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"gin-user-app/dto"
+	"gin-user-app/models"
+	"gin-user-app/repositories"
+	"gin-user-app/utils"
+	"log"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Supported JWT signing algorithms. HS256 signs with the shared JWT_SECRET
+// and publishes no keys; RS256 and EdDSA use rotating key pairs.
+const (
+	AlgorithmHS256 = "HS256"
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
+const (
+	// rsaKeyBits is the size of generated RSA keys.
+	rsaKeyBits = 2048
+	// keyReloadCooldown limits reloads triggered by tokens with an unknown kid.
+	keyReloadCooldown = 10 * time.Second
+)
+
+var (
+	// ErrUnknownSigningKey is returned when a token names a kid that is not in the key set.
+	ErrUnknownSigningKey = errors.New("unknown signing key")
+	// ErrNoSigningKey is returned when no key is available for signing.
+	ErrNoSigningKey = errors.New("no active signing key")
+)
+
+// KeyManagerConfig selects the signing algorithm and the rotation schedule.
+// GracePeriod must be longer than the lifetime of any token signed with a key.
+type KeyManagerConfig struct {
+	Algorithm        string
+	Secret           string
+	RotationInterval time.Duration
+	GracePeriod      time.Duration
+}
+
+// KeyManager signs JWTs and resolves verification keys by kid
+type KeyManager interface {
+	Sign(claims jwt.Claims) (string, error)
+	Keyfunc(token *jwt.Token) (interface{}, error)
+	Algorithms() []string
+	JWKS() dto.JWKSetDTO
+	RotateIfDue() error
+	Rotate() error
+}
+
+// managedKey is a parsed signing key
+type managedKey struct {
+	kid       string
+	algorithm string
+	private   crypto.Signer
+	public    crypto.PublicKey
+	createdAt time.Time
+	retired   bool
+}
+
+// KeyManagerImpl implements KeyManager
+type KeyManagerImpl struct {
+	keyRepo    repositories.SigningKeyRepository
+	cfg        KeyManagerConfig
+	mu         sync.RWMutex
+	keys       map[string]*managedKey
+	current    *managedKey
+	lastReload time.Time
+	now        func() time.Time
+}
+
+// NewKeyManager creates a KeyManager. For RS256 and EdDSA the keys are loaded
+// from keyRepo and a first key is generated when none is usable.
+func NewKeyManager(keyRepo repositories.SigningKeyRepository, cfg KeyManagerConfig) (KeyManager, error) {
+	m := &KeyManagerImpl{
+		keyRepo: keyRepo,
+		cfg:     cfg,
+		keys:    make(map[string]*managedKey),
+		now:     time.Now,
+	}
+
+	switch cfg.Algorithm {
+	case AlgorithmHS256:
+		if cfg.Secret == "" {
+			return nil, errors.New("HS256 signing requires a secret")
+		}
+		return m, nil
+	case AlgorithmRS256, AlgorithmEdDSA:
+		if err := m.RotateIfDue(); err != nil {
+			return nil, err
+		}
+		return m, nil
+	default:
+		return nil, fmt.Errorf("unsupported jwt signing algorithm %q", cfg.Algorithm)
+	}
+}
+
+// Sign signs the claims with the current key and sets the kid header
+func (m *KeyManagerImpl) Sign(claims jwt.Claims) (string, error) {
+	if m.cfg.Algorithm == AlgorithmHS256 {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(m.cfg.Secret))
+	}
+
+	m.mu.RLock()
+	key := m.current
+	m.mu.RUnlock()
+	if key == nil {
+		return "", ErrNoSigningKey
+	}
+
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(key.algorithm), claims)
+	token.Header["kid"] = key.kid
+	return token.SignedString(key.private)
+}
+
+// Keyfunc returns the verification key for a token. The token's alg must match
+// the algorithm of the key named by its kid, which prevents algorithm confusion.
+func (m *KeyManagerImpl) Keyfunc(token *jwt.Token) (interface{}, error) {
+	if m.cfg.Algorithm == AlgorithmHS256 {
+		if token.Method.Alg() != AlgorithmHS256 {
+			return nil, jwt.ErrTokenSignatureInvalid
+		}
+		return []byte(m.cfg.Secret), nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		return nil, ErrUnknownSigningKey
+	}
+
+	key, ok := m.lookup(kid)
+	if !ok {
+		// Key may have been created by another instance since the last reload
+		if err := m.reloadThrottled(); err != nil {
+			return nil, err
+		}
+		if key, ok = m.lookup(kid); !ok {
+			return nil, ErrUnknownSigningKey
+		}
+	}
+	if token.Method.Alg() != key.algorithm {
+		return nil, jwt.ErrTokenSignatureInvalid
+	}
+	return key.public, nil
+}
+
+// Algorithms lists the algorithms accepted when parsing tokens
+func (m *KeyManagerImpl) Algorithms() []string {
+	if m.cfg.Algorithm == AlgorithmHS256 {
+		return []string{AlgorithmHS256}
+	}
+	return []string{AlgorithmRS256, AlgorithmEdDSA}
+}
+
+// JWKS returns the public keys that can currently verify tokens
+func (m *KeyManagerImpl) JWKS() dto.JWKSetDTO {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	set := dto.JWKSetDTO{Keys: make([]dto.JWKDTO, 0, len(m.keys))}
+	for _, key := range m.keys {
+		jwk := dto.JWKDTO{Kid: key.kid, Use: "sig", Alg: key.algorithm}
+		switch public := key.public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		default:
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
+
+// RotateIfDue reloads the key set, removes expired keys and rotates when the
+// current key is older than RotationInterval or uses another algorithm.
+func (m *KeyManagerImpl) RotateIfDue() error {
+	if m.cfg.Algorithm == AlgorithmHS256 {
+		return nil
+	}
+
+	if _, err := m.keyRepo.DeleteExpired(m.now()); err != nil {
+		return err
+	}
+	if err := m.reload(); err != nil {
+		return err
+	}
+
+	m.mu.RLock()
+	current := m.current
+	m.mu.RUnlock()
+	if current != nil && current.algorithm == m.cfg.Algorithm &&
+		(m.cfg.RotationInterval <= 0 || m.now().Sub(current.createdAt) < m.cfg.RotationInterval) {
+		return nil
+	}
+	return m.Rotate()
+}
+
+// Rotate generates a new signing key. Older keys stop signing but keep
+// verifying tokens for GracePeriod.
+func (m *KeyManagerImpl) Rotate() error {
+	if m.cfg.Algorithm == AlgorithmHS256 {
+		return errors.New("HS256 keys cannot be rotated")
+	}
+
+	private, err := generateSigningKey(m.cfg.Algorithm)
+	if err != nil {
+		return err
+	}
+	encoded, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return fmt.Errorf("failed to encode signing key: %w", err)
+	}
+	kid, err := utils.GenerateRandomID()
+	if err != nil {
+		return err
+	}
+
+	now := m.now()
+	if err := m.keyRepo.Create(models.SigningKey{
+		KID:        kid,
+		Algorithm:  m.cfg.Algorithm,
+		PrivateKey: string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: encoded})),
+		CreatedAt:  now,
+	}); err != nil {
+		return err
+	}
+	if err := m.keyRepo.RetireOlderThan(now, now, now.Add(m.cfg.GracePeriod)); err != nil {
+		return err
+	}
+	log.Printf("Rotated JWT signing key, new kid %s (%s)", kid, m.cfg.Algorithm)
+	return m.reload()
+}
+
+// lookup finds a verification key by kid
+func (m *KeyManagerImpl) lookup(kid string) (*managedKey, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	key, ok := m.keys[kid]
+	return key, ok
+}
+
+// reloadThrottled reloads the key set at most once per keyReloadCooldown
+func (m *KeyManagerImpl) reloadThrottled() error {
+	m.mu.RLock()
+	recent := m.now().Sub(m.lastReload) < keyReloadCooldown
+	m.mu.RUnlock()
+	if recent {
+		return nil
+	}
+	return m.reload()
+}
+
+// reload replaces the in-memory key set with the keys in the repository
+func (m *KeyManagerImpl) reload() error {
+	now := m.now()
+	stored, err := m.keyRepo.ListValid(now)
+	if err != nil {
+		return err
+	}
+
+	keys := make(map[string]*managedKey, len(stored))
+	var current *managedKey
+	for _, record := range stored {
+		key, err := parseSigningKey(record)
+		if err != nil {
+			log.Printf("Skipping signing key %s: %v", record.KID, err)
+			continue
+		}
+		keys[key.kid] = key
+		// Keys are ordered newest first
+		if current == nil && !key.retired {
+			current = key
+		}
+	}
+
+	m.mu.Lock()
+	m.keys = keys
+	m.current = current
+	m.lastReload = now
+	m.mu.Unlock()
+	return nil
+}
+
+// parseSigningKey decodes a stored PKCS#8 private key
+func parseSigningKey(record models.SigningKey) (*managedKey, error) {
+	block, _ := pem.Decode([]byte(record.PrivateKey))
+	if block == nil {
+		return nil, errors.New("invalid PEM block")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	var private crypto.Signer
+	switch record.Algorithm {
+	case AlgorithmRS256:
+		rsaKey, ok := parsed.(*rsa.PrivateKey)
+		if !ok {
+			return nil, errors.New("RS256 key is not an RSA key")
+		}
+		private = rsaKey
+	case AlgorithmEdDSA:
+		edKey, ok := parsed.(ed25519.PrivateKey)
+		if !ok {
+			return nil, errors.New("EdDSA key is not an Ed25519 key")
+		}
+		private = edKey
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q", record.Algorithm)
+	}
+
+	return &managedKey{
+		kid:       record.KID,
+		algorithm: record.Algorithm,
+		private:   private,
+		public:    private.Public(),
+		createdAt: record.CreatedAt,
+		retired:   record.RetiredAt != nil,
+	}, nil
+}
+
+// generateSigningKey creates a new private key for the algorithm
+func generateSigningKey(algorithm string) (crypto.Signer, error) {
+	switch algorithm {
+	case AlgorithmRS256:
+		return rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	case AlgorithmEdDSA:
+		_, private, err := ed25519.GenerateKey(rand.Reader)
+		return private, err
+	default:
+		return nil, fmt.Errorf("unsupported jwt signing algorithm %q", algorithm)
+	}
+}
+
+// StartKeyRotation calls RotateIfDue on every tick until stop is called
+func StartKeyRotation(manager KeyManager, interval time.Duration) (stop func()) {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				if err := manager.RotateIfDue(); err != nil {
+					log.Println("Failed to rotate JWT signing keys:", err)
+				}
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() { once.Do(func() { close(done) }) }
+}
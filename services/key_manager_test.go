@@ -0,0 +1,190 @@
+package services
+
+import (
+	"crypto/ed25519"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"gin-user-app/models"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeSigningKeyRepository keeps signing keys in memory
+type fakeSigningKeyRepository struct {
+	mu   sync.Mutex
+	keys []models.SigningKey
+}
+
+func (r *fakeSigningKeyRepository) ListValid(now time.Time) ([]models.SigningKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	valid := []models.SigningKey{}
+	for _, key := range r.keys {
+		if key.ExpiresAt == nil || key.ExpiresAt.After(now) {
+			valid = append(valid, key)
+		}
+	}
+	sort.Slice(valid, func(i, j int) bool { return valid[i].CreatedAt.After(valid[j].CreatedAt) })
+	return valid, nil
+}
+
+func (r *fakeSigningKeyRepository) Create(key models.SigningKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.keys = append(r.keys, key)
+	return nil
+}
+
+func (r *fakeSigningKeyRepository) RetireOlderThan(createdBefore time.Time, at time.Time, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.keys {
+		if r.keys[i].RetiredAt == nil && r.keys[i].CreatedAt.Before(createdBefore) {
+			retiredAt, expires := at, expiresAt
+			r.keys[i].RetiredAt = &retiredAt
+			r.keys[i].ExpiresAt = &expires
+		}
+	}
+	return nil
+}
+
+func (r *fakeSigningKeyRepository) DeleteExpired(now time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	kept := r.keys[:0]
+	var deleted int64
+	for _, key := range r.keys {
+		if key.ExpiresAt != nil && !key.ExpiresAt.After(now) {
+			deleted++
+			continue
+		}
+		kept = append(kept, key)
+	}
+	r.keys = kept
+	return deleted, nil
+}
+
+// newTestKeyManager returns an HS256 key manager for tests that do not care about key rotation
+func newTestKeyManager() KeyManager {
+	keys, err := NewKeyManager(nil, KeyManagerConfig{Algorithm: AlgorithmHS256, Secret: "test-secret"})
+	if err != nil {
+		panic(err)
+	}
+	return keys
+}
+
+// newTestRotatingKeyManager returns an asymmetric key manager with a controllable clock
+func newTestRotatingKeyManager(t *testing.T, algorithm string, clock *time.Time) *KeyManagerImpl {
+	m := &KeyManagerImpl{
+		keyRepo: &fakeSigningKeyRepository{},
+		cfg: KeyManagerConfig{
+			Algorithm:        algorithm,
+			RotationInterval: 24 * time.Hour,
+			GracePeriod:      time.Hour,
+		},
+		keys: map[string]*managedKey{},
+		now:  func() time.Time { return *clock },
+	}
+	assert.NoError(t, m.RotateIfDue())
+	return m
+}
+
+func parseWith(m KeyManager, token string) error {
+	_, err := jwt.Parse(token, m.Keyfunc, jwt.WithValidMethods(m.Algorithms()), jwt.WithoutClaimsValidation())
+	return err
+}
+
+// TestKeyManager_SignAndVerifyEdDSAUnit tests that EdDSA tokens carry a kid and verify against the key set
+func TestKeyManager_SignAndVerifyEdDSAUnit(t *testing.T) {
+	clock := time.Now()
+	m := newTestRotatingKeyManager(t, AlgorithmEdDSA, &clock)
+
+	token, err := m.Sign(jwt.MapClaims{"user_id": 1})
+	assert.NoError(t, err)
+	assert.NoError(t, parseWith(m, token))
+
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, jwt.MapClaims{})
+	assert.NoError(t, err)
+	assert.Equal(t, AlgorithmEdDSA, parsed.Header["alg"])
+	assert.Equal(t, m.current.kid, parsed.Header["kid"])
+
+	jwks := m.JWKS()
+	assert.Len(t, jwks.Keys, 1)
+	assert.Equal(t, "OKP", jwks.Keys[0].Kty)
+	assert.Equal(t, "Ed25519", jwks.Keys[0].Crv)
+	assert.NotEmpty(t, jwks.Keys[0].X)
+}
+
+// TestKeyManager_RS256JWKSUnit tests the JWK encoding of RSA keys
+func TestKeyManager_RS256JWKSUnit(t *testing.T) {
+	clock := time.Now()
+	m := newTestRotatingKeyManager(t, AlgorithmRS256, &clock)
+
+	jwks := m.JWKS()
+	assert.Len(t, jwks.Keys, 1)
+	assert.Equal(t, "RSA", jwks.Keys[0].Kty)
+	assert.Equal(t, AlgorithmRS256, jwks.Keys[0].Alg)
+	assert.Equal(t, "AQAB", jwks.Keys[0].E)
+	assert.NotEmpty(t, jwks.Keys[0].N)
+}
+
+// TestKeyManager_RejectsAlgorithmConfusionUnit tests that an HS256 token signed with public key bytes is rejected
+func TestKeyManager_RejectsAlgorithmConfusionUnit(t *testing.T) {
+	clock := time.Now()
+	m := newTestRotatingKeyManager(t, AlgorithmEdDSA, &clock)
+
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"user_id": 1})
+	forged.Header["kid"] = m.current.kid
+	signed, err := forged.SignedString([]byte(m.current.public.(ed25519.PublicKey)))
+	assert.NoError(t, err)
+	assert.Error(t, parseWith(m, signed))
+
+	// An unknown kid is rejected as well
+	unknown := jwt.NewWithClaims(jwt.SigningMethodEdDSA, jwt.MapClaims{"user_id": 1})
+	unknown.Header["kid"] = "unknown"
+	signed, err = unknown.SignedString(m.current.private)
+	assert.NoError(t, err)
+	assert.Error(t, parseWith(m, signed))
+}
+
+// TestKeyManager_RotationGracePeriodUnit tests that retired keys verify until the grace period ends
+func TestKeyManager_RotationGracePeriodUnit(t *testing.T) {
+	clock := time.Now()
+	m := newTestRotatingKeyManager(t, AlgorithmEdDSA, &clock)
+	oldKID := m.current.kid
+
+	oldToken, err := m.Sign(jwt.MapClaims{"user_id": 1})
+	assert.NoError(t, err)
+
+	// Not due yet
+	assert.NoError(t, m.RotateIfDue())
+	assert.Equal(t, oldKID, m.current.kid)
+
+	clock = clock.Add(25 * time.Hour)
+	assert.NoError(t, m.RotateIfDue())
+	assert.NotEqual(t, oldKID, m.current.kid)
+	assert.Len(t, m.JWKS().Keys, 2)
+	assert.NoError(t, parseWith(m, oldToken))
+
+	clock = clock.Add(2 * time.Hour)
+	assert.NoError(t, m.RotateIfDue())
+	assert.Len(t, m.JWKS().Keys, 1)
+	assert.ErrorIs(t, parseWith(m, oldToken), ErrUnknownSigningKey)
+}
+
+// TestKeyManager_HS256RejectsOtherAlgorithmsUnit tests that HS256 mode only accepts HS256 tokens
+func TestKeyManager_HS256RejectsOtherAlgorithmsUnit(t *testing.T) {
+	m := newTestKeyManager()
+	token, err := m.Sign(jwt.MapClaims{"user_id": 1})
+	assert.NoError(t, err)
+	assert.NoError(t, parseWith(m, token))
+	assert.Empty(t, m.JWKS().Keys)
+
+	none, err := jwt.NewWithClaims(jwt.SigningMethodNone, jwt.MapClaims{"user_id": 1}).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	assert.NoError(t, err)
+	assert.Error(t, parseWith(m, none))
+}
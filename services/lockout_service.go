@@ -0,0 +1,235 @@
+package services
+
+import (
+	"fmt"
+	"gin-user-app/apperrors"
+	"gin-user-app/dto"
+	"gin-user-app/models"
+	"gin-user-app/repositories"
+	"gin-user-app/utils"
+	"strings"
+	"sync"
+	"time"
+)
+
+// unknownAttemptRetention is how long counters of unknown identifiers are
+// kept after their last failure
+const unknownAttemptRetention = 24 * time.Hour
+
+var (
+	// ErrAccountLocked is returned while an account is locked after too many failed logins.
+	ErrAccountLocked = apperrors.New(apperrors.ErrLocked, "account temporarily locked after too many failed logins")
+	// ErrLoginThrottled is returned when a login is attempted before the progressive delay passed.
+	ErrLoginThrottled = apperrors.New(apperrors.ErrTooManyRequests, "too many failed logins, please wait before trying again")
+)
+
+// LoginBlockedError wraps ErrAccountLocked or ErrLoginThrottled with the time
+// the client has to wait before the next attempt.
+type LoginBlockedError struct {
+	Err        error
+	RetryAfter time.Duration
+}
+
+func (e *LoginBlockedError) Error() string {
+	return fmt.Sprintf("%s (retry after %s)", e.Err, e.RetryAfter.Round(time.Second))
+}
+
+func (e *LoginBlockedError) Unwrap() error {
+	return e.Err
+}
+
+// LockoutPolicy controls progressive delays and lockout of failed logins.
+// After FreeAttempts failures every further attempt has to wait BaseDelay,
+// doubled per failure up to MaxDelay. MaxAttempts failures lock the account
+// for LockoutDuration.
+type LockoutPolicy struct {
+	FreeAttempts    int
+	MaxAttempts     int
+	BaseDelay       time.Duration
+	MaxDelay        time.Duration
+	LockoutDuration time.Duration
+}
+
+// delay returns how long to wait after the given number of consecutive failures
+func (p LockoutPolicy) delay(failures int) time.Duration {
+	if failures < p.FreeAttempts || p.BaseDelay <= 0 {
+		return 0
+	}
+	delay := p.BaseDelay
+	for i := p.FreeAttempts; i < failures && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	return delay
+}
+
+// LockoutService tracks failed logins per account
+type LockoutService interface {
+	Check(userID int) error
+	RecordFailure(userID int) error
+	RecordSuccess(userID int) error
+	// CheckUnknown and RecordUnknownFailure apply the same policy to
+	// identifiers without an account, so the 429 and 423 responses do not
+	// reveal which identifiers exist
+	CheckUnknown(identifier string) error
+	RecordUnknownFailure(identifier string)
+	ListLockouts() ([]dto.LockoutDTO, error)
+	GetLockout(userID int) (dto.LockoutDTO, error)
+	ClearLockout(userID int) error
+}
+
+// LockoutServiceImpl implements LockoutService
+type LockoutServiceImpl struct {
+	attemptRepo repositories.LoginAttemptRepository
+	policy      LockoutPolicy
+	now         func() time.Time
+
+	// Counters of unknown identifiers are kept in memory only, keyed by the
+	// canonical identifier
+	unknownMu       sync.Mutex
+	unknown         map[string]models.LoginAttempt
+	unknownPrunedAt time.Time
+}
+
+// NewLockoutService creates a new instance of LockoutService
+func NewLockoutService(attemptRepo repositories.LoginAttemptRepository, policy LockoutPolicy) LockoutService {
+	return &LockoutServiceImpl{
+		attemptRepo: attemptRepo,
+		policy:      policy,
+		now:         time.Now,
+		unknown:     make(map[string]models.LoginAttempt),
+	}
+}
+
+// Check returns a *LoginBlockedError when the account is locked or still inside its progressive delay
+func (s *LockoutServiceImpl) Check(userID int) error {
+	attempt, err := s.attemptRepo.Get(userID)
+	if err != nil {
+		return err
+	}
+
+	return s.blocked(attempt, s.now())
+}
+
+// blocked returns a *LoginBlockedError when the counter is locked or still inside its progressive delay
+func (s *LockoutServiceImpl) blocked(attempt models.LoginAttempt, now time.Time) error {
+	if attempt.LockedUntil != nil && now.Before(*attempt.LockedUntil) {
+		return &LoginBlockedError{Err: ErrAccountLocked, RetryAfter: attempt.LockedUntil.Sub(now)}
+	}
+	if delay := s.policy.delay(attempt.FailedAttempts); delay > 0 {
+		if next := attempt.LastFailedAt.Add(delay); now.Before(next) {
+			return &LoginBlockedError{Err: ErrLoginThrottled, RetryAfter: next.Sub(now)}
+		}
+	}
+	return nil
+}
+
+// RecordFailure counts a failed login and locks the account once MaxAttempts is reached
+func (s *LockoutServiceImpl) RecordFailure(userID int) error {
+	now := s.now()
+	attempt, err := s.attemptRepo.RecordFailure(userID, now)
+	if err != nil {
+		return err
+	}
+	if s.policy.MaxAttempts > 0 && attempt.FailedAttempts >= s.policy.MaxAttempts {
+		return s.attemptRepo.Lock(userID, now.Add(s.policy.LockoutDuration))
+	}
+	return nil
+}
+
+// CheckUnknown is Check for an identifier that does not belong to an account
+func (s *LockoutServiceImpl) CheckUnknown(identifier string) error {
+	s.unknownMu.Lock()
+	defer s.unknownMu.Unlock()
+	return s.blocked(s.unknown[unknownAttemptKey(identifier)], s.now())
+}
+
+// RecordUnknownFailure is RecordFailure for an identifier that does not
+// belong to an account. Counters untouched for unknownAttemptRetention are
+// pruned.
+func (s *LockoutServiceImpl) RecordUnknownFailure(identifier string) {
+	now := s.now()
+	key := unknownAttemptKey(identifier)
+	s.unknownMu.Lock()
+	defer s.unknownMu.Unlock()
+
+	attempt := s.unknown[key]
+	attempt.FailedAttempts++
+	attempt.LastFailedAt = now
+	attempt.UpdatedAt = now
+	if s.policy.MaxAttempts > 0 && attempt.FailedAttempts >= s.policy.MaxAttempts {
+		lockedUntil := now.Add(s.policy.LockoutDuration)
+		attempt.LockedUntil = &lockedUntil
+	}
+	s.unknown[key] = attempt
+
+	if now.Sub(s.unknownPrunedAt) >= time.Minute {
+		for key, attempt := range s.unknown {
+			if now.Sub(attempt.LastFailedAt) > unknownAttemptRetention {
+				delete(s.unknown, key)
+			}
+		}
+		s.unknownPrunedAt = now
+	}
+}
+
+// unknownAttemptKey canonicalizes identifier like the user lookup does, so
+// spellings that would match the same account share a counter
+func unknownAttemptKey(identifier string) string {
+	if strings.Contains(identifier, "@") {
+		return utils.CanonicalEmail(identifier)
+	}
+	if phone, ok := utils.CanonicalPhone(identifier); ok {
+		return phone
+	}
+	return utils.CanonicalUsername(identifier)
+}
+
+// RecordSuccess resets the counter after a successful login
+func (s *LockoutServiceImpl) RecordSuccess(userID int) error {
+	return s.attemptRepo.Clear(userID)
+}
+
+// ListLockouts returns every account with failed logins
+func (s *LockoutServiceImpl) ListLockouts() ([]dto.LockoutDTO, error) {
+	attempts, err := s.attemptRepo.List()
+	if err != nil {
+		return nil, err
+	}
+	lockouts := make([]dto.LockoutDTO, 0, len(attempts))
+	for _, attempt := range attempts {
+		lockouts = append(lockouts, s.toLockoutDTO(attempt))
+	}
+	return lockouts, nil
+}
+
+// GetLockout returns the failed login counter of one account
+func (s *LockoutServiceImpl) GetLockout(userID int) (dto.LockoutDTO, error) {
+	attempt, err := s.attemptRepo.Get(userID)
+	if err != nil {
+		return dto.LockoutDTO{}, err
+	}
+	return s.toLockoutDTO(attempt), nil
+}
+
+// ClearLockout removes the counter and lockout of an account
+func (s *LockoutServiceImpl) ClearLockout(userID int) error {
+	return s.attemptRepo.Clear(userID)
+}
+
+// toLockoutDTO converts a LoginAttempt model into a LockoutDTO
+func (s *LockoutServiceImpl) toLockoutDTO(attempt models.LoginAttempt) dto.LockoutDTO {
+	lockout := dto.LockoutDTO{
+		UserID:         attempt.UserID,
+		FailedAttempts: attempt.FailedAttempts,
+		LockedUntil:    attempt.LockedUntil,
+		Locked:         attempt.LockedUntil != nil && s.now().Before(*attempt.LockedUntil),
+	}
+	if !attempt.LastFailedAt.IsZero() {
+		lastFailedAt := attempt.LastFailedAt
+		lockout.LastFailedAt = &lastFailedAt
+	}
+	return lockout
+}
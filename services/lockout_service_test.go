@@ -0,0 +1,165 @@
+package services
+
+import (
+	"gin-user-app/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockLoginAttemptRepository adalah mock untuk LoginAttemptRepository
+type MockLoginAttemptRepository struct {
+	mock.Mock
+}
+
+func (m *MockLoginAttemptRepository) Get(userID int) (models.LoginAttempt, error) {
+	args := m.Called(userID)
+	return args.Get(0).(models.LoginAttempt), args.Error(1)
+}
+
+func (m *MockLoginAttemptRepository) RecordFailure(userID int, at time.Time) (models.LoginAttempt, error) {
+	args := m.Called(userID, at)
+	return args.Get(0).(models.LoginAttempt), args.Error(1)
+}
+
+func (m *MockLoginAttemptRepository) Lock(userID int, until time.Time) error {
+	args := m.Called(userID, until)
+	return args.Error(0)
+}
+
+func (m *MockLoginAttemptRepository) Clear(userID int) error {
+	args := m.Called(userID)
+	return args.Error(0)
+}
+
+func (m *MockLoginAttemptRepository) List() ([]models.LoginAttempt, error) {
+	args := m.Called()
+	return args.Get(0).([]models.LoginAttempt), args.Error(1)
+}
+
+var testLockoutPolicy = LockoutPolicy{
+	FreeAttempts:    3,
+	MaxAttempts:     5,
+	BaseDelay:       time.Second,
+	MaxDelay:        3 * time.Second,
+	LockoutDuration: 15 * time.Minute,
+}
+
+func newTestLockoutService(repo *MockLoginAttemptRepository, now time.Time) *LockoutServiceImpl {
+	service := NewLockoutService(repo, testLockoutPolicy).(*LockoutServiceImpl)
+	service.now = func() time.Time { return now }
+	return service
+}
+
+// TestLockoutPolicy_ProgressiveDelayUnit tests that delays double after the free attempts and are capped
+func TestLockoutPolicy_ProgressiveDelayUnit(t *testing.T) {
+	assert.Equal(t, time.Duration(0), testLockoutPolicy.delay(2))
+	assert.Equal(t, time.Second, testLockoutPolicy.delay(3))
+	assert.Equal(t, 2*time.Second, testLockoutPolicy.delay(4))
+	assert.Equal(t, 3*time.Second, testLockoutPolicy.delay(5))
+	assert.Equal(t, 3*time.Second, testLockoutPolicy.delay(50))
+}
+
+// TestLockoutCheck_ThrottledUnit tests that an attempt inside the progressive delay is rejected
+func TestLockoutCheck_ThrottledUnit(t *testing.T) {
+	now := time.Now()
+	repo := new(MockLoginAttemptRepository)
+	repo.On("Get", 1).Return(models.LoginAttempt{UserID: 1, FailedAttempts: 4, LastFailedAt: now.Add(-500 * time.Millisecond)}, nil)
+
+	err := newTestLockoutService(repo, now).Check(1)
+
+	assert.ErrorIs(t, err, ErrLoginThrottled)
+	var blocked *LoginBlockedError
+	assert.ErrorAs(t, err, &blocked)
+	assert.Equal(t, 1500*time.Millisecond, blocked.RetryAfter)
+}
+
+// TestLockoutCheck_LockedUnit tests that a locked account is rejected until the lock expires
+func TestLockoutCheck_LockedUnit(t *testing.T) {
+	now := time.Now()
+	lockedUntil := now.Add(time.Minute)
+	repo := new(MockLoginAttemptRepository)
+	repo.On("Get", 1).Return(models.LoginAttempt{UserID: 1, FailedAttempts: 5, LastFailedAt: now.Add(-time.Hour), LockedUntil: &lockedUntil}, nil)
+
+	err := newTestLockoutService(repo, now).Check(1)
+
+	assert.ErrorIs(t, err, ErrAccountLocked)
+}
+
+// TestLockoutCheck_AfterDelayUnit tests that an attempt after the delay is allowed
+func TestLockoutCheck_AfterDelayUnit(t *testing.T) {
+	now := time.Now()
+	expiredLock := now.Add(-time.Second)
+	repo := new(MockLoginAttemptRepository)
+	repo.On("Get", 1).Return(models.LoginAttempt{UserID: 1, FailedAttempts: 5, LastFailedAt: now.Add(-time.Minute), LockedUntil: &expiredLock}, nil)
+
+	assert.NoError(t, newTestLockoutService(repo, now).Check(1))
+}
+
+// TestLockoutRecordFailure_LocksAtMaxUnit tests that reaching MaxAttempts locks the account
+func TestLockoutRecordFailure_LocksAtMaxUnit(t *testing.T) {
+	now := time.Now()
+	repo := new(MockLoginAttemptRepository)
+	repo.On("RecordFailure", 1, now).Return(models.LoginAttempt{UserID: 1, FailedAttempts: 5}, nil)
+	repo.On("Lock", 1, now.Add(15*time.Minute)).Return(nil)
+
+	err := newTestLockoutService(repo, now).RecordFailure(1)
+
+	assert.NoError(t, err)
+	repo.AssertExpectations(t)
+}
+
+// TestLockoutRecordFailure_BelowMaxUnit tests that failures below MaxAttempts do not lock
+func TestLockoutRecordFailure_BelowMaxUnit(t *testing.T) {
+	now := time.Now()
+	repo := new(MockLoginAttemptRepository)
+	repo.On("RecordFailure", 1, now).Return(models.LoginAttempt{UserID: 1, FailedAttempts: 4}, nil)
+
+	err := newTestLockoutService(repo, now).RecordFailure(1)
+
+	assert.NoError(t, err)
+	repo.AssertNotCalled(t, "Lock", mock.Anything, mock.Anything)
+}
+
+// TestLockoutUnknown_SameAsAccountUnit tests that unknown identifiers are throttled and locked like accounts
+func TestLockoutUnknown_SameAsAccountUnit(t *testing.T) {
+	now := time.Now()
+	service := newTestLockoutService(new(MockLoginAttemptRepository), now)
+
+	for i := 0; i < testLockoutPolicy.FreeAttempts; i++ {
+		assert.NoError(t, service.CheckUnknown("ghost"))
+		service.RecordUnknownFailure("ghost")
+	}
+	err := service.CheckUnknown("ghost")
+	assert.ErrorIs(t, err, ErrLoginThrottled)
+	var blocked *LoginBlockedError
+	assert.ErrorAs(t, err, &blocked)
+	assert.Equal(t, time.Second, blocked.RetryAfter)
+
+	// Spellings that would match the same account share the counter
+	assert.ErrorIs(t, service.CheckUnknown(" GHOST "), ErrLoginThrottled)
+	assert.NoError(t, service.CheckUnknown("other"))
+
+	for i := testLockoutPolicy.FreeAttempts; i < testLockoutPolicy.MaxAttempts; i++ {
+		service.RecordUnknownFailure("ghost")
+	}
+	assert.ErrorIs(t, service.CheckUnknown("ghost"), ErrAccountLocked)
+
+	service.now = func() time.Time { return now.Add(testLockoutPolicy.LockoutDuration + time.Second) }
+	assert.NoError(t, service.CheckUnknown("ghost"))
+}
+
+// TestLockoutUnknown_PrunesOldCountersUnit tests that counters untouched for the retention period are removed
+func TestLockoutUnknown_PrunesOldCountersUnit(t *testing.T) {
+	now := time.Now()
+	service := newTestLockoutService(new(MockLoginAttemptRepository), now)
+	service.RecordUnknownFailure("ghost")
+
+	service.now = func() time.Time { return now.Add(unknownAttemptRetention + time.Minute) }
+	service.RecordUnknownFailure("other")
+
+	assert.NotContains(t, service.unknown, "ghost")
+	assert.Contains(t, service.unknown, "other")
+}
@@ -0,0 +1,218 @@
+package services
+
+import (
+	"errors"
+	"gin-user-app/apperrors"
+	"gin-user-app/dto"
+	"gin-user-app/models"
+	"gin-user-app/repositories"
+	"gin-user-app/utils"
+	"time"
+)
+
+const (
+	// recoveryCodeCount is the number of recovery codes generated at a time.
+	recoveryCodeCount = 10
+	// maxMFAAttempts is the number of wrong codes accepted before verification is locked.
+	maxMFAAttempts = 5
+	// mfaLockoutDuration is how long codes are refused once maxMFAAttempts is reached.
+	mfaLockoutDuration = 15 * time.Minute
+)
+
+var (
+	// ErrMFAAlreadyEnabled is returned when enrolling while two-factor authentication is active.
+	ErrMFAAlreadyEnabled = apperrors.Conflict("two-factor authentication is already enabled")
+	// ErrMFANotEnabled is returned when an operation requires confirmed two-factor authentication.
+	ErrMFANotEnabled = apperrors.BadRequest("two-factor authentication is not enabled")
+	// ErrMFANotEnrolled is returned when confirming without a pending enrollment.
+	ErrMFANotEnrolled = apperrors.BadRequest("two-factor authentication enrollment not started")
+	// ErrInvalidMFACode is returned for wrong, expired or replayed codes.
+	ErrInvalidMFACode = apperrors.Unauthorized("invalid two-factor authentication code")
+	// ErrMFATooManyAttempts is returned once too many wrong codes were entered.
+	ErrMFATooManyAttempts = apperrors.New(apperrors.ErrTooManyRequests, "too many invalid two-factor authentication codes")
+)
+
+// MFAService manages TOTP enrollment, verification and recovery codes
+type MFAService interface {
+	Enroll(userID int) (dto.MFAEnrollmentDTO, error)
+	Confirm(userID int, code string) (dto.RecoveryCodesDTO, error)
+	Disable(userID int, code string) error
+	RegenerateRecoveryCodes(userID int, code string) (dto.RecoveryCodesDTO, error)
+	IsEnabled(userID int) (bool, error)
+	Verify(userID int, code string) error
+}
+
+// MFAServiceImpl implements MFAService
+type MFAServiceImpl struct {
+	mfaRepo  repositories.MFARepository
+	userRepo repositories.UserRepository
+	issuer   string
+	now      func() time.Time
+}
+
+// NewMFAService creates a new instance of MFAService
+func NewMFAService(mfaRepo repositories.MFARepository, userRepo repositories.UserRepository, issuer string) MFAService {
+	return &MFAServiceImpl{
+		mfaRepo:  mfaRepo,
+		userRepo: userRepo,
+		issuer:   issuer,
+		now:      time.Now,
+	}
+}
+
+// Enroll starts TOTP enrollment and returns the secret as an otpauth:// URI.
+// Starting again before confirming replaces the pending secret.
+func (s *MFAServiceImpl) Enroll(userID int) (dto.MFAEnrollmentDTO, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return dto.MFAEnrollmentDTO{}, err
+	}
+
+	existing, err := s.mfaRepo.GetByUserID(userID)
+	if err == nil && existing.ConfirmedAt != nil {
+		return dto.MFAEnrollmentDTO{}, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return dto.MFAEnrollmentDTO{}, err
+	}
+	if err := s.mfaRepo.Save(models.UserMFA{UserID: userID, Secret: secret}); err != nil {
+		return dto.MFAEnrollmentDTO{}, err
+	}
+
+	return dto.MFAEnrollmentDTO{
+		Secret:     secret,
+		OTPAuthURL: utils.TOTPAuthURL(s.issuer, user.Username, secret),
+	}, nil
+}
+
+// Confirm activates a pending enrollment with a TOTP code and returns recovery codes
+func (s *MFAServiceImpl) Confirm(userID int, code string) (dto.RecoveryCodesDTO, error) {
+	mfa, err := s.mfaRepo.GetByUserID(userID)
+	if err != nil {
+		return dto.RecoveryCodesDTO{}, ErrMFANotEnrolled
+	}
+	if mfa.ConfirmedAt != nil {
+		return dto.RecoveryCodesDTO{}, ErrMFAAlreadyEnabled
+	}
+
+	if err := s.verifyTOTP(mfa, code); err != nil {
+		return dto.RecoveryCodesDTO{}, err
+	}
+	if err := s.mfaRepo.Confirm(userID, s.now()); err != nil {
+		return dto.RecoveryCodesDTO{}, err
+	}
+	return s.generateRecoveryCodes(userID)
+}
+
+// Disable removes two-factor authentication after checking a valid code
+func (s *MFAServiceImpl) Disable(userID int, code string) error {
+	if err := s.Verify(userID, code); err != nil {
+		return err
+	}
+	return s.mfaRepo.Delete(userID)
+}
+
+// RegenerateRecoveryCodes replaces every recovery code after checking a valid code
+func (s *MFAServiceImpl) RegenerateRecoveryCodes(userID int, code string) (dto.RecoveryCodesDTO, error) {
+	if err := s.Verify(userID, code); err != nil {
+		return dto.RecoveryCodesDTO{}, err
+	}
+	return s.generateRecoveryCodes(userID)
+}
+
+// IsEnabled reports whether the user has confirmed two-factor authentication
+func (s *MFAServiceImpl) IsEnabled(userID int) (bool, error) {
+	mfa, err := s.mfaRepo.GetByUserID(userID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return mfa.ConfirmedAt != nil, nil
+}
+
+// Verify accepts either a TOTP code or an unused recovery code. After
+// maxMFAAttempts wrong codes every code, even a valid one, is refused with
+// ErrMFATooManyAttempts for mfaLockoutDuration. The counter is only reset by
+// a valid code, so each wrong code after a lock expires locks again.
+func (s *MFAServiceImpl) Verify(userID int, code string) error {
+	mfa, err := s.mfaRepo.GetByUserID(userID)
+	if err != nil || mfa.ConfirmedAt == nil {
+		return ErrMFANotEnabled
+	}
+	now := s.now()
+	if mfa.LockedUntil != nil && now.Before(*mfa.LockedUntil) {
+		return ErrMFATooManyAttempts
+	}
+
+	if len(code) == utils.TOTPDigits {
+		err = s.verifyTOTP(mfa, code)
+	} else {
+		err = s.verifyRecoveryCode(userID, code)
+	}
+	if !errors.Is(err, ErrInvalidMFACode) {
+		return err
+	}
+
+	failures, recordErr := s.mfaRepo.RecordFailure(userID)
+	if recordErr != nil {
+		return recordErr
+	}
+	if failures >= maxMFAAttempts {
+		if err := s.mfaRepo.Lock(userID, now.Add(mfaLockoutDuration)); err != nil {
+			return err
+		}
+		return ErrMFATooManyAttempts
+	}
+	return ErrInvalidMFACode
+}
+
+// verifyTOTP checks a TOTP code and rejects codes from an already used time-step
+func (s *MFAServiceImpl) verifyTOTP(mfa models.UserMFA, code string) error {
+	ok, step := utils.ValidateTOTP(mfa.Secret, code, s.now())
+	if !ok {
+		return ErrInvalidMFACode
+	}
+	advanced, err := s.mfaRepo.AdvanceStep(mfa.UserID, step)
+	if err != nil {
+		return err
+	}
+	if !advanced {
+		return ErrInvalidMFACode
+	}
+	return nil
+}
+
+// verifyRecoveryCode consumes a recovery code
+func (s *MFAServiceImpl) verifyRecoveryCode(userID int, code string) error {
+	used, err := s.mfaRepo.UseRecoveryCode(userID, utils.HashToken(utils.NormalizeRecoveryCode(code)), s.now())
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrInvalidMFACode
+	}
+	return s.mfaRepo.ResetFailures(userID)
+}
+
+// generateRecoveryCodes creates and stores a new set of recovery codes
+func (s *MFAServiceImpl) generateRecoveryCodes(userID int) (dto.RecoveryCodesDTO, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := utils.GenerateRecoveryCode()
+		if err != nil {
+			return dto.RecoveryCodesDTO{}, err
+		}
+		codes = append(codes, code)
+		hashes = append(hashes, utils.HashToken(utils.NormalizeRecoveryCode(code)))
+	}
+
+	if err := s.mfaRepo.ReplaceRecoveryCodes(userID, hashes); err != nil {
+		return dto.RecoveryCodesDTO{}, err
+	}
+	return dto.RecoveryCodesDTO{RecoveryCodes: codes}, nil
+}
@@ -0,0 +1,232 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"gin-user-app/apperrors"
+	"gin-user-app/models"
+	"gin-user-app/utils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// testTOTPSecret adalah secret RFC 6238 ("12345678901234567890") dalam base32
+const testTOTPSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// MockMFARepository adalah mock untuk MFARepository
+type MockMFARepository struct {
+	mock.Mock
+}
+
+func (m *MockMFARepository) GetByUserID(userID int) (models.UserMFA, error) {
+	args := m.Called(userID)
+	return args.Get(0).(models.UserMFA), args.Error(1)
+}
+
+func (m *MockMFARepository) Save(mfa models.UserMFA) error {
+	args := m.Called(mfa)
+	return args.Error(0)
+}
+
+func (m *MockMFARepository) Confirm(userID int, at time.Time) error {
+	args := m.Called(userID, at)
+	return args.Error(0)
+}
+
+func (m *MockMFARepository) Delete(userID int) error {
+	args := m.Called(userID)
+	return args.Error(0)
+}
+
+func (m *MockMFARepository) AdvanceStep(userID int, step int64) (bool, error) {
+	args := m.Called(userID, step)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockMFARepository) RecordFailure(userID int) (int, error) {
+	args := m.Called(userID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockMFARepository) ResetFailures(userID int) error {
+	args := m.Called(userID)
+	return args.Error(0)
+}
+
+func (m *MockMFARepository) Lock(userID int, until time.Time) error {
+	args := m.Called(userID, until)
+	return args.Error(0)
+}
+
+func (m *MockMFARepository) ReplaceRecoveryCodes(userID int, hashes []string) error {
+	args := m.Called(userID, hashes)
+	return args.Error(0)
+}
+
+func (m *MockMFARepository) UseRecoveryCode(userID int, hash string, at time.Time) (bool, error) {
+	args := m.Called(userID, hash, at)
+	return args.Bool(0), args.Error(1)
+}
+
+func newTestMFAService(mfaRepo *MockMFARepository, now time.Time) *MFAServiceImpl {
+	service := NewMFAService(mfaRepo, new(MockUserRepository), "test").(*MFAServiceImpl)
+	service.now = func() time.Time { return now }
+	return service
+}
+
+func confirmedMFA() models.UserMFA {
+	confirmedAt := time.Unix(0, 0)
+	return models.UserMFA{UserID: 1, Secret: testTOTPSecret, ConfirmedAt: &confirmedAt}
+}
+
+// TestMFAEnroll_AlreadyEnabledUnit tests that enrolling again is rejected once confirmed
+func TestMFAEnroll_AlreadyEnabledUnit(t *testing.T) {
+	mfaRepo := new(MockMFARepository)
+	service := newTestMFAService(mfaRepo, time.Now())
+	service.userRepo.(*MockUserRepository).On("GetByID", 1).Return(models.User{ID: 1, Username: "alice"}, nil)
+	mfaRepo.On("GetByUserID", 1).Return(confirmedMFA(), nil)
+
+	_, err := service.Enroll(1)
+
+	assert.ErrorIs(t, err, ErrMFAAlreadyEnabled)
+	mfaRepo.AssertNotCalled(t, "Save", mock.Anything)
+}
+
+// TestMFAConfirm_ReturnsRecoveryCodesUnit tests that confirming enrollment issues recovery codes
+func TestMFAConfirm_ReturnsRecoveryCodesUnit(t *testing.T) {
+	now := time.Now()
+	mfaRepo := new(MockMFARepository)
+	service := newTestMFAService(mfaRepo, now)
+	code, _ := utils.TOTPCode(testTOTPSecret, utils.TOTPStep(now))
+
+	mfaRepo.On("GetByUserID", 1).Return(models.UserMFA{UserID: 1, Secret: testTOTPSecret}, nil)
+	mfaRepo.On("AdvanceStep", 1, utils.TOTPStep(now)).Return(true, nil)
+	mfaRepo.On("Confirm", 1, now).Return(nil)
+	mfaRepo.On("ReplaceRecoveryCodes", 1, mock.AnythingOfType("[]string")).Return(nil)
+
+	codes, err := service.Confirm(1, code)
+
+	assert.NoError(t, err)
+	assert.Len(t, codes.RecoveryCodes, recoveryCodeCount)
+	hashes := mfaRepo.Calls[len(mfaRepo.Calls)-1].Arguments.Get(1).([]string)
+	assert.Equal(t, utils.HashToken(utils.NormalizeRecoveryCode(codes.RecoveryCodes[0])), hashes[0])
+}
+
+// TestMFAVerify_ReplayedCodeUnit tests that a code from an already used time-step is rejected
+func TestMFAVerify_ReplayedCodeUnit(t *testing.T) {
+	now := time.Now()
+	mfaRepo := new(MockMFARepository)
+	service := newTestMFAService(mfaRepo, now)
+	code, _ := utils.TOTPCode(testTOTPSecret, utils.TOTPStep(now))
+
+	mfaRepo.On("GetByUserID", 1).Return(confirmedMFA(), nil)
+	mfaRepo.On("AdvanceStep", 1, utils.TOTPStep(now)).Return(false, nil)
+	mfaRepo.On("RecordFailure", 1).Return(1, nil)
+
+	err := service.Verify(1, code)
+
+	assert.ErrorIs(t, err, ErrInvalidMFACode)
+	mfaRepo.AssertCalled(t, "RecordFailure", 1)
+}
+
+// TestMFAVerify_RecoveryCodeUnit tests that a recovery code is normalized before it is consumed
+func TestMFAVerify_RecoveryCodeUnit(t *testing.T) {
+	now := time.Now()
+	mfaRepo := new(MockMFARepository)
+	service := newTestMFAService(mfaRepo, now)
+
+	mfaRepo.On("GetByUserID", 1).Return(confirmedMFA(), nil)
+	mfaRepo.On("UseRecoveryCode", 1, utils.HashToken("abcde12345"), now).Return(true, nil)
+	mfaRepo.On("ResetFailures", 1).Return(nil)
+
+	err := service.Verify(1, "ABCDE-12345")
+
+	assert.NoError(t, err)
+}
+
+// TestMFAVerify_TooManyAttemptsUnit tests that the fifth wrong code locks verification
+func TestMFAVerify_TooManyAttemptsUnit(t *testing.T) {
+	now := time.Now()
+	mfaRepo := new(MockMFARepository)
+	service := newTestMFAService(mfaRepo, now)
+
+	mfaRepo.On("GetByUserID", 1).Return(confirmedMFA(), nil)
+	mfaRepo.On("UseRecoveryCode", 1, mock.Anything, mock.Anything).Return(false, nil)
+	mfaRepo.On("RecordFailure", 1).Return(maxMFAAttempts, nil)
+	mfaRepo.On("Lock", 1, now.Add(mfaLockoutDuration)).Return(nil)
+
+	err := service.Verify(1, "wrong-code")
+
+	assert.ErrorIs(t, err, ErrMFATooManyAttempts)
+	mfaRepo.AssertExpectations(t)
+	mfaRepo.AssertNotCalled(t, "ResetFailures", 1)
+}
+
+// TestMFAVerify_LockedRejectsLaterAttemptsUnit tests that the sixth and later
+// attempts are refused while locked, even with a valid code, and that a wrong
+// code after the lock expired locks again
+func TestMFAVerify_LockedRejectsLaterAttemptsUnit(t *testing.T) {
+	now := time.Now()
+	mfaRepo := new(MockMFARepository)
+	service := newTestMFAService(mfaRepo, now)
+	code, _ := utils.TOTPCode(testTOTPSecret, utils.TOTPStep(now))
+
+	locked := confirmedMFA()
+	lockedUntil := now.Add(time.Minute)
+	locked.LockedUntil = &lockedUntil
+	locked.FailedAttempts = maxMFAAttempts
+	mfaRepo.On("GetByUserID", 1).Return(locked, nil).Times(3)
+
+	for _, attempt := range []string{code, "000000", "ABCDE-12345"} {
+		assert.ErrorIs(t, service.Verify(1, attempt), ErrMFATooManyAttempts)
+	}
+	mfaRepo.AssertNotCalled(t, "AdvanceStep", mock.Anything, mock.Anything)
+	mfaRepo.AssertNotCalled(t, "UseRecoveryCode", mock.Anything, mock.Anything, mock.Anything)
+	mfaRepo.AssertNotCalled(t, "RecordFailure", 1)
+
+	expired := locked
+	expiredAt := now.Add(-time.Second)
+	expired.LockedUntil = &expiredAt
+	mfaRepo.On("GetByUserID", 1).Return(expired, nil).Once()
+	mfaRepo.On("UseRecoveryCode", 1, mock.Anything, now).Return(false, nil)
+	mfaRepo.On("RecordFailure", 1).Return(maxMFAAttempts+1, nil)
+	mfaRepo.On("Lock", 1, now.Add(mfaLockoutDuration)).Return(nil)
+
+	assert.ErrorIs(t, service.Verify(1, "wrong-code"), ErrMFATooManyAttempts)
+	mfaRepo.AssertExpectations(t)
+}
+
+// TestMFAVerify_NotEnabledUnit tests that an unconfirmed enrollment cannot be used to verify
+func TestMFAVerify_NotEnabledUnit(t *testing.T) {
+	mfaRepo := new(MockMFARepository)
+	service := newTestMFAService(mfaRepo, time.Now())
+	mfaRepo.On("GetByUserID", 1).Return(models.UserMFA{}, apperrors.NotFound("mfa not enrolled"))
+
+	err := service.Verify(1, "123456")
+
+	assert.ErrorIs(t, err, ErrMFANotEnabled)
+}
+
+// TestMFAIsEnabled_NotFoundByKindUnit tests that a wrapped not-found error
+// means disabled and that other errors are returned
+func TestMFAIsEnabled_NotFoundByKindUnit(t *testing.T) {
+	mfaRepo := new(MockMFARepository)
+	service := newTestMFAService(mfaRepo, time.Now())
+	mfaRepo.On("GetByUserID", 1).Return(models.UserMFA{}, fmt.Errorf("load mfa: %w", apperrors.NotFound("no enrollment")))
+	mfaRepo.On("GetByUserID", 2).Return(models.UserMFA{}, errors.New("mfa not enrolled"))
+	mfaRepo.On("GetByUserID", 3).Return(confirmedMFA(), nil)
+
+	enabled, err := service.IsEnabled(1)
+	assert.NoError(t, err)
+	assert.False(t, enabled)
+
+	_, err = service.IsEnabled(2)
+	assert.Error(t, err)
+
+	enabled, err = service.IsEnabled(3)
+	assert.NoError(t, err)
+	assert.True(t, enabled)
+}
@@ -0,0 +1,309 @@
+package services
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"gin-user-app/apperrors"
+	"gin-user-app/models"
+	"gin-user-app/repositories"
+	"gin-user-app/utils"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// breachedPrefixLength is the length of the SHA-1 prefix that names a range file
+const breachedPrefixLength = 5
+
+// ErrInvalidPassword is returned when a new password does not meet the
+// password policy. The returned error also lists the failed rule.
+var ErrInvalidPassword = apperrors.Validation("password does not meet the password policy")
+
+// PasswordPolicyConfig configures the checks on new passwords. A zero value
+// disables a check, except MinLength which falls back to utils.PasswordMinLength.
+type PasswordPolicyConfig struct {
+	MinLength int
+	MaxLength int
+	// MinClasses is the number of character classes a password needs: lower
+	// case, upper case, digits and symbols
+	MinClasses int
+	// MinScore is the minimum strength from utils.PasswordScore, 0 to 4
+	MinScore int
+	// RejectPersonalInfo rejects passwords that contain the username, the
+	// email address or its local part, or the first or last name
+	RejectPersonalInfo bool
+	// BreachedDir holds breached password range files in the format of the
+	// Have I Been Pwned range API: one file per SHA-1 prefix named for
+	// example "5BAA6.txt", with lines "SUFFIX:COUNT"
+	BreachedDir string
+	// HistorySize is the number of recent passwords, the current one
+	// included, that cannot be chosen again
+	HistorySize int
+	// MaxAge forces a password change at the next login once the password is older
+	MaxAge time.Duration
+}
+
+// PasswordPolicy decides which new passwords are acceptable
+type PasswordPolicy interface {
+	// Rules returns the checks for a new password of account. Only the
+	// username, email and names of account are used.
+	Rules(account models.User) []utils.Rule
+	// HistoryRules returns the check against the current and recent passwords
+	// of an existing user, nil when the history is disabled
+	HistoryRules(user models.User) ([]utils.Rule, error)
+	// Remember keeps the password hash of user in the history. It is called
+	// with the user as it was before the password was replaced.
+	Remember(user models.User) error
+	// Expired reports whether the password of user must be changed before the next login
+	Expired(user models.User, now time.Time) bool
+}
+
+// PasswordPolicyImpl implements PasswordPolicy
+type PasswordPolicyImpl struct {
+	cfg         PasswordPolicyConfig
+	historyRepo repositories.PasswordHistoryRepository
+	now         func() time.Time
+}
+
+// NewPasswordPolicy creates a PasswordPolicy. historyRepo may be nil when
+// HistorySize is at most 1, only the current password is compared then.
+func NewPasswordPolicy(cfg PasswordPolicyConfig, historyRepo repositories.PasswordHistoryRepository) (PasswordPolicy, error) {
+	if cfg.MinLength <= 0 {
+		cfg.MinLength = utils.PasswordMinLength
+	}
+	if cfg.MaxLength != 0 && cfg.MaxLength < cfg.MinLength {
+		return nil, fmt.Errorf("password max length %d is below the min length %d", cfg.MaxLength, cfg.MinLength)
+	}
+	if cfg.MinClasses < 0 || cfg.MinClasses > 4 {
+		return nil, fmt.Errorf("password character classes must be between 0 and 4, got %d", cfg.MinClasses)
+	}
+	if cfg.MinScore < 0 || cfg.MinScore > 4 {
+		return nil, fmt.Errorf("password score must be between 0 and 4, got %d", cfg.MinScore)
+	}
+	if cfg.HistorySize > 1 && historyRepo == nil {
+		return nil, errors.New("password history needs a history repository")
+	}
+	if cfg.BreachedDir != "" {
+		if info, err := os.Stat(cfg.BreachedDir); err != nil || !info.IsDir() {
+			return nil, fmt.Errorf("breached password directory %q is not readable", cfg.BreachedDir)
+		}
+	}
+
+	return &PasswordPolicyImpl{
+		cfg:         cfg,
+		historyRepo: historyRepo,
+		now:         time.Now,
+	}, nil
+}
+
+// Rules returns the checks in the order length, character classes, strength,
+// personal information and breached passwords
+func (p *PasswordPolicyImpl) Rules(account models.User) []utils.Rule {
+	rules := []utils.Rule{utils.String(), utils.MinLength(p.cfg.MinLength)}
+	if p.cfg.MaxLength > 0 {
+		rules = append(rules, utils.Length(p.cfg.MinLength, p.cfg.MaxLength))
+	}
+	if p.cfg.MinClasses > 0 {
+		rules = append(rules, utils.Rule{
+			Code:    "character_classes",
+			Message: "{field} must contain at least {min} of lower case letters, upper case letters, digits and symbols",
+			Params:  map[string]interface{}{"min": p.cfg.MinClasses},
+			Check:   checkPassword(func(password string) bool { return utils.PasswordClasses(password) >= p.cfg.MinClasses }),
+		})
+	}
+	if p.cfg.MinScore > 0 {
+		rules = append(rules, utils.Rule{
+			Code:    "too_weak",
+			Message: "{field} is too easy to guess",
+			Params:  map[string]interface{}{"min_score": p.cfg.MinScore},
+			Check:   checkPassword(func(password string) bool { return utils.PasswordScore(password) >= p.cfg.MinScore }),
+		})
+	}
+	if p.cfg.RejectPersonalInfo {
+		personal := personalInfo(account)
+		rules = append(rules, utils.Rule{
+			Code:    "personal_info",
+			Message: "{field} must not contain your username, email or name",
+			Check:   checkPassword(func(password string) bool { return !containsAny(password, personal) }),
+		})
+	}
+	if p.cfg.BreachedDir != "" {
+		rules = append(rules, utils.Rule{
+			Code:    "breached",
+			Message: "{field} has appeared in a data breach, choose another one",
+			Check:   checkPassword(func(password string) bool { return !p.isBreached(password) }),
+		})
+	}
+	return rules
+}
+
+// HistoryRules loads the recent password hashes of user once and returns a
+// rule that rejects a password matching any of them
+func (p *PasswordPolicyImpl) HistoryRules(user models.User) ([]utils.Rule, error) {
+	if p.cfg.HistorySize < 1 || user.ID == 0 {
+		return nil, nil
+	}
+
+	hashes := []string{user.Password}
+	if p.cfg.HistorySize > 1 {
+		entries, err := p.historyRepo.Recent(user.ID, p.cfg.HistorySize-1)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			hashes = append(hashes, entry.PasswordHash)
+		}
+	}
+
+	return []utils.Rule{{
+		Code:    "reused",
+		Message: "{field} must not be one of your last {count} passwords",
+		Params:  map[string]interface{}{"count": p.cfg.HistorySize},
+		Check: checkPassword(func(password string) bool {
+			for _, hash := range hashes {
+				if hash != "" && utils.CheckPasswordHash(password, hash) {
+					return false
+				}
+			}
+			return true
+		}),
+	}}, nil
+}
+
+// Remember stores the current hash of user and drops entries that are older
+// than the history. The current password itself is compared from the users
+// table, so the history keeps HistorySize-1 entries.
+func (p *PasswordPolicyImpl) Remember(user models.User) error {
+	if p.cfg.HistorySize < 2 || user.ID == 0 || user.Password == "" {
+		return nil
+	}
+	if err := p.historyRepo.Add(models.PasswordHistory{
+		UserID:       user.ID,
+		PasswordHash: user.Password,
+		CreatedAt:    p.now(),
+	}); err != nil {
+		return err
+	}
+	return p.historyRepo.Prune(user.ID, p.cfg.HistorySize-1)
+}
+
+// Expired reports whether the password is older than MaxAge. Users without a
+// recorded change time never expire.
+func (p *PasswordPolicyImpl) Expired(user models.User, now time.Time) bool {
+	if p.cfg.MaxAge <= 0 || user.PasswordChangedAt == nil {
+		return false
+	}
+	return !now.Before(user.PasswordChangedAt.Add(p.cfg.MaxAge))
+}
+
+// isBreached looks the password up in the range file of its SHA-1 prefix.
+// A missing file means no breached password has that prefix. Read errors let
+// the password through, an unreadable list must not block password changes.
+func (p *PasswordPolicyImpl) isBreached(password string) bool {
+	sum := sha1.Sum([]byte(password))
+	digest := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := digest[:breachedPrefixLength], digest[breachedPrefixLength:]
+
+	file, err := os.Open(filepath.Join(p.cfg.BreachedDir, prefix+".txt"))
+	if errors.Is(err, os.ErrNotExist) {
+		return false
+	}
+	if err != nil {
+		log.Printf("Failed to read breached password range %s: %v", prefix, err)
+		return false
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		hashSuffix, count, ok := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if !ok || !strings.EqualFold(hashSuffix, suffix) {
+			continue
+		}
+		// Padded responses contain fake suffixes with a count of 0
+		occurrences, err := strconv.Atoi(count)
+		return err != nil || occurrences > 0
+	}
+	if err := scanner.Err(); err != nil {
+		log.Printf("Failed to read breached password range %s: %v", prefix, err)
+	}
+	return false
+}
+
+// checkPassword turns a check on a password string into a rule check
+func checkPassword(check func(password string) bool) func(value interface{}) bool {
+	return func(value interface{}) bool {
+		password, ok := value.(string)
+		return !ok || check(password)
+	}
+}
+
+// personalInfo returns the lower-cased parts of account a password must not
+// contain. Parts shorter than 3 characters are skipped, they match too often.
+func personalInfo(account models.User) []string {
+	candidates := []string{account.Username, account.Email, account.FirstName, account.LastName}
+	if local, _, ok := strings.Cut(account.Email, "@"); ok {
+		candidates = append(candidates, local)
+	}
+
+	var parts []string
+	for _, candidate := range candidates {
+		candidate = strings.ToLower(strings.TrimSpace(candidate))
+		if len([]rune(candidate)) >= 3 {
+			parts = append(parts, candidate)
+		}
+	}
+	return parts
+}
+
+// containsAny reports whether password contains one of the lower-cased parts, ignoring case
+func containsAny(password string, parts []string) bool {
+	password = strings.ToLower(password)
+	for _, part := range parts {
+		if strings.Contains(password, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// passwordRules returns the policy checks for a new password of account, or
+// only the minimum length when no policy is configured
+func passwordRules(policy PasswordPolicy, account models.User) []utils.Rule {
+	if policy == nil {
+		return []utils.Rule{utils.String(), utils.MinLength(utils.PasswordMinLength)}
+	}
+	return policy.Rules(account)
+}
+
+// validateNewPassword checks a password chosen by user in a single-field form
+// such as change or reset password, including the password history.
+func validateNewPassword(policy PasswordPolicy, field string, user models.User, password string) error {
+	rules := append([]utils.Rule{utils.Required()}, passwordRules(policy, user)...)
+	if policy != nil {
+		history, err := policy.HistoryRules(user)
+		if err != nil {
+			return err
+		}
+		rules = append(rules, history...)
+	}
+	err := utils.RuleSet{{Field: field, Label: "password", Rules: rules}}.Validate(map[string]interface{}{field: password})
+	return withCause(err, ErrInvalidPassword)
+}
+
+// rememberPassword keeps the replaced password of user in the history. The
+// new password is already stored, so failures are only logged.
+func rememberPassword(policy PasswordPolicy, user models.User) {
+	if policy == nil {
+		return
+	}
+	if err := policy.Remember(user); err != nil {
+		log.Printf("Failed to store password history for user %d: %v", user.ID, err)
+	}
+}
@@ -0,0 +1,133 @@
+package services
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"gin-user-app/apperrors"
+	"gin-user-app/models"
+	"gin-user-app/utils"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockPasswordHistoryRepository adalah mock untuk PasswordHistoryRepository
+type MockPasswordHistoryRepository struct {
+	mock.Mock
+}
+
+func (m *MockPasswordHistoryRepository) Add(entry models.PasswordHistory) error {
+	args := m.Called(entry)
+	return args.Error(0)
+}
+
+func (m *MockPasswordHistoryRepository) Recent(userID int, limit int) ([]models.PasswordHistory, error) {
+	args := m.Called(userID, limit)
+	return args.Get(0).([]models.PasswordHistory), args.Error(1)
+}
+
+func (m *MockPasswordHistoryRepository) Prune(userID int, keep int) error {
+	args := m.Called(userID, keep)
+	return args.Error(0)
+}
+
+// checkPasswordRules validates password against rules and returns the failed rule code
+func checkPasswordRules(rules []utils.Rule, password string) string {
+	err := utils.RuleSet{{Field: "password", Rules: rules}}.Validate(map[string]interface{}{"password": password})
+	if fields := apperrors.Fields(err); len(fields) > 0 {
+		return fields[0].Code
+	}
+	return ""
+}
+
+// TestPasswordPolicy_StrengthAndPersonalInfoUnit tests length, character classes, score and personal information
+func TestPasswordPolicy_StrengthAndPersonalInfoUnit(t *testing.T) {
+	policy, err := NewPasswordPolicy(PasswordPolicyConfig{
+		MinLength:          10,
+		MaxLength:          20,
+		MinClasses:         3,
+		MinScore:           3,
+		RejectPersonalInfo: true,
+	}, nil)
+	assert.NoError(t, err)
+	rules := policy.Rules(models.User{Username: "alice", Email: "a.smith@example.com", FirstName: "Al", LastName: "Smith"})
+
+	assert.Equal(t, "min_length", checkPasswordRules(rules, "Sh0rt!"))
+	assert.Equal(t, "length", checkPasswordRules(rules, "Way-too-long-passw0rd!"))
+	assert.Equal(t, "character_classes", checkPasswordRules(rules, "alllowercase1"))
+	assert.Equal(t, "too_weak", checkPasswordRules(rules, "Aaaaaaaaaa1"))
+	assert.Equal(t, "personal_info", checkPasswordRules(rules, "Alice-2024-xq"))
+	assert.Equal(t, "personal_info", checkPasswordRules(rules, "x-SMITH-2024q"))
+	// Parts shorter than 3 characters, such as the first name "Al", are ignored
+	assert.Equal(t, "", checkPasswordRules(rules, "Alpine-2024-xq"))
+
+	_, err = NewPasswordPolicy(PasswordPolicyConfig{MinLength: 12, MaxLength: 10}, nil)
+	assert.Error(t, err)
+	_, err = NewPasswordPolicy(PasswordPolicyConfig{HistorySize: 3}, nil)
+	assert.Error(t, err)
+}
+
+// TestPasswordPolicy_BreachedRangeFilesUnit tests lookups in HIBP range files, including padding entries
+func TestPasswordPolicy_BreachedRangeFilesUnit(t *testing.T) {
+	dir := t.TempDir()
+	range5 := func(password string) (string, string) {
+		sum := sha1.Sum([]byte(password))
+		digest := strings.ToUpper(hex.EncodeToString(sum[:]))
+		return digest[:5], digest[5:]
+	}
+	prefix, suffix := range5("password123")
+	padPrefix, padSuffix := range5("padded-entry")
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, prefix+".txt"), []byte("0000000000000000000000000000000000A:3\r\n"+strings.ToLower(suffix)+":251682\r\n"), 0o600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, padPrefix+".txt"), []byte(padSuffix+":0\n"), 0o600))
+
+	policy, err := NewPasswordPolicy(PasswordPolicyConfig{BreachedDir: dir}, nil)
+	assert.NoError(t, err)
+	rules := policy.Rules(models.User{})
+
+	assert.Equal(t, "breached", checkPasswordRules(rules, "password123"))
+	assert.Equal(t, "", checkPasswordRules(rules, "padded-entry"))
+	assert.Equal(t, "", checkPasswordRules(rules, "not-in-any-range"))
+
+	_, err = NewPasswordPolicy(PasswordPolicyConfig{BreachedDir: filepath.Join(dir, "missing")}, nil)
+	assert.Error(t, err)
+}
+
+// TestPasswordPolicy_HistoryAndMaxAgeUnit tests that recent passwords are rejected and old ones expire
+func TestPasswordPolicy_HistoryAndMaxAgeUnit(t *testing.T) {
+	current, err := utils.HashPassword("current-password")
+	assert.NoError(t, err)
+	previous, err := utils.HashPassword("previous-password")
+	assert.NoError(t, err)
+
+	historyRepo := new(MockPasswordHistoryRepository)
+	historyRepo.On("Recent", 1, 2).Return([]models.PasswordHistory{{UserID: 1, PasswordHash: previous}}, nil)
+	historyRepo.On("Add", mock.MatchedBy(func(entry models.PasswordHistory) bool {
+		return entry.UserID == 1 && entry.PasswordHash == current
+	})).Return(nil)
+	historyRepo.On("Prune", 1, 2).Return(nil)
+
+	policy, err := NewPasswordPolicy(PasswordPolicyConfig{HistorySize: 3, MaxAge: 90 * 24 * time.Hour}, historyRepo)
+	assert.NoError(t, err)
+	user := models.User{ID: 1, Password: current}
+
+	rules, err := policy.HistoryRules(user)
+	assert.NoError(t, err)
+	assert.Equal(t, "reused", checkPasswordRules(rules, "current-password"))
+	assert.Equal(t, "reused", checkPasswordRules(rules, "previous-password"))
+	assert.Equal(t, "", checkPasswordRules(rules, "brand-new-password"))
+
+	assert.NoError(t, policy.Remember(user))
+	historyRepo.AssertExpectations(t)
+
+	now := time.Now()
+	changed := now.Add(-89 * 24 * time.Hour)
+	assert.False(t, policy.Expired(models.User{PasswordChangedAt: &changed}, now))
+	changed = now.Add(-90 * 24 * time.Hour)
+	assert.True(t, policy.Expired(models.User{PasswordChangedAt: &changed}, now))
+	assert.False(t, policy.Expired(models.User{}, now))
+}
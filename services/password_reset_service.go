@@ -0,0 +1,134 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"gin-user-app/apperrors"
+	"gin-user-app/mailer"
+	"gin-user-app/models"
+	"gin-user-app/repositories"
+	"gin-user-app/utils"
+	"log"
+	"strings"
+	"time"
+)
+
+// resetTokenSize is the number of random bytes in a password reset token.
+const resetTokenSize = 32
+
+var (
+	// ErrInvalidResetToken is returned for unknown, expired or already used reset tokens.
+	ErrInvalidResetToken = apperrors.BadRequest("invalid or expired password reset token")
+)
+
+// PasswordResetService handles the forgot/reset password flow
+type PasswordResetService interface {
+	RequestReset(email string) error
+	ResetPassword(token, newPassword string) error
+}
+
+// PasswordResetServiceImpl implements PasswordResetService
+type PasswordResetServiceImpl struct {
+	resetRepo   repositories.PasswordResetRepository
+	userRepo    repositories.UserRepository
+	authService *AuthService
+	passwords   PasswordPolicy
+	mailer      mailer.Mailer
+	resetURL    string
+	ttl         time.Duration
+	now         func() time.Time
+}
+
+// NewPasswordResetService creates a new instance of PasswordResetService.
+// resetURL is the page that receives the token as the "token" query parameter.
+// passwords may be nil to only check the minimum password length.
+func NewPasswordResetService(resetRepo repositories.PasswordResetRepository, userRepo repositories.UserRepository, authService *AuthService, passwords PasswordPolicy, m mailer.Mailer, resetURL string, ttl time.Duration) PasswordResetService {
+	return &PasswordResetServiceImpl{
+		resetRepo:   resetRepo,
+		userRepo:    userRepo,
+		authService: authService,
+		passwords:   passwords,
+		mailer:      m,
+		resetURL:    resetURL,
+		ttl:         ttl,
+		now:         time.Now,
+	}
+}
+
+// RequestReset emails a reset link when the address belongs to a user. Unknown
+// addresses and mail delivery failures do not return an error so the endpoint
+// does not reveal which emails are registered. Older unused tokens stop working.
+func (s *PasswordResetServiceImpl) RequestReset(email string) error {
+	user, err := s.userRepo.FindByEmail(strings.TrimSpace(email))
+	if err != nil {
+		if errors.Is(err, repositories.ErrUserNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	token, err := utils.GenerateOpaqueToken(resetTokenSize)
+	if err != nil {
+		return err
+	}
+
+	now := s.now()
+	if err := s.resetRepo.InvalidateForUser(user.ID, now); err != nil {
+		return err
+	}
+	if err := s.resetRepo.Create(models.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: utils.HashToken(token),
+		ExpiresAt: now.Add(s.ttl),
+	}); err != nil {
+		return err
+	}
+
+	// Kegagalan kirim email hanya dicatat agar respons tetap sama untuk email terdaftar maupun tidak
+	err = s.mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\nUse the link below to choose a new password. It expires in %s and can only be used once.\n\n%s\n\nIf you did not ask for a password reset you can ignore this email.\n",
+			user.Username, s.ttl, appendToken(s.resetURL, token)),
+	})
+	if err != nil {
+		log.Println("Failed to send password reset email:", err)
+	}
+	return nil
+}
+
+// ResetPassword sets a new password with a reset token and logs the user out
+// everywhere. The new password must meet the password policy, including the history.
+func (s *PasswordResetServiceImpl) ResetPassword(token, newPassword string) error {
+	resetToken, err := s.resetRepo.FindByHash(utils.HashToken(token))
+	if err != nil {
+		return ErrInvalidResetToken
+	}
+	now := s.now()
+	if resetToken.UsedAt != nil || now.After(resetToken.ExpiresAt) {
+		return ErrInvalidResetToken
+	}
+
+	user, err := s.userRepo.GetByID(resetToken.UserID)
+	if err != nil {
+		return ErrInvalidResetToken
+	}
+	if err := validateNewPassword(s.passwords, "new_password", user, newPassword); err != nil {
+		return err
+	}
+
+	hash, err := utils.HashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+	consumed, err := s.resetRepo.Consume(resetToken, hash, now)
+	if err != nil {
+		return err
+	}
+	if !consumed {
+		return ErrInvalidResetToken
+	}
+	rememberPassword(s.passwords, user)
+
+	return s.authService.LogoutAll(resetToken.UserID)
+}
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"gin-user-app/apperrors"
+	"gin-user-app/mailer"
+	"gin-user-app/models"
+	"gin-user-app/repositories"
+	"gin-user-app/utils"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockPasswordResetRepository adalah mock untuk PasswordResetRepository
+type MockPasswordResetRepository struct {
+	mock.Mock
+}
+
+func (m *MockPasswordResetRepository) Create(token models.PasswordResetToken) error {
+	args := m.Called(token)
+	return args.Error(0)
+}
+
+func (m *MockPasswordResetRepository) FindByHash(hash string) (models.PasswordResetToken, error) {
+	args := m.Called(hash)
+	return args.Get(0).(models.PasswordResetToken), args.Error(1)
+}
+
+func (m *MockPasswordResetRepository) InvalidateForUser(userID int, at time.Time) error {
+	args := m.Called(userID, at)
+	return args.Error(0)
+}
+
+func (m *MockPasswordResetRepository) Consume(token models.PasswordResetToken, passwordHash string, at time.Time) (bool, error) {
+	args := m.Called(token, passwordHash, at)
+	return args.Bool(0), args.Error(1)
+}
+
+func newTestPasswordResetService(resetRepo *MockPasswordResetRepository, userRepo *MockUserRepository, refreshRepo *MockRefreshTokenRepository, m mailer.Mailer) PasswordResetService {
+	return NewPasswordResetService(resetRepo, userRepo, newTestAuthService(refreshRepo), nil, m, "http://localhost/reset-password", 30*time.Minute)
+}
+
+// TestRequestReset_UnknownEmailUnit tests that unknown emails are accepted silently
+func TestRequestReset_UnknownEmailUnit(t *testing.T) {
+	userRepo := new(MockUserRepository)
+	resetRepo := new(MockPasswordResetRepository)
+	outbox := mailer.NewMemoryMailer("no-reply@test")
+	userRepo.On("FindByEmail", "nobody@gmail.com").Return(models.User{}, repositories.ErrUserNotFound)
+
+	err := newTestPasswordResetService(resetRepo, userRepo, nil, outbox).RequestReset("nobody@gmail.com")
+
+	assert.NoError(t, err)
+	assert.Empty(t, outbox.Messages())
+	resetRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+// TestRequestReset_SendsHashedTokenUnit tests that only the hash of the emailed token is stored
+func TestRequestReset_SendsHashedTokenUnit(t *testing.T) {
+	userRepo := new(MockUserRepository)
+	resetRepo := new(MockPasswordResetRepository)
+	outbox := mailer.NewMemoryMailer("no-reply@test")
+	userRepo.On("FindByEmail", "alice@gmail.com").Return(models.User{ID: 7, Username: "alice", Email: "alice@gmail.com"}, nil)
+	resetRepo.On("InvalidateForUser", 7, mock.Anything).Return(nil)
+	resetRepo.On("Create", mock.Anything).Return(nil)
+
+	err := newTestPasswordResetService(resetRepo, userRepo, nil, outbox).RequestReset("alice@gmail.com")
+
+	assert.NoError(t, err)
+	messages := outbox.Messages()
+	assert.Len(t, messages, 1)
+	assert.Equal(t, "alice@gmail.com", messages[0].To)
+
+	body := messages[0].Body
+	start := strings.Index(body, "token=") + len("token=")
+	token := strings.Fields(body[start:])[0]
+	stored := resetRepo.Calls[1].Arguments.Get(0).(models.PasswordResetToken)
+	assert.Equal(t, utils.HashToken(token), stored.TokenHash)
+}
+
+// TestResetPassword_ExpiredTokenUnit tests that expired tokens are rejected
+func TestResetPassword_ExpiredTokenUnit(t *testing.T) {
+	resetRepo := new(MockPasswordResetRepository)
+	resetRepo.On("FindByHash", utils.HashToken("expired")).Return(models.PasswordResetToken{ID: 1, UserID: 7, ExpiresAt: time.Now().Add(-time.Minute)}, nil)
+
+	err := newTestPasswordResetService(resetRepo, nil, nil, nil).ResetPassword("expired", "newpassword123")
+
+	assert.ErrorIs(t, err, ErrInvalidResetToken)
+	resetRepo.AssertNotCalled(t, "Consume", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// TestResetPassword_AlreadyUsedUnit tests that a token loses a concurrent race only once
+func TestResetPassword_AlreadyUsedUnit(t *testing.T) {
+	resetRepo := new(MockPasswordResetRepository)
+	token := models.PasswordResetToken{ID: 1, UserID: 7, ExpiresAt: time.Now().Add(time.Minute)}
+	resetRepo.On("FindByHash", utils.HashToken("raced")).Return(token, nil)
+	resetRepo.On("Consume", token, mock.Anything, mock.Anything).Return(false, nil)
+	userRepo := new(MockUserRepository)
+	userRepo.On("GetByID", 7).Return(models.User{ID: 7, Username: "alice"}, nil)
+
+	err := newTestPasswordResetService(resetRepo, userRepo, nil, nil).ResetPassword("raced", "newpassword123")
+
+	assert.ErrorIs(t, err, ErrInvalidResetToken)
+}
+
+// TestResetPassword_LogsOutEverywhereUnit tests that a successful reset revokes every session
+func TestResetPassword_LogsOutEverywhereUnit(t *testing.T) {
+	resetRepo := new(MockPasswordResetRepository)
+	refreshRepo := new(MockRefreshTokenRepository)
+	token := models.PasswordResetToken{ID: 1, UserID: 7, ExpiresAt: time.Now().Add(time.Minute)}
+	resetRepo.On("FindByHash", utils.HashToken("valid")).Return(token, nil)
+	resetRepo.On("Consume", token, mock.Anything, mock.Anything).Return(true, nil)
+	refreshRepo.On("RevokeAllForUser", 7, mock.Anything).Return(nil)
+	userRepo := new(MockUserRepository)
+	userRepo.On("GetByID", 7).Return(models.User{ID: 7, Username: "alice"}, nil)
+
+	err := newTestPasswordResetService(resetRepo, userRepo, refreshRepo, nil).ResetPassword("valid", "newpassword123")
+
+	assert.NoError(t, err)
+	hash := resetRepo.Calls[1].Arguments.String(1)
+	assert.True(t, utils.CheckPasswordHash("newpassword123", hash))
+	refreshRepo.AssertCalled(t, "RevokeAllForUser", 7, mock.Anything)
+}
+
+// TestResetPassword_ShortPasswordUnit tests that the new password must meet the minimum length
+func TestResetPassword_ShortPasswordUnit(t *testing.T) {
+	resetRepo := new(MockPasswordResetRepository)
+	resetRepo.On("FindByHash", utils.HashToken("valid")).Return(models.PasswordResetToken{ID: 1, UserID: 7, ExpiresAt: time.Now().Add(time.Minute)}, nil)
+	userRepo := new(MockUserRepository)
+	userRepo.On("GetByID", 7).Return(models.User{ID: 7, Username: "alice"}, nil)
+
+	err := newTestPasswordResetService(resetRepo, userRepo, nil, nil).ResetPassword("valid", "short")
+
+	assert.ErrorIs(t, err, ErrInvalidPassword)
+	assert.Equal(t, "new_password", apperrors.Fields(err)[0].Field)
+	resetRepo.AssertNotCalled(t, "Consume", mock.Anything, mock.Anything, mock.Anything)
+}
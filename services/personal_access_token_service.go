@@ -0,0 +1,211 @@
+package services
+
+import (
+	"gin-user-app/apperrors"
+	"gin-user-app/dto"
+	"gin-user-app/models"
+	"gin-user-app/repositories"
+	"gin-user-app/utils"
+	"log"
+	"strings"
+	"time"
+)
+
+const (
+	// PersonalAccessTokenPrefix starts every personal access token so that
+	// AuthMiddleware and secret scanners can tell them apart from JWTs.
+	PersonalAccessTokenPrefix = "gua_pat_"
+	// personalAccessTokenSize is the number of random bytes after the prefix.
+	personalAccessTokenSize = 32
+	// personalAccessTokenDisplayLength is how much of the token is kept to identify it in listings.
+	personalAccessTokenDisplayLength = len(PersonalAccessTokenPrefix) + 4
+	// personalAccessTokenTouchInterval limits how often last-used time is written.
+	personalAccessTokenTouchInterval = time.Minute
+)
+
+var (
+	// ErrInvalidPersonalAccessToken is returned for unknown, expired or revoked personal access tokens.
+	ErrInvalidPersonalAccessToken = apperrors.Unauthorized("invalid personal access token")
+	// ErrPersonalAccessTokenNotFound is returned when revoking a token the user does not own.
+	ErrPersonalAccessTokenNotFound = apperrors.NotFound("personal access token not found")
+	// ErrInvalidScope is returned when a requested scope is not a permission the user has.
+	ErrInvalidScope = apperrors.Invalid("scopes", "scope is not a permission of the user")
+	// ErrInvalidTokenLifetime is returned when the requested expiry exceeds the allowed maximum.
+	ErrInvalidTokenLifetime = apperrors.Invalid("expires_in_days", "token lifetime exceeds the allowed maximum")
+)
+
+// PersonalAccessTokenGrant is the access given by a valid personal access token
+type PersonalAccessTokenGrant struct {
+	TokenID     int
+	UserID      int
+	Roles       []string
+	Permissions []string
+}
+
+// PersonalAccessTokenService manages personal access tokens for machine clients
+type PersonalAccessTokenService interface {
+	Create(userID int, req dto.CreatePersonalAccessTokenDTO) (dto.CreatedPersonalAccessTokenDTO, error)
+	List(userID int) ([]dto.PersonalAccessTokenDTO, error)
+	Revoke(userID, tokenID int) error
+	RevokeAll(userID int) error
+	Authenticate(token, ip string) (PersonalAccessTokenGrant, error)
+}
+
+// PersonalAccessTokenServiceImpl implements PersonalAccessTokenService
+type PersonalAccessTokenServiceImpl struct {
+	tokenRepo   repositories.PersonalAccessTokenRepository
+	roleService RoleService
+	maxLifetime time.Duration
+	now         func() time.Time
+}
+
+// NewPersonalAccessTokenService creates a new instance of PersonalAccessTokenService
+func NewPersonalAccessTokenService(tokenRepo repositories.PersonalAccessTokenRepository, roleService RoleService, maxLifetime time.Duration) PersonalAccessTokenService {
+	return &PersonalAccessTokenServiceImpl{
+		tokenRepo:   tokenRepo,
+		roleService: roleService,
+		maxLifetime: maxLifetime,
+		now:         time.Now,
+	}
+}
+
+// IsPersonalAccessToken reports whether a bearer token looks like a personal access token
+func IsPersonalAccessToken(token string) bool {
+	return strings.HasPrefix(token, PersonalAccessTokenPrefix)
+}
+
+// Create issues a new token. The value is only returned here; the database
+// keeps its hash.
+func (s *PersonalAccessTokenServiceImpl) Create(userID int, req dto.CreatePersonalAccessTokenDTO) (dto.CreatedPersonalAccessTokenDTO, error) {
+	lifetime := time.Duration(req.ExpiresInDays) * 24 * time.Hour
+	if s.maxLifetime > 0 && lifetime > s.maxLifetime {
+		return dto.CreatedPersonalAccessTokenDTO{}, ErrInvalidTokenLifetime
+	}
+
+	_, permissions, err := s.roleService.ResolveAccess(userID)
+	if err != nil {
+		return dto.CreatedPersonalAccessTokenDTO{}, err
+	}
+	scopes := uniqueStrings(req.Scopes)
+	for _, scope := range scopes {
+		if !containsString(permissions, scope) {
+			return dto.CreatedPersonalAccessTokenDTO{}, ErrInvalidScope
+		}
+	}
+
+	secret, err := utils.GenerateOpaqueToken(personalAccessTokenSize)
+	if err != nil {
+		return dto.CreatedPersonalAccessTokenDTO{}, err
+	}
+	value := PersonalAccessTokenPrefix + secret
+
+	created, err := s.tokenRepo.Create(models.PersonalAccessToken{
+		UserID:    userID,
+		Name:      strings.TrimSpace(req.Name),
+		Prefix:    value[:personalAccessTokenDisplayLength],
+		TokenHash: utils.HashToken(value),
+		Scopes:    scopes,
+		ExpiresAt: s.now().Add(lifetime),
+	})
+	if err != nil {
+		return dto.CreatedPersonalAccessTokenDTO{}, err
+	}
+
+	return dto.CreatedPersonalAccessTokenDTO{
+		PersonalAccessTokenDTO: toPersonalAccessTokenDTO(created),
+		Token:                  value,
+	}, nil
+}
+
+// List returns the tokens of a user that are not revoked
+func (s *PersonalAccessTokenServiceImpl) List(userID int) ([]dto.PersonalAccessTokenDTO, error) {
+	tokens, err := s.tokenRepo.ListByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	tokenDTOs := make([]dto.PersonalAccessTokenDTO, 0, len(tokens))
+	for _, token := range tokens {
+		tokenDTOs = append(tokenDTOs, toPersonalAccessTokenDTO(token))
+	}
+	return tokenDTOs, nil
+}
+
+// Revoke revokes one of the user's tokens
+func (s *PersonalAccessTokenServiceImpl) Revoke(userID, tokenID int) error {
+	revoked, err := s.tokenRepo.Revoke(userID, tokenID, s.now())
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrPersonalAccessTokenNotFound
+	}
+	return nil
+}
+
+// RevokeAll revokes every token of the user
+func (s *PersonalAccessTokenServiceImpl) RevokeAll(userID int) error {
+	return s.tokenRepo.RevokeAllForUser(userID, s.now())
+}
+
+// Authenticate resolves a token to its owner. The granted permissions are the
+// token's scopes that the owner still has, so removing a role also narrows
+// existing tokens.
+func (s *PersonalAccessTokenServiceImpl) Authenticate(token, ip string) (PersonalAccessTokenGrant, error) {
+	if !IsPersonalAccessToken(token) {
+		return PersonalAccessTokenGrant{}, ErrInvalidPersonalAccessToken
+	}
+
+	stored, err := s.tokenRepo.FindByHash(utils.HashToken(token))
+	if err != nil {
+		return PersonalAccessTokenGrant{}, ErrInvalidPersonalAccessToken
+	}
+	now := s.now()
+	if stored.RevokedAt != nil || !now.Before(stored.ExpiresAt) {
+		return PersonalAccessTokenGrant{}, ErrInvalidPersonalAccessToken
+	}
+
+	roles, permissions, err := s.roleService.ResolveAccess(stored.UserID)
+	if err != nil {
+		return PersonalAccessTokenGrant{}, err
+	}
+	granted := make([]string, 0, len(stored.Scopes))
+	for _, scope := range stored.Scopes {
+		if containsString(permissions, scope) {
+			granted = append(granted, scope)
+		}
+	}
+
+	// Last-used tracking must not fail the request
+	if err := s.tokenRepo.Touch(stored.ID, now, ip, now.Add(-personalAccessTokenTouchInterval)); err != nil {
+		log.Printf("Failed to record use of personal access token %d: %v", stored.ID, err)
+	}
+
+	return PersonalAccessTokenGrant{
+		TokenID:     stored.ID,
+		UserID:      stored.UserID,
+		Roles:       roles,
+		Permissions: granted,
+	}, nil
+}
+
+func toPersonalAccessTokenDTO(token models.PersonalAccessToken) dto.PersonalAccessTokenDTO {
+	return dto.PersonalAccessTokenDTO{
+		ID:         token.ID,
+		Name:       token.Name,
+		Prefix:     token.Prefix,
+		Scopes:     token.Scopes,
+		ExpiresAt:  token.ExpiresAt,
+		LastUsedAt: token.LastUsedAt,
+		LastUsedIP: token.LastUsedIP,
+		CreatedAt:  token.CreatedAt,
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
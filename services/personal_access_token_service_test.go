@@ -0,0 +1,159 @@
+package services
+
+import (
+	"errors"
+	"gin-user-app/dto"
+	"gin-user-app/models"
+	"gin-user-app/utils"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockPersonalAccessTokenRepository adalah mock untuk PersonalAccessTokenRepository
+type MockPersonalAccessTokenRepository struct {
+	mock.Mock
+}
+
+func (m *MockPersonalAccessTokenRepository) Create(token models.PersonalAccessToken) (models.PersonalAccessToken, error) {
+	args := m.Called(token)
+	return args.Get(0).(models.PersonalAccessToken), args.Error(1)
+}
+
+func (m *MockPersonalAccessTokenRepository) FindByHash(hash string) (models.PersonalAccessToken, error) {
+	args := m.Called(hash)
+	return args.Get(0).(models.PersonalAccessToken), args.Error(1)
+}
+
+func (m *MockPersonalAccessTokenRepository) ListByUser(userID int) ([]models.PersonalAccessToken, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]models.PersonalAccessToken), args.Error(1)
+}
+
+func (m *MockPersonalAccessTokenRepository) Revoke(userID, id int, at time.Time) (bool, error) {
+	args := m.Called(userID, id, at)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockPersonalAccessTokenRepository) RevokeAllForUser(userID int, at time.Time) error {
+	args := m.Called(userID, at)
+	return args.Error(0)
+}
+
+func (m *MockPersonalAccessTokenRepository) Touch(id int, at time.Time, ip string, staleBefore time.Time) error {
+	args := m.Called(id, at, ip, staleBefore)
+	return args.Error(0)
+}
+
+// newTestPersonalAccessTokenService returns a service for user 1 holding users:read and users:update
+func newTestPersonalAccessTokenService(repo *MockPersonalAccessTokenRepository, now time.Time) *PersonalAccessTokenServiceImpl {
+	roleRepo := new(MockRoleRepository)
+	roleRepo.On("GetUserRoles", 1).Return([]models.Role{{
+		Name: "editor",
+		Permissions: []models.Permission{
+			{Name: models.PermissionUsersRead},
+			{Name: models.PermissionUsersUpdate},
+		},
+	}}, nil)
+
+	return &PersonalAccessTokenServiceImpl{
+		tokenRepo:   repo,
+		roleService: NewRoleService(roleRepo),
+		maxLifetime: 30 * 24 * time.Hour,
+		now:         func() time.Time { return now },
+	}
+}
+
+// TestCreatePersonalAccessToken_StoresHashUnit tests that only the hash and a short prefix are stored
+func TestCreatePersonalAccessToken_StoresHashUnit(t *testing.T) {
+	now := time.Now()
+	repo := new(MockPersonalAccessTokenRepository)
+	service := newTestPersonalAccessTokenService(repo, now)
+
+	var stored models.PersonalAccessToken
+	repo.On("Create", mock.Anything).Run(func(args mock.Arguments) {
+		stored = args.Get(0).(models.PersonalAccessToken)
+	}).Return(models.PersonalAccessToken{ID: 7, Name: "ci", Prefix: "gua_pat_abcd"}, nil)
+
+	created, err := service.Create(1, dto.CreatePersonalAccessTokenDTO{Name: " ci ", Scopes: []string{"users:read", "users:read"}, ExpiresInDays: 7})
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(created.Token, PersonalAccessTokenPrefix))
+	assert.Equal(t, 7, created.ID)
+
+	assert.Equal(t, "ci", stored.Name)
+	assert.Equal(t, utils.HashToken(created.Token), stored.TokenHash)
+	assert.Equal(t, created.Token[:len(stored.Prefix)], stored.Prefix)
+	assert.Equal(t, []string{"users:read"}, stored.Scopes)
+	assert.Equal(t, now.Add(7*24*time.Hour), stored.ExpiresAt)
+}
+
+// TestCreatePersonalAccessToken_RejectsUnheldScopeUnit tests that a token cannot carry permissions the user does not have
+func TestCreatePersonalAccessToken_RejectsUnheldScopeUnit(t *testing.T) {
+	repo := new(MockPersonalAccessTokenRepository)
+	service := newTestPersonalAccessTokenService(repo, time.Now())
+
+	_, err := service.Create(1, dto.CreatePersonalAccessTokenDTO{Name: "ci", Scopes: []string{models.PermissionUsersDelete}, ExpiresInDays: 7})
+	assert.ErrorIs(t, err, ErrInvalidScope)
+
+	_, err = service.Create(1, dto.CreatePersonalAccessTokenDTO{Name: "ci", Scopes: []string{models.PermissionUsersRead}, ExpiresInDays: 31})
+	assert.ErrorIs(t, err, ErrInvalidTokenLifetime)
+	repo.AssertNotCalled(t, "Create")
+}
+
+// TestAuthenticatePersonalAccessToken_GrantsScopesUnit tests that a valid token grants its scopes and records its use
+func TestAuthenticatePersonalAccessToken_GrantsScopesUnit(t *testing.T) {
+	now := time.Now()
+	repo := new(MockPersonalAccessTokenRepository)
+	service := newTestPersonalAccessTokenService(repo, now)
+
+	token := PersonalAccessTokenPrefix + "secret"
+	repo.On("FindByHash", utils.HashToken(token)).Return(models.PersonalAccessToken{
+		ID:        3,
+		UserID:    1,
+		Scopes:    []string{models.PermissionUsersRead, models.PermissionUsersDelete},
+		ExpiresAt: now.Add(time.Hour),
+	}, nil)
+	repo.On("Touch", 3, now, "10.0.0.1", now.Add(-time.Minute)).Return(nil)
+
+	grant, err := service.Authenticate(token, "10.0.0.1")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, grant.UserID)
+	assert.Equal(t, 3, grant.TokenID)
+	// users:delete was lost by the owner, so the token no longer grants it
+	assert.Equal(t, []string{models.PermissionUsersRead}, grant.Permissions)
+	repo.AssertExpectations(t)
+}
+
+// TestAuthenticatePersonalAccessToken_RejectsRevokedAndExpiredUnit tests that revoked, expired and unknown tokens are rejected
+func TestAuthenticatePersonalAccessToken_RejectsRevokedAndExpiredUnit(t *testing.T) {
+	now := time.Now()
+	repo := new(MockPersonalAccessTokenRepository)
+	service := newTestPersonalAccessTokenService(repo, now)
+
+	revokedAt := now.Add(-time.Minute)
+	repo.On("FindByHash", utils.HashToken(PersonalAccessTokenPrefix+"revoked")).Return(models.PersonalAccessToken{UserID: 1, ExpiresAt: now.Add(time.Hour), RevokedAt: &revokedAt}, nil)
+	repo.On("FindByHash", utils.HashToken(PersonalAccessTokenPrefix+"expired")).Return(models.PersonalAccessToken{UserID: 1, ExpiresAt: now}, nil)
+	repo.On("FindByHash", utils.HashToken(PersonalAccessTokenPrefix+"unknown")).Return(models.PersonalAccessToken{}, errors.New("personal access token not found"))
+
+	for _, token := range []string{"revoked", "expired", "unknown"} {
+		_, err := service.Authenticate(PersonalAccessTokenPrefix+token, "10.0.0.1")
+		assert.ErrorIs(t, err, ErrInvalidPersonalAccessToken, token)
+	}
+	_, err := service.Authenticate("not-a-pat", "10.0.0.1")
+	assert.ErrorIs(t, err, ErrInvalidPersonalAccessToken)
+	repo.AssertNotCalled(t, "Touch")
+}
+
+// TestRevokePersonalAccessToken_NotOwnedUnit tests revoking a token of another user
+func TestRevokePersonalAccessToken_NotOwnedUnit(t *testing.T) {
+	now := time.Now()
+	repo := new(MockPersonalAccessTokenRepository)
+	service := newTestPersonalAccessTokenService(repo, now)
+
+	repo.On("Revoke", 1, 9, now).Return(false, nil)
+
+	assert.ErrorIs(t, service.Revoke(1, 9), ErrPersonalAccessTokenNotFound)
+}
@@ -0,0 +1,205 @@
+package services
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"gin-user-app/apperrors"
+	"gin-user-app/dto"
+	"gin-user-app/models"
+	"gin-user-app/repositories"
+	"gin-user-app/sms"
+	"gin-user-app/utils"
+	"strings"
+	"time"
+)
+
+const (
+	// phoneCodeDigits is the length of the code sent by SMS.
+	phoneCodeDigits = 6
+	// phoneCodeMaxAttempts is the number of guesses allowed before a code is burned.
+	phoneCodeMaxAttempts = 5
+)
+
+var (
+	// ErrInvalidPhone is returned for a phone number that is not in E.164 format.
+	ErrInvalidPhone = apperrors.Invalid("phone", "phone must be an E.164 number such as +628123456789")
+	// ErrPhoneUnchanged is returned when the number is already the verified phone of the user.
+	ErrPhoneUnchanged = apperrors.Invalid("phone", "phone is the same as the current phone")
+	// ErrPhoneTaken is returned when another user already verified the number.
+	ErrPhoneTaken = apperrors.Conflict("phone already taken", "phone")
+	// ErrInvalidPhoneCode is returned for wrong, expired, used or burned codes.
+	ErrInvalidPhoneCode = apperrors.BadRequest("invalid or expired phone verification code")
+	// ErrPhoneCodeThrottled is returned when a code was sent too recently.
+	ErrPhoneCodeThrottled = apperrors.New(apperrors.ErrTooManyRequests, "verification code was sent recently, please wait before requesting another")
+)
+
+// PhoneService lets the authenticated user add, verify and remove a phone
+// number. Only a verified number can be used to log in.
+type PhoneService interface {
+	RequestPhoneChange(userID int, req dto.ChangePhoneRequestDTO) error
+	VerifyPhone(userID int, req dto.VerifyPhoneRequestDTO) (dto.UserDTO, error)
+	RemovePhone(userID int, req dto.RemovePhoneRequestDTO) error
+}
+
+// PhoneServiceImpl implements PhoneService
+type PhoneServiceImpl struct {
+	userRepo       repositories.UserRepository
+	phoneRepo      repositories.PhoneVerificationRepository
+	lockouts       LockoutService
+	sender         sms.Sender
+	ttl            time.Duration
+	resendInterval time.Duration
+	now            func() time.Time
+}
+
+// NewPhoneService creates a new instance of PhoneService. A code is valid for
+// ttl and a new one can be requested once every resendInterval.
+func NewPhoneService(userRepo repositories.UserRepository, phoneRepo repositories.PhoneVerificationRepository, lockouts LockoutService, sender sms.Sender, ttl, resendInterval time.Duration) PhoneService {
+	return &PhoneServiceImpl{
+		userRepo:       userRepo,
+		phoneRepo:      phoneRepo,
+		lockouts:       lockouts,
+		sender:         sender,
+		ttl:            ttl,
+		resendInterval: resendInterval,
+		now:            time.Now,
+	}
+}
+
+// RequestPhoneChange sends a verification code to the new number. The phone
+// of the user only changes when the code is confirmed with VerifyPhone.
+func (s *PhoneServiceImpl) RequestPhoneChange(userID int, req dto.ChangePhoneRequestDTO) error {
+	phone, ok := utils.CanonicalPhone(req.Phone)
+	if !ok {
+		return ErrInvalidPhone
+	}
+
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return err
+	}
+	if err := confirmPassword(s.lockouts, user, req.CurrentPassword); err != nil {
+		return err
+	}
+	if user.PhoneVerifiedAt != nil && user.Phone != nil && *user.Phone == phone {
+		return ErrPhoneUnchanged
+	}
+	if err := s.ensurePhoneAvailable(phone, userID); err != nil {
+		return err
+	}
+
+	now := s.now()
+	lastSentAt, err := s.phoneRepo.LastSentAt(userID)
+	if err != nil {
+		return err
+	}
+	if lastSentAt != nil && now.Sub(*lastSentAt) < s.resendInterval {
+		return ErrPhoneCodeThrottled
+	}
+
+	code, err := utils.GenerateNumericCode(phoneCodeDigits)
+	if err != nil {
+		return err
+	}
+	if err := s.phoneRepo.InvalidateForUser(userID, now); err != nil {
+		return err
+	}
+	if err := s.phoneRepo.Create(models.PhoneVerificationCode{
+		UserID:    userID,
+		Phone:     phone,
+		CodeHash:  utils.HashToken(code),
+		ExpiresAt: now.Add(s.ttl),
+	}); err != nil {
+		return err
+	}
+
+	return s.sender.Send(sms.Message{
+		To:   phone,
+		Body: fmt.Sprintf("Your verification code is %s. It expires in %s.", code, s.ttl),
+	})
+}
+
+// VerifyPhone sets the number the newest code was sent to as the verified
+// phone of the user. Every guess counts, after phoneCodeMaxAttempts guesses
+// the code stops working and a new one has to be requested.
+func (s *PhoneServiceImpl) VerifyPhone(userID int, req dto.VerifyPhoneRequestDTO) (dto.UserDTO, error) {
+	now := s.now()
+	code, err := s.phoneRepo.FindActive(userID, now)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return dto.UserDTO{}, ErrInvalidPhoneCode
+		}
+		return dto.UserDTO{}, err
+	}
+
+	allowed, err := s.phoneRepo.UseAttempt(code.ID, phoneCodeMaxAttempts)
+	if err != nil {
+		return dto.UserDTO{}, err
+	}
+	hash := utils.HashToken(strings.TrimSpace(req.Code))
+	if !allowed || subtle.ConstantTimeCompare([]byte(hash), []byte(code.CodeHash)) != 1 {
+		return dto.UserDTO{}, ErrInvalidPhoneCode
+	}
+	// The number may have been verified by someone else since the request
+	if err := s.ensurePhoneAvailable(code.Phone, userID); err != nil {
+		return dto.UserDTO{}, err
+	}
+
+	consumed, err := s.phoneRepo.Consume(code, now)
+	if errors.Is(err, ErrDuplicate) {
+		return dto.UserDTO{}, ErrPhoneTaken
+	}
+	if err != nil {
+		return dto.UserDTO{}, err
+	}
+	if !consumed {
+		return dto.UserDTO{}, ErrInvalidPhoneCode
+	}
+
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return dto.UserDTO{}, err
+	}
+	return toUserDTO(user), nil
+}
+
+// RemovePhone removes the phone of the user after checking the password, the
+// number can no longer be used to log in. Pending codes stop working.
+func (s *PhoneServiceImpl) RemovePhone(userID int, req dto.RemovePhoneRequestDTO) error {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return err
+	}
+	if err := confirmPassword(s.lockouts, user, req.Password); err != nil {
+		return err
+	}
+
+	now := s.now()
+	if err := s.phoneRepo.InvalidateForUser(userID, now); err != nil {
+		return err
+	}
+	if user.Phone == nil && user.PhoneVerifiedAt == nil {
+		return nil
+	}
+	user.Phone = nil
+	user.PhoneVerifiedAt = nil
+	user.UpdatedAt = now
+	_, err = s.userRepo.Update(user)
+	return err
+}
+
+// ensurePhoneAvailable returns ErrPhoneTaken when another user verified the number
+func (s *PhoneServiceImpl) ensurePhoneAvailable(phone string, userID int) error {
+	existing, err := s.userRepo.FindByPhone(phone)
+	if err != nil {
+		if errors.Is(err, repositories.ErrUserNotFound) {
+			return nil
+		}
+		return err
+	}
+	if existing.ID != userID {
+		return ErrPhoneTaken
+	}
+	return nil
+}
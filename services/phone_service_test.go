@@ -0,0 +1,198 @@
+package services
+
+import (
+	"gin-user-app/apperrors"
+	"gin-user-app/dto"
+	"gin-user-app/models"
+	"gin-user-app/repositories"
+	"gin-user-app/sms"
+	"gin-user-app/utils"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockPhoneVerificationRepository adalah mock untuk PhoneVerificationRepository
+type MockPhoneVerificationRepository struct {
+	mock.Mock
+}
+
+func (m *MockPhoneVerificationRepository) Create(code models.PhoneVerificationCode) error {
+	args := m.Called(code)
+	return args.Error(0)
+}
+
+func (m *MockPhoneVerificationRepository) FindActive(userID int, now time.Time) (models.PhoneVerificationCode, error) {
+	args := m.Called(userID, now)
+	return args.Get(0).(models.PhoneVerificationCode), args.Error(1)
+}
+
+func (m *MockPhoneVerificationRepository) LastSentAt(userID int) (*time.Time, error) {
+	args := m.Called(userID)
+	sentAt, _ := args.Get(0).(*time.Time)
+	return sentAt, args.Error(1)
+}
+
+func (m *MockPhoneVerificationRepository) UseAttempt(id int, maxAttempts int) (bool, error) {
+	args := m.Called(id, maxAttempts)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockPhoneVerificationRepository) InvalidateForUser(userID int, at time.Time) error {
+	args := m.Called(userID, at)
+	return args.Error(0)
+}
+
+func (m *MockPhoneVerificationRepository) Consume(code models.PhoneVerificationCode, at time.Time) (bool, error) {
+	args := m.Called(code, at)
+	return args.Bool(0), args.Error(1)
+}
+
+// phoneTestDeps holds the mocks behind a test PhoneService
+type phoneTestDeps struct {
+	userRepo    *MockUserRepository
+	phoneRepo   *MockPhoneVerificationRepository
+	attemptRepo *MockLoginAttemptRepository
+	outbox      *sms.MemorySender
+}
+
+// newTestPhoneService returns a PhoneService for user 1, stored as user with the password "current-password"
+func newTestPhoneService(t *testing.T, now time.Time, user models.User) (*PhoneServiceImpl, phoneTestDeps) {
+	hash, err := utils.HashPassword("current-password")
+	assert.NoError(t, err)
+
+	deps := phoneTestDeps{
+		userRepo:    new(MockUserRepository),
+		phoneRepo:   new(MockPhoneVerificationRepository),
+		attemptRepo: new(MockLoginAttemptRepository),
+		outbox:      sms.NewMemorySender(),
+	}
+	user.ID = 1
+	user.Password = hash
+	deps.userRepo.On("GetByID", 1).Return(user, nil)
+	deps.attemptRepo.On("Get", 1).Return(models.LoginAttempt{}, nil).Maybe()
+	deps.attemptRepo.On("Clear", 1).Return(nil).Maybe()
+
+	service := NewPhoneService(deps.userRepo, deps.phoneRepo, newTestLockoutService(deps.attemptRepo, now), deps.outbox, 10*time.Minute, time.Minute).(*PhoneServiceImpl)
+	service.now = func() time.Time { return now }
+	return service, deps
+}
+
+// TestRequestPhoneChange_SendsCodeUnit tests that the SMS code matches the stored hash and the phone is not changed yet
+func TestRequestPhoneChange_SendsCodeUnit(t *testing.T) {
+	now := time.Now()
+	service, deps := newTestPhoneService(t, now, models.User{Username: "alice"})
+	deps.userRepo.On("FindByPhone", "+628123456789").Return(models.User{}, repositories.ErrUserNotFound)
+	deps.phoneRepo.On("LastSentAt", 1).Return(nil, nil)
+	deps.phoneRepo.On("InvalidateForUser", 1, now).Return(nil)
+
+	var stored models.PhoneVerificationCode
+	deps.phoneRepo.On("Create", mock.Anything).Run(func(args mock.Arguments) {
+		stored = args.Get(0).(models.PhoneVerificationCode)
+	}).Return(nil)
+
+	err := service.RequestPhoneChange(1, dto.ChangePhoneRequestDTO{Phone: "+62 812-3456-789", CurrentPassword: "current-password"})
+	assert.NoError(t, err)
+	assert.Equal(t, "+628123456789", stored.Phone)
+	assert.Equal(t, now.Add(10*time.Minute), stored.ExpiresAt)
+
+	messages := deps.outbox.Messages()
+	assert.Len(t, messages, 1)
+	assert.Equal(t, "+628123456789", messages[0].To)
+	code := regexp.MustCompile(`\d{6}`).FindString(messages[0].Body)
+	assert.Equal(t, utils.HashToken(code), stored.CodeHash)
+	deps.userRepo.AssertNotCalled(t, "Update", mock.Anything)
+}
+
+// TestRequestPhoneChange_RejectedUnit tests that invalid, taken and throttled numbers get no code
+func TestRequestPhoneChange_RejectedUnit(t *testing.T) {
+	now := time.Now()
+	req := dto.ChangePhoneRequestDTO{Phone: "+628123456789", CurrentPassword: "current-password"}
+
+	service, deps := newTestPhoneService(t, now, models.User{Username: "alice"})
+	err := service.RequestPhoneChange(1, dto.ChangePhoneRequestDTO{Phone: "08123456789", CurrentPassword: "current-password"})
+	assert.ErrorIs(t, err, ErrInvalidPhone)
+	deps.userRepo.AssertNotCalled(t, "GetByID", 1)
+
+	service, deps = newTestPhoneService(t, now, models.User{Username: "alice"})
+	deps.userRepo.On("FindByPhone", "+628123456789").Return(models.User{ID: 2}, nil)
+	err = service.RequestPhoneChange(1, req)
+	assert.ErrorIs(t, err, ErrPhoneTaken)
+	deps.phoneRepo.AssertNotCalled(t, "Create", mock.Anything)
+
+	service, deps = newTestPhoneService(t, now, models.User{Username: "alice"})
+	sentAt := now.Add(-10 * time.Second)
+	deps.userRepo.On("FindByPhone", "+628123456789").Return(models.User{}, repositories.ErrUserNotFound)
+	deps.phoneRepo.On("LastSentAt", 1).Return(&sentAt, nil)
+	err = service.RequestPhoneChange(1, req)
+	assert.ErrorIs(t, err, ErrPhoneCodeThrottled)
+	assert.Equal(t, apperrors.ErrTooManyRequests, apperrors.KindOf(err))
+	deps.phoneRepo.AssertNotCalled(t, "Create", mock.Anything)
+	assert.Empty(t, deps.outbox.Messages())
+}
+
+// TestVerifyPhone_WrongOrBurnedCodeUnit tests that a wrong code or a code without attempts left does not set the phone
+func TestVerifyPhone_WrongOrBurnedCodeUnit(t *testing.T) {
+	now := time.Now()
+	service, deps := newTestPhoneService(t, now, models.User{Username: "alice"})
+	code := models.PhoneVerificationCode{ID: 5, UserID: 1, Phone: "+628123456789", CodeHash: utils.HashToken("123456")}
+	deps.phoneRepo.On("FindActive", 1, now).Return(code, nil)
+
+	deps.phoneRepo.On("UseAttempt", 5, phoneCodeMaxAttempts).Return(true, nil).Once()
+	_, err := service.VerifyPhone(1, dto.VerifyPhoneRequestDTO{Code: "654321"})
+	assert.ErrorIs(t, err, ErrInvalidPhoneCode)
+
+	// The right code no longer works once the attempts are used up
+	deps.phoneRepo.On("UseAttempt", 5, phoneCodeMaxAttempts).Return(false, nil).Once()
+	_, err = service.VerifyPhone(1, dto.VerifyPhoneRequestDTO{Code: "123456"})
+	assert.ErrorIs(t, err, ErrInvalidPhoneCode)
+
+	deps.phoneRepo.On("FindActive", 2, now).Return(models.PhoneVerificationCode{}, apperrors.NotFound("phone verification code not found"))
+	_, err = service.VerifyPhone(2, dto.VerifyPhoneRequestDTO{Code: "123456"})
+	assert.ErrorIs(t, err, ErrInvalidPhoneCode)
+
+	deps.phoneRepo.AssertNotCalled(t, "Consume", mock.Anything, mock.Anything)
+}
+
+// TestVerifyPhone_SetsVerifiedPhoneUnit tests that the right code sets the phone and a taken number is reported as a conflict
+func TestVerifyPhone_SetsVerifiedPhoneUnit(t *testing.T) {
+	now := time.Now()
+	phone := "+628123456789"
+	service, deps := newTestPhoneService(t, now, models.User{Username: "alice", Phone: &phone, PhoneVerifiedAt: &now})
+	code := models.PhoneVerificationCode{ID: 5, UserID: 1, Phone: phone, CodeHash: utils.HashToken("123456")}
+	deps.phoneRepo.On("FindActive", 1, now).Return(code, nil)
+	deps.phoneRepo.On("UseAttempt", 5, phoneCodeMaxAttempts).Return(true, nil)
+	deps.userRepo.On("FindByPhone", "+628123456789").Return(models.User{}, repositories.ErrUserNotFound)
+	deps.phoneRepo.On("Consume", code, now).Return(false, ErrDuplicate).Once()
+
+	_, err := service.VerifyPhone(1, dto.VerifyPhoneRequestDTO{Code: "123456"})
+	assert.ErrorIs(t, err, ErrPhoneTaken)
+
+	deps.phoneRepo.On("Consume", code, now).Return(true, nil).Once()
+
+	user, err := service.VerifyPhone(1, dto.VerifyPhoneRequestDTO{Code: " 123456 "})
+	assert.NoError(t, err)
+	assert.Equal(t, &phone, user.Phone)
+}
+
+// TestRemovePhone_ClearsPhoneUnit tests that the phone and its verification are removed after the password check
+func TestRemovePhone_ClearsPhoneUnit(t *testing.T) {
+	now := time.Now()
+	phone := "+628123456789"
+	service, deps := newTestPhoneService(t, now, models.User{Username: "alice", Phone: &phone, PhoneVerifiedAt: &now})
+	deps.phoneRepo.On("InvalidateForUser", 1, now).Return(nil)
+
+	var saved models.User
+	deps.userRepo.On("Update", mock.Anything).Run(func(args mock.Arguments) {
+		saved = args.Get(0).(models.User)
+	}).Return(models.User{}, nil)
+
+	err := service.RemovePhone(1, dto.RemovePhoneRequestDTO{Password: "current-password"})
+	assert.NoError(t, err)
+	assert.Nil(t, saved.Phone)
+	assert.Nil(t, saved.PhoneVerifiedAt)
+	deps.phoneRepo.AssertExpectations(t)
+}
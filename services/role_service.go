@@ -0,0 +1,171 @@
+package services
+
+import (
+	"gin-user-app/apperrors"
+	"gin-user-app/dto"
+	"gin-user-app/models"
+	"gin-user-app/repositories"
+	"regexp"
+	"sort"
+)
+
+var (
+	// ErrUnknownRole is returned when assigning a role that does not exist.
+	ErrUnknownRole = apperrors.Validation("unknown role")
+	// ErrUnknownPermission is returned when creating a role with a permission that does not exist.
+	ErrUnknownPermission = apperrors.Validation("unknown permission")
+	// ErrInvalidRoleName is returned for role names that are not lower_underscore_case.
+	ErrInvalidRoleName = apperrors.Invalid("name", "role name must be 3-30 lowercase letters, digits or underscores")
+)
+
+var roleNameRegex = regexp.MustCompile(`^[a-z0-9_]{3,30}$`)
+
+// RoleService manages roles, permissions and their assignment to users
+type RoleService interface {
+	GetAllRoles() ([]dto.RoleDTO, error)
+	CreateRole(role dto.CreateRoleDTO) (dto.RoleDTO, error)
+	GetUserRoles(userID int) ([]dto.RoleDTO, error)
+	AssignRoles(userID int, roleNames []string) ([]dto.RoleDTO, error)
+	ResolveAccess(userID int) (roles []string, permissions []string, err error)
+}
+
+// RoleServiceImpl implements RoleService
+type RoleServiceImpl struct {
+	roleRepo repositories.RoleRepository
+}
+
+// NewRoleService creates a new instance of RoleService
+func NewRoleService(roleRepo repositories.RoleRepository) RoleService {
+	return &RoleServiceImpl{roleRepo: roleRepo}
+}
+
+// GetAllRoles returns every role with its permissions
+func (s *RoleServiceImpl) GetAllRoles() ([]dto.RoleDTO, error) {
+	roles, err := s.roleRepo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	return toRoleDTOs(roles), nil
+}
+
+// CreateRole creates a custom role from existing permissions
+func (s *RoleServiceImpl) CreateRole(role dto.CreateRoleDTO) (dto.RoleDTO, error) {
+	if !roleNameRegex.MatchString(role.Name) {
+		return dto.RoleDTO{}, ErrInvalidRoleName
+	}
+
+	permissions, err := s.roleRepo.FindPermissionsByNames(role.Permissions)
+	if err != nil {
+		return dto.RoleDTO{}, err
+	}
+	if len(permissions) != len(uniqueStrings(role.Permissions)) {
+		return dto.RoleDTO{}, ErrUnknownPermission
+	}
+
+	created, err := s.roleRepo.Create(models.Role{
+		Name:        role.Name,
+		Description: role.Description,
+		Permissions: permissions,
+	})
+	if err != nil {
+		return dto.RoleDTO{}, err
+	}
+	return toRoleDTO(created), nil
+}
+
+// GetUserRoles returns the effective roles of a user
+func (s *RoleServiceImpl) GetUserRoles(userID int) ([]dto.RoleDTO, error) {
+	roles, err := s.effectiveRoles(userID)
+	if err != nil {
+		return nil, err
+	}
+	return toRoleDTOs(roles), nil
+}
+
+// AssignRoles replaces the roles of a user
+func (s *RoleServiceImpl) AssignRoles(userID int, roleNames []string) ([]dto.RoleDTO, error) {
+	roleNames = uniqueStrings(roleNames)
+	roles, err := s.roleRepo.GetByNames(roleNames)
+	if err != nil {
+		return nil, err
+	}
+	if len(roles) != len(roleNames) {
+		return nil, ErrUnknownRole
+	}
+
+	if err := s.roleRepo.SetUserRoles(userID, roles); err != nil {
+		return nil, err
+	}
+	return s.GetUserRoles(userID)
+}
+
+// ResolveAccess returns the role and permission names to embed in the user's tokens
+func (s *RoleServiceImpl) ResolveAccess(userID int) ([]string, []string, error) {
+	roles, err := s.effectiveRoles(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	roleNames := make([]string, 0, len(roles))
+	var permissionNames []string
+	for _, role := range roles {
+		roleNames = append(roleNames, role.Name)
+		for _, permission := range role.Permissions {
+			permissionNames = append(permissionNames, permission.Name)
+		}
+	}
+	permissionNames = uniqueStrings(permissionNames)
+	sort.Strings(permissionNames)
+	return roleNames, permissionNames, nil
+}
+
+// effectiveRoles returns the assigned roles, falling back to the default user role
+func (s *RoleServiceImpl) effectiveRoles(userID int) ([]models.Role, error) {
+	roles, err := s.roleRepo.GetUserRoles(userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(roles) > 0 {
+		return roles, nil
+	}
+
+	defaultRole, err := s.roleRepo.GetByName(models.RoleUser)
+	if err != nil {
+		return nil, err
+	}
+	return []models.Role{defaultRole}, nil
+}
+
+func toRoleDTO(role models.Role) dto.RoleDTO {
+	permissions := make([]string, 0, len(role.Permissions))
+	for _, permission := range role.Permissions {
+		permissions = append(permissions, permission.Name)
+	}
+	sort.Strings(permissions)
+	return dto.RoleDTO{
+		ID:          role.ID,
+		Name:        role.Name,
+		Description: role.Description,
+		Permissions: permissions,
+	}
+}
+
+func toRoleDTOs(roles []models.Role) []dto.RoleDTO {
+	roleDTOs := make([]dto.RoleDTO, 0, len(roles))
+	for _, role := range roles {
+		roleDTOs = append(roleDTOs, toRoleDTO(role))
+	}
+	return roleDTOs
+}
+
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	unique := make([]string, 0, len(values))
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			unique = append(unique, value)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"gin-user-app/dto"
+	"gin-user-app/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockRoleRepository adalah mock untuk RoleRepository
+type MockRoleRepository struct {
+	mock.Mock
+}
+
+func (m *MockRoleRepository) GetAll() ([]models.Role, error) {
+	args := m.Called()
+	return args.Get(0).([]models.Role), args.Error(1)
+}
+
+func (m *MockRoleRepository) GetByName(name string) (models.Role, error) {
+	args := m.Called(name)
+	return args.Get(0).(models.Role), args.Error(1)
+}
+
+func (m *MockRoleRepository) GetByNames(names []string) ([]models.Role, error) {
+	args := m.Called(names)
+	return args.Get(0).([]models.Role), args.Error(1)
+}
+
+func (m *MockRoleRepository) Create(role models.Role) (models.Role, error) {
+	args := m.Called(role)
+	return args.Get(0).(models.Role), args.Error(1)
+}
+
+func (m *MockRoleRepository) FindPermissionsByNames(names []string) ([]models.Permission, error) {
+	args := m.Called(names)
+	return args.Get(0).([]models.Permission), args.Error(1)
+}
+
+func (m *MockRoleRepository) GetUserRoles(userID int) ([]models.Role, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]models.Role), args.Error(1)
+}
+
+func (m *MockRoleRepository) SetUserRoles(userID int, roles []models.Role) error {
+	args := m.Called(userID, roles)
+	return args.Error(0)
+}
+
+func (m *MockRoleRepository) EnsureDefaults() error {
+	args := m.Called()
+	return args.Error(0)
+}
+
+// TestResolveAccess_DefaultRoleUnit tests that users without roles get the default user role
+func TestResolveAccess_DefaultRoleUnit(t *testing.T) {
+	mockRepo := new(MockRoleRepository)
+	service := NewRoleService(mockRepo)
+
+	mockRepo.On("GetUserRoles", 1).Return([]models.Role{}, nil)
+	mockRepo.On("GetByName", models.RoleUser).Return(models.Role{
+		Name:        models.RoleUser,
+		Permissions: []models.Permission{{Name: models.PermissionUsersRead}},
+	}, nil)
+
+	roles, permissions, err := service.ResolveAccess(1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{models.RoleUser}, roles)
+	assert.Equal(t, []string{models.PermissionUsersRead}, permissions)
+	mockRepo.AssertExpectations(t)
+}
+
+// TestResolveAccess_MergesPermissionsUnit tests that permissions of several roles are merged
+func TestResolveAccess_MergesPermissionsUnit(t *testing.T) {
+	mockRepo := new(MockRoleRepository)
+	service := NewRoleService(mockRepo)
+
+	mockRepo.On("GetUserRoles", 1).Return([]models.Role{
+		{Name: models.RoleUser, Permissions: []models.Permission{{Name: models.PermissionUsersRead}}},
+		{Name: "support", Permissions: []models.Permission{{Name: models.PermissionUsersRead}, {Name: models.PermissionUsersUpdate}}},
+	}, nil)
+
+	roles, permissions, err := service.ResolveAccess(1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{models.RoleUser, "support"}, roles)
+	assert.Equal(t, []string{models.PermissionUsersRead, models.PermissionUsersUpdate}, permissions)
+	mockRepo.AssertNotCalled(t, "GetByName")
+}
+
+// TestAssignRoles_UnknownRoleUnit tests assigning a role that does not exist
+func TestAssignRoles_UnknownRoleUnit(t *testing.T) {
+	mockRepo := new(MockRoleRepository)
+	service := NewRoleService(mockRepo)
+
+	mockRepo.On("GetByNames", []string{"admin", "ghost"}).Return([]models.Role{{ID: 1, Name: "admin"}}, nil)
+
+	_, err := service.AssignRoles(1, []string{"admin", "ghost", "admin"})
+	assert.ErrorIs(t, err, ErrUnknownRole)
+	mockRepo.AssertNotCalled(t, "SetUserRoles")
+}
+
+// TestCreateRole_UnknownPermissionUnit tests creating a role with a permission that does not exist
+func TestCreateRole_UnknownPermissionUnit(t *testing.T) {
+	mockRepo := new(MockRoleRepository)
+	service := NewRoleService(mockRepo)
+
+	mockRepo.On("FindPermissionsByNames", []string{"users:read", "users:fly"}).Return([]models.Permission{{ID: 1, Name: "users:read"}}, nil)
+
+	_, err := service.CreateRole(dto.CreateRoleDTO{
+		Name:        "support",
+		Permissions: []string{"users:read", "users:fly"},
+	})
+	assert.ErrorIs(t, err, ErrUnknownPermission)
+	mockRepo.AssertNotCalled(t, "Create")
+}
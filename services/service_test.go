@@ -20,7 +20,7 @@ func setupTestDB(t *testing.T) *gorm.DB {
 		t.Fatalf("Failed to initialize test database")
 	}
 
-	err := db.AutoMigrate(&models.User{})
+	err := db.AutoMigrate(&models.Permission{}, &models.Role{}, &models.User{})
 	if err != nil {
 		t.Fatalf("Failed to migrate database: %v", err)
 	}
@@ -35,7 +35,7 @@ func TestCreateUser_ValidInputIntegration(t *testing.T) {
 
 	tx := db.Begin()
 	userRepo := repositories.NewUserRepository(tx)
-	service := NewUserService(userRepo)
+	service := NewUserService(userRepo, nil, nil, nil, "")
 	age := 20
 
 	createUserDTO := dto.CreateUserDTO{
@@ -68,7 +68,7 @@ func TestCreateUserIntegration_ShortUsername(t *testing.T) {
 
 	tx := db.Begin()
 	userRepo := repositories.NewUserRepository(tx)
-	service := NewUserService(userRepo)
+	service := NewUserService(userRepo, nil, nil, nil, "")
 	age := 20
 
 	createUserDTO := dto.CreateUserDTO{
@@ -95,7 +95,7 @@ func TestCreateUserIntegration_NonGmailEmail(t *testing.T) {
 
 	tx := db.Begin()
 	userRepo := repositories.NewUserRepository(tx)
-	service := NewUserService(userRepo)
+	service := NewUserService(userRepo, nil, newGmailOnlyPolicy(t), nil, "")
 	age := 20
 
 	createUserDTO := dto.CreateUserDTO{
@@ -109,7 +109,7 @@ func TestCreateUserIntegration_NonGmailEmail(t *testing.T) {
 
 	user, err := service.CreateUser(createUserDTO)
 	assert.Error(t, err)
-	assert.Equal(t, "email must be a valid Gmail address (@gmail.com)", err.Error())
+	assert.Equal(t, "email domain is not allowed", err.Error())
 	assert.Equal(t, dto.UserDTO{}, user)
 
 	tx.Rollback()
@@ -122,7 +122,7 @@ func TestCreateUserIntegration_ShortPassword(t *testing.T) {
 
 	tx := db.Begin()
 	userRepo := repositories.NewUserRepository(tx)
-	service := NewUserService(userRepo)
+	service := NewUserService(userRepo, nil, nil, nil, "")
 	age := 20
 
 	createUserDTO := dto.CreateUserDTO{
@@ -149,7 +149,7 @@ func TestCreateUserIntegration_YoungAge(t *testing.T) {
 
 	tx := db.Begin()
 	userRepo := repositories.NewUserRepository(tx)
-	service := NewUserService(userRepo)
+	service := NewUserService(userRepo, nil, nil, nil, "")
 	age := 14 // Too young
 
 	createUserDTO := dto.CreateUserDTO{
@@ -176,7 +176,7 @@ func TestCreateUserIntegration_DuplicateUsername(t *testing.T) {
 
 	tx := db.Begin()
 	userRepo := repositories.NewUserRepository(tx)
-	service := NewUserService(userRepo)
+	service := NewUserService(userRepo, nil, nil, nil, "")
 	age := 20
 
 	createUserDTO1 := dto.CreateUserDTO{
@@ -214,7 +214,7 @@ func TestUpdateUser_ValidInputIntegration(t *testing.T) {
 
 	tx := db.Begin()
 	userRepo := repositories.NewUserRepository(tx)
-	service := NewUserService(userRepo)
+	service := NewUserService(userRepo, nil, nil, nil, "")
 	age := 20
 
 	createUserDTO := dto.CreateUserDTO{
@@ -229,14 +229,15 @@ func TestUpdateUser_ValidInputIntegration(t *testing.T) {
 	assert.NoError(t, err)
 
 	updateUserDTO := dto.UpdateUserDTO{
-		Username:  "updateduser",
-		Email:     "updatedemail@gmail.com",
-		FirstName: "UpdatedTest",
-		LastName:  "UpdatedUser",
-		Age:       &age,
+		Username:             "updateduser",
+		Email:                "updatedemail@gmail.com",
+		FirstName:            "UpdatedTest",
+		LastName:             "UpdatedUser",
+		Age:                  &age,
+		CanChangeCredentials: true,
 	}
 
-	updatedUser, err := service.UpdateUser(user.ID, updateUserDTO)
+	updatedUser, err := service.UpdateUser(user.ID, updateUserDTO, 0)
 	assert.NoError(t, err)
 
 	assert.Equal(t, updateUserDTO.Username, updatedUser.Username)
@@ -258,7 +259,7 @@ func TestUpdateUser_InvalidEmailIntegration(t *testing.T) {
 
 	tx := db.Begin()
 	userRepo := repositories.NewUserRepository(tx)
-	service := NewUserService(userRepo)
+	service := NewUserService(userRepo, nil, nil, nil, "")
 	age := 20
 
 	createUserDTO := dto.CreateUserDTO{
@@ -280,7 +281,7 @@ func TestUpdateUser_InvalidEmailIntegration(t *testing.T) {
 		Age:       &age,
 	}
 
-	updatedUser, err := service.UpdateUser(user.ID, updateUserDTO)
+	updatedUser, err := service.UpdateUser(user.ID, updateUserDTO, 0)
 	assert.Error(t, err)
 	assert.Equal(t, "invalid email format", err.Error())
 	assert.Equal(t, dto.UserDTO{}, updatedUser)
@@ -295,7 +296,7 @@ func TestUpdateUserIntegration_ShortUsername(t *testing.T) {
 
 	tx := db.Begin()
 	userRepo := repositories.NewUserRepository(tx)
-	service := NewUserService(userRepo)
+	service := NewUserService(userRepo, nil, nil, nil, "")
 	age := 20
 
 	createUserDTO := dto.CreateUserDTO{
@@ -317,7 +318,7 @@ func TestUpdateUserIntegration_ShortUsername(t *testing.T) {
 		Age:       &age,
 	}
 
-	updatedUser, err := service.UpdateUser(user.ID, updateUserDTO)
+	updatedUser, err := service.UpdateUser(user.ID, updateUserDTO, 0)
 	assert.Error(t, err)
 	assert.Equal(t, "username must be 3-20 characters", err.Error())
 	assert.Equal(t, dto.UserDTO{}, updatedUser)
@@ -332,7 +333,7 @@ func TestUpdateUserIntegration_NonGmailEmail(t *testing.T) {
 
 	tx := db.Begin()
 	userRepo := repositories.NewUserRepository(tx)
-	service := NewUserService(userRepo)
+	service := NewUserService(userRepo, nil, newGmailOnlyPolicy(t), nil, "")
 	age := 20
 
 	createUserDTO := dto.CreateUserDTO{
@@ -354,9 +355,9 @@ func TestUpdateUserIntegration_NonGmailEmail(t *testing.T) {
 		Age:       &age,
 	}
 
-	updatedUser, err := service.UpdateUser(user.ID, updateUserDTO)
+	updatedUser, err := service.UpdateUser(user.ID, updateUserDTO, 0)
 	assert.Error(t, err)
-	assert.Equal(t, "email must be a valid Gmail address (@gmail.com)", err.Error())
+	assert.Equal(t, "email domain is not allowed", err.Error())
 	assert.Equal(t, dto.UserDTO{}, updatedUser)
 
 	tx.Rollback()
@@ -369,7 +370,7 @@ func TestUpdateUser_NotFoundIntegration(t *testing.T) {
 
 	tx := db.Begin()
 	userRepo := repositories.NewUserRepository(tx)
-	service := NewUserService(userRepo)
+	service := NewUserService(userRepo, nil, nil, nil, "")
 	age := 20
 
 	createUserDTO := dto.CreateUserDTO{
@@ -392,10 +393,98 @@ func TestUpdateUser_NotFoundIntegration(t *testing.T) {
 		Age:       &age,
 	}
 
-	updatedUser, err := service.UpdateUser(nonExistentUserID, updateUserDTO)
+	updatedUser, err := service.UpdateUser(nonExistentUserID, updateUserDTO, 0)
 	assert.Error(t, err)
 	assert.Equal(t, "user not found", err.Error())
 	assert.Equal(t, dto.UserDTO{}, updatedUser)
 
 	tx.Rollback()
 }
+
+// TestCollidedUserIntegration menguji user yang bentrok saat backfill: login
+// dengan username aslinya menemukan akunnya sendiri dan perubahan yang tidak
+// mengganti username atau email tetap tersimpan.
+func TestCollidedUserIntegration(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Migrator().DropTable(&models.User{})
+
+	tx := db.Begin()
+	defer tx.Rollback()
+	assert.NoError(t, tx.Exec("CREATE UNIQUE INDEX uni_users_username_canonical ON users (username_canonical)").Error)
+	assert.NoError(t, tx.Exec("CREATE UNIQUE INDEX uni_users_email_canonical ON users (email_canonical)").Error)
+	userRepo := repositories.NewUserRepository(tx)
+	service := NewUserService(userRepo, nil, nil, nil, "")
+
+	kept, err := service.CreateUser(dto.CreateUserDTO{Username: "alice", Email: "alice@gmail.com", Password: "password123", FirstName: "Alice", LastName: "Old"})
+	assert.NoError(t, err)
+	collided, err := service.CreateUser(dto.CreateUserDTO{Username: "bobby", Email: "bobby@gmail.com", Password: "password123", FirstName: "Alice", LastName: "New"})
+	assert.NoError(t, err)
+	// Keadaan setelah migrasi 0015: username hanya beda huruf besar kecil, kolom kanonik NULL
+	assert.NoError(t, tx.Exec("UPDATE users SET username = 'Alice', username_canonical = NULL WHERE id = ?", collided.ID).Error)
+
+	found, err := userRepo.FindByUsername("Alice")
+	assert.NoError(t, err)
+	assert.Equal(t, collided.ID, found.ID)
+	found, err = userRepo.FindByUsername("ALICE")
+	assert.NoError(t, err)
+	assert.Equal(t, kept.ID, found.ID)
+
+	// Field lain bisa diubah tanpa menghitung ulang kolom kanonik
+	updated, err := service.UpdateUser(collided.ID, dto.UpdateUserDTO{Username: "Alice", Email: "bobby@gmail.com", FirstName: "Alice", LastName: "Renamed"}, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "Renamed", updated.LastName)
+	stored, err := userRepo.GetByID(collided.ID)
+	assert.NoError(t, err)
+	assert.Nil(t, stored.UsernameCanonical)
+
+	// Mengganti username mengisi kolom kanonik lagi
+	_, err = service.UpdateUser(collided.ID, dto.UpdateUserDTO{Username: "Alicia", Email: "bobby@gmail.com", FirstName: "Alice", LastName: "Renamed", CanChangeCredentials: true}, 0)
+	assert.NoError(t, err)
+	stored, err = userRepo.GetByID(collided.ID)
+	assert.NoError(t, err)
+	if assert.NotNil(t, stored.UsernameCanonical) {
+		assert.Equal(t, "alicia", *stored.UsernameCanonical)
+	}
+}
+
+// TestPhoneLoginIntegration menguji bahwa hanya nomor telepon yang sudah diverifikasi dengan kode bisa dipakai login
+func TestPhoneLoginIntegration(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Migrator().DropTable(&models.User{})
+
+	tx := db.Begin()
+	defer tx.Rollback()
+	assert.NoError(t, tx.AutoMigrate(&models.PhoneVerificationCode{}))
+	userRepo := repositories.NewUserRepository(tx)
+	phoneRepo := repositories.NewPhoneVerificationRepository(tx)
+	authRepo := repositories.NewAuthRepository(tx)
+	service := NewUserService(userRepo, nil, nil, nil, "")
+	identifiers := []string{repositories.IdentifierPhone}
+
+	created, err := service.CreateUser(dto.CreateUserDTO{Username: "alice", Email: "alice@gmail.com", Password: "password123", FirstName: "Alice", LastName: "Phone"})
+	assert.NoError(t, err)
+
+	// Nomor yang belum diverifikasi tidak bisa dipakai login
+	assert.NoError(t, tx.Exec("UPDATE users SET phone = '+628123456789' WHERE id = ?", created.ID).Error)
+	_, err = authRepo.FindByIdentifier("+628123456789", identifiers)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	now := time.Now()
+	assert.NoError(t, phoneRepo.Create(models.PhoneVerificationCode{UserID: created.ID, Phone: "+628123456789", CodeHash: "hash", ExpiresAt: now.Add(time.Minute)}))
+	code, err := phoneRepo.FindActive(created.ID, now)
+	assert.NoError(t, err)
+	consumed, err := phoneRepo.Consume(code, now)
+	assert.NoError(t, err)
+	assert.True(t, consumed)
+
+	user, err := authRepo.FindByIdentifier("+62 812-3456-789", identifiers)
+	assert.NoError(t, err)
+	if assert.NotNil(t, user) {
+		assert.Equal(t, created.ID, user.ID)
+	}
+
+	// Kode yang sudah dipakai tidak bisa dipakai lagi
+	consumed, err = phoneRepo.Consume(code, now)
+	assert.NoError(t, err)
+	assert.False(t, consumed)
+}
@@ -0,0 +1,153 @@
+package services
+
+import (
+	"errors"
+	"gin-user-app/apperrors"
+	"gin-user-app/dto"
+	"gin-user-app/models"
+	"gin-user-app/repositories"
+	"gin-user-app/utils"
+	"log"
+	"time"
+)
+
+const (
+	// maxUserAgentLength is the size of the user_agent column.
+	maxUserAgentLength = 512
+	// sessionTouchInterval limits how often last-seen time is written.
+	sessionTouchInterval = time.Minute
+)
+
+var (
+	// ErrSessionRevoked is returned for tokens whose session was revoked or does not exist.
+	ErrSessionRevoked = apperrors.Unauthorized("session has been revoked")
+	// ErrSessionNotFound is returned when revoking a session the user does not have.
+	ErrSessionNotFound = apperrors.NotFound("session not found")
+)
+
+// ClientInfo describes the client that performs a login
+type ClientInfo struct {
+	UserAgent string
+	IP        string
+}
+
+// SessionService tracks login sessions and lets users revoke them
+type SessionService interface {
+	Start(userID int, client ClientInfo) (string, error)
+	Validate(sessionID string, userID int, ip string) error
+	List(userID int, currentSessionID string) ([]dto.SessionDTO, error)
+	Revoke(userID int, sessionID string) error
+	RevokeAll(userID int) error
+}
+
+// SessionServiceImpl implements SessionService
+type SessionServiceImpl struct {
+	sessionRepo repositories.SessionRepository
+	refreshRepo repositories.RefreshTokenRepository
+	idleTimeout time.Duration
+	now         func() time.Time
+}
+
+// NewSessionService creates a new instance of SessionService. Sessions unused
+// for idleTimeout (the refresh token lifetime) are no longer listed.
+func NewSessionService(sessionRepo repositories.SessionRepository, refreshRepo repositories.RefreshTokenRepository, idleTimeout time.Duration) SessionService {
+	return &SessionServiceImpl{
+		sessionRepo: sessionRepo,
+		refreshRepo: refreshRepo,
+		idleTimeout: idleTimeout,
+		now:         time.Now,
+	}
+}
+
+// Start records a new session and returns its ID, which is also used as the
+// refresh token family ID
+func (s *SessionServiceImpl) Start(userID int, client ClientInfo) (string, error) {
+	id, err := utils.GenerateRandomID()
+	if err != nil {
+		return "", err
+	}
+
+	userAgent := client.UserAgent
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = userAgent[:maxUserAgentLength]
+	}
+	now := s.now()
+	err = s.sessionRepo.Create(models.Session{
+		ID:         id,
+		UserID:     userID,
+		UserAgent:  userAgent,
+		IP:         client.IP,
+		CreatedAt:  now,
+		LastSeenAt: now,
+	})
+	if err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// Validate checks that a session belongs to the user and is not revoked, and
+// records the activity
+func (s *SessionServiceImpl) Validate(sessionID string, userID int, ip string) error {
+	session, err := s.sessionRepo.GetByID(sessionID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return ErrSessionRevoked
+		}
+		return err
+	}
+	if session.UserID != userID || session.RevokedAt != nil {
+		return ErrSessionRevoked
+	}
+
+	// Last-seen tracking must not fail the request
+	now := s.now()
+	if err := s.sessionRepo.Touch(sessionID, now, ip, now.Add(-sessionTouchInterval)); err != nil {
+		log.Printf("Failed to record activity of session %s: %v", sessionID, err)
+	}
+	return nil
+}
+
+// List returns the active sessions of a user and marks the current one
+func (s *SessionServiceImpl) List(userID int, currentSessionID string) ([]dto.SessionDTO, error) {
+	sessions, err := s.sessionRepo.ListActive(userID, s.now().Add(-s.idleTimeout))
+	if err != nil {
+		return nil, err
+	}
+
+	sessionDTOs := make([]dto.SessionDTO, 0, len(sessions))
+	for _, session := range sessions {
+		sessionDTOs = append(sessionDTOs, dto.SessionDTO{
+			ID:         session.ID,
+			UserAgent:  session.UserAgent,
+			IP:         session.IP,
+			CreatedAt:  session.CreatedAt,
+			LastSeenAt: session.LastSeenAt,
+			Current:    session.ID == currentSessionID,
+		})
+	}
+	return sessionDTOs, nil
+}
+
+// Revoke ends one session of the user. Its refresh tokens are revoked and its
+// access tokens are rejected by AuthMiddleware.
+func (s *SessionServiceImpl) Revoke(userID int, sessionID string) error {
+	now := s.now()
+	revoked, err := s.sessionRepo.Revoke(userID, sessionID, now)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrSessionNotFound
+	}
+	return s.refreshRepo.RevokeFamily(sessionID, now)
+}
+
+// RevokeAll ends every session of the user
+func (s *SessionServiceImpl) RevokeAll(userID int) error {
+	now := s.now()
+	if err := s.sessionRepo.RevokeAllForUser(userID, now); err != nil {
+		return err
+	}
+	return s.refreshRepo.RevokeAllForUser(userID, now)
+}
@@ -0,0 +1,175 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"gin-user-app/apperrors"
+	"gin-user-app/dto"
+	"gin-user-app/models"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockSessionRepository adalah mock untuk SessionRepository
+type MockSessionRepository struct {
+	mock.Mock
+}
+
+func (m *MockSessionRepository) Create(session models.Session) error {
+	args := m.Called(session)
+	return args.Error(0)
+}
+
+func (m *MockSessionRepository) GetByID(id string) (models.Session, error) {
+	args := m.Called(id)
+	return args.Get(0).(models.Session), args.Error(1)
+}
+
+func (m *MockSessionRepository) ListActive(userID int, seenSince time.Time) ([]models.Session, error) {
+	args := m.Called(userID, seenSince)
+	return args.Get(0).([]models.Session), args.Error(1)
+}
+
+func (m *MockSessionRepository) Revoke(userID int, id string, at time.Time) (bool, error) {
+	args := m.Called(userID, id, at)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockSessionRepository) RevokeAllForUser(userID int, at time.Time) error {
+	args := m.Called(userID, at)
+	return args.Error(0)
+}
+
+func (m *MockSessionRepository) Touch(id string, at time.Time, ip string, staleBefore time.Time) error {
+	args := m.Called(id, at, ip, staleBefore)
+	return args.Error(0)
+}
+
+// MockSessionService adalah mock untuk SessionService
+type MockSessionService struct {
+	mock.Mock
+}
+
+func (m *MockSessionService) Start(userID int, client ClientInfo) (string, error) {
+	args := m.Called(userID, client)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockSessionService) Validate(sessionID string, userID int, ip string) error {
+	args := m.Called(sessionID, userID, ip)
+	return args.Error(0)
+}
+
+func (m *MockSessionService) List(userID int, currentSessionID string) ([]dto.SessionDTO, error) {
+	args := m.Called(userID, currentSessionID)
+	return args.Get(0).([]dto.SessionDTO), args.Error(1)
+}
+
+func (m *MockSessionService) Revoke(userID int, sessionID string) error {
+	args := m.Called(userID, sessionID)
+	return args.Error(0)
+}
+
+func (m *MockSessionService) RevokeAll(userID int) error {
+	args := m.Called(userID)
+	return args.Error(0)
+}
+
+func newTestSessionService(sessionRepo *MockSessionRepository, refreshRepo *MockRefreshTokenRepository, now time.Time) *SessionServiceImpl {
+	return &SessionServiceImpl{
+		sessionRepo: sessionRepo,
+		refreshRepo: refreshRepo,
+		idleTimeout: 24 * time.Hour,
+		now:         func() time.Time { return now },
+	}
+}
+
+// TestStartSession_RecordsClientUnit tests that a session stores the client and truncates long user agents
+func TestStartSession_RecordsClientUnit(t *testing.T) {
+	now := time.Now()
+	sessionRepo := new(MockSessionRepository)
+	service := newTestSessionService(sessionRepo, nil, now)
+
+	var stored models.Session
+	sessionRepo.On("Create", mock.Anything).Run(func(args mock.Arguments) {
+		stored = args.Get(0).(models.Session)
+	}).Return(nil)
+
+	id, err := service.Start(1, ClientInfo{UserAgent: strings.Repeat("a", 600), IP: "10.0.0.1"})
+	assert.NoError(t, err)
+	assert.Equal(t, id, stored.ID)
+	assert.Equal(t, 1, stored.UserID)
+	assert.Equal(t, "10.0.0.1", stored.IP)
+	assert.Len(t, stored.UserAgent, maxUserAgentLength)
+	assert.Equal(t, now, stored.LastSeenAt)
+}
+
+// TestValidateSession_RejectsRevokedUnit tests that revoked, unknown and foreign sessions are rejected
+func TestValidateSession_RejectsRevokedUnit(t *testing.T) {
+	now := time.Now()
+	sessionRepo := new(MockSessionRepository)
+	service := newTestSessionService(sessionRepo, nil, now)
+
+	revokedAt := now.Add(-time.Minute)
+	sessionRepo.On("GetByID", "revoked").Return(models.Session{ID: "revoked", UserID: 1, RevokedAt: &revokedAt}, nil)
+	sessionRepo.On("GetByID", "foreign").Return(models.Session{ID: "foreign", UserID: 2}, nil)
+	sessionRepo.On("GetByID", "unknown").Return(models.Session{}, apperrors.NotFound("session not found"))
+	// Callers match the kind, not the message, so wrapped and reworded errors still count
+	sessionRepo.On("GetByID", "wrapped").Return(models.Session{}, fmt.Errorf("load session: %w", apperrors.NotFound("no such session")))
+
+	for _, id := range []string{"revoked", "foreign", "unknown", "wrapped"} {
+		assert.ErrorIs(t, service.Validate(id, 1, "10.0.0.1"), ErrSessionRevoked, id)
+	}
+	sessionRepo.AssertNotCalled(t, "Touch")
+
+	sessionRepo.On("GetByID", "broken").Return(models.Session{}, errors.New("session not found"))
+	assert.NotErrorIs(t, service.Validate("broken", 1, "10.0.0.1"), ErrSessionRevoked)
+}
+
+// TestValidateSession_TouchesActiveSessionUnit tests that activity on a valid session is recorded
+func TestValidateSession_TouchesActiveSessionUnit(t *testing.T) {
+	now := time.Now()
+	sessionRepo := new(MockSessionRepository)
+	service := newTestSessionService(sessionRepo, nil, now)
+
+	sessionRepo.On("GetByID", "active").Return(models.Session{ID: "active", UserID: 1}, nil)
+	sessionRepo.On("Touch", "active", now, "10.0.0.2", now.Add(-sessionTouchInterval)).Return(nil)
+
+	assert.NoError(t, service.Validate("active", 1, "10.0.0.2"))
+	sessionRepo.AssertExpectations(t)
+}
+
+// TestRevokeSession_RevokesRefreshFamilyUnit tests that revoking a session also revokes its refresh tokens
+func TestRevokeSession_RevokesRefreshFamilyUnit(t *testing.T) {
+	now := time.Now()
+	sessionRepo := new(MockSessionRepository)
+	refreshRepo := new(MockRefreshTokenRepository)
+	service := newTestSessionService(sessionRepo, refreshRepo, now)
+
+	sessionRepo.On("Revoke", 1, "session", now).Return(true, nil)
+	sessionRepo.On("Revoke", 1, "other", now).Return(false, nil)
+	refreshRepo.On("RevokeFamily", "session", now).Return(nil)
+
+	assert.NoError(t, service.Revoke(1, "session"))
+	assert.ErrorIs(t, service.Revoke(1, "other"), ErrSessionNotFound)
+	refreshRepo.AssertExpectations(t)
+	refreshRepo.AssertNotCalled(t, "RevokeFamily", "other", mock.Anything)
+}
+
+// TestListSessions_MarksCurrentUnit tests that the session of the current token is marked
+func TestListSessions_MarksCurrentUnit(t *testing.T) {
+	now := time.Now()
+	sessionRepo := new(MockSessionRepository)
+	service := newTestSessionService(sessionRepo, nil, now)
+
+	sessionRepo.On("ListActive", 1, now.Add(-24*time.Hour)).Return([]models.Session{{ID: "a"}, {ID: "b"}}, nil)
+
+	sessions, err := service.List(1, "b")
+	assert.NoError(t, err)
+	assert.False(t, sessions[0].Current)
+	assert.True(t, sessions[1].Current)
+}
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"fmt"
+	"gin-user-app/apperrors"
+	"strconv"
+	"time"
+
+	"gin-user-app/utils"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ErrInvalidToken is returned for JWTs with a bad signature, unexpected
+// algorithm, wrong issuer or audience, expired lifetime or wrong token_use.
+var ErrInvalidToken = apperrors.Unauthorized("invalid or expired token")
+
+// TokenClaims are the claims of every JWT issued by TokenService. The user ID
+// is carried in sub as a decimal string, the login session in sid.
+type TokenClaims struct {
+	TokenUse    string   `json:"token_use"`
+	SessionID   string   `json:"sid,omitempty"`
+	Roles       []string `json:"roles,omitempty"`
+	Permissions []string `json:"permissions,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// UserID returns the user ID from sub, or 0 when it is not a number.
+// Claims returned by TokenService.Verify always carry a valid user ID.
+func (c *TokenClaims) UserID() int {
+	id, err := strconv.Atoi(c.Subject)
+	if err != nil {
+		return 0
+	}
+	return id
+}
+
+// TokenConfig configures the claims and lifetimes of issued JWTs.
+// ClockSkew is the leeway accepted on exp, nbf and iat when verifying.
+type TokenConfig struct {
+	Issuer          string
+	Audience        string
+	AccessTokenTTL  time.Duration
+	MFAChallengeTTL time.Duration
+	ClockSkew       time.Duration
+}
+
+// TokenService is the only place where JWTs are issued and verified
+type TokenService interface {
+	IssueAccessToken(userID int, sessionID string, roles, permissions []string) (string, error)
+	IssueMFAChallenge(userID int) (string, error)
+	IssuePasswordChangeChallenge(userID int) (string, error)
+	Verify(tokenString, use string) (*TokenClaims, error)
+	AccessTokenTTL() time.Duration
+	MFAChallengeTTL() time.Duration
+	ClockSkew() time.Duration
+}
+
+// TokenServiceImpl implements TokenService
+type TokenServiceImpl struct {
+	keys KeyManager
+	cfg  TokenConfig
+	now  func() time.Time
+}
+
+// NewTokenService creates a new instance of TokenService
+func NewTokenService(keys KeyManager, cfg TokenConfig) TokenService {
+	return &TokenServiceImpl{
+		keys: keys,
+		cfg:  cfg,
+		now:  time.Now,
+	}
+}
+
+// IssueAccessToken signs a short-lived access token for a session carrying the user's roles and permissions
+func (s *TokenServiceImpl) IssueAccessToken(userID int, sessionID string, roles, permissions []string) (string, error) {
+	return s.issue(userID, TokenUseAccess, s.cfg.AccessTokenTTL, sessionID, roles, permissions)
+}
+
+// IssueMFAChallenge signs an mfa_pending token that can only be exchanged at POST /auth/login/mfa
+func (s *TokenServiceImpl) IssueMFAChallenge(userID int) (string, error) {
+	return s.issue(userID, TokenUseMFAPending, s.cfg.MFAChallengeTTL, "", nil, nil)
+}
+
+// IssuePasswordChangeChallenge signs a password_change token that can only be
+// exchanged at POST /auth/login/password. It lives as long as an mfa challenge.
+func (s *TokenServiceImpl) IssuePasswordChangeChallenge(userID int) (string, error) {
+	return s.issue(userID, TokenUsePasswordChange, s.cfg.MFAChallengeTTL, "", nil, nil)
+}
+
+// Verify checks the signature against the key set and validates every
+// registered claim. The token must have been issued for the given use.
+func (s *TokenServiceImpl) Verify(tokenString, use string) (*TokenClaims, error) {
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods(s.keys.Algorithms()),
+		jwt.WithLeeway(s.cfg.ClockSkew),
+		jwt.WithTimeFunc(s.now),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	}
+	if s.cfg.Issuer != "" {
+		options = append(options, jwt.WithIssuer(s.cfg.Issuer))
+	}
+	if s.cfg.Audience != "" {
+		options = append(options, jwt.WithAudience(s.cfg.Audience))
+	}
+
+	claims := &TokenClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, s.keys.Keyfunc, options...)
+	if err != nil || !token.Valid {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	if claims.TokenUse != use || claims.ID == "" || claims.IssuedAt == nil || claims.UserID() == 0 {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
+
+// AccessTokenTTL returns the lifetime of access tokens
+func (s *TokenServiceImpl) AccessTokenTTL() time.Duration {
+	return s.cfg.AccessTokenTTL
+}
+
+// MFAChallengeTTL returns the lifetime of mfa_pending tokens
+func (s *TokenServiceImpl) MFAChallengeTTL() time.Duration {
+	return s.cfg.MFAChallengeTTL
+}
+
+// ClockSkew returns how long after exp a token is still accepted. Revocations
+// must be kept at least that long.
+func (s *TokenServiceImpl) ClockSkew() time.Duration {
+	return s.cfg.ClockSkew
+}
+
+// issue signs a JWT with a fresh jti for the given purpose and lifetime
+func (s *TokenServiceImpl) issue(userID int, use string, ttl time.Duration, sessionID string, roles, permissions []string) (string, error) {
+	jti, err := utils.GenerateRandomID()
+	if err != nil {
+		return "", err
+	}
+
+	now := s.now()
+	claims := TokenClaims{
+		TokenUse:    use,
+		SessionID:   sessionID,
+		Roles:       roles,
+		Permissions: permissions,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.Itoa(userID),
+			Issuer:    s.cfg.Issuer,
+			ID:        jti,
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	}
+	if s.cfg.Audience != "" {
+		claims.Audience = jwt.ClaimStrings{s.cfg.Audience}
+	}
+	return s.keys.Sign(claims)
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+var testTokenConfig = TokenConfig{
+	Issuer:          "test-issuer",
+	Audience:        "test-audience",
+	AccessTokenTTL:  15 * time.Minute,
+	MFAChallengeTTL: 5 * time.Minute,
+	ClockSkew:       30 * time.Second,
+}
+
+// newTestTokenService returns a TokenService signing with the HS256 test key manager
+func newTestTokenService() TokenService {
+	return NewTokenService(newTestKeyManager(), testTokenConfig)
+}
+
+// TestTokenService_IssueAndVerifyUnit tests that issued access tokens carry typed claims
+func TestTokenService_IssueAndVerifyUnit(t *testing.T) {
+	service := newTestTokenService()
+
+	token, err := service.IssueAccessToken(42, "session-1", []string{"admin"}, []string{"users:read"})
+	assert.NoError(t, err)
+
+	claims, err := service.Verify(token, TokenUseAccess)
+	assert.NoError(t, err)
+	assert.Equal(t, 42, claims.UserID())
+	assert.Equal(t, "42", claims.Subject)
+	assert.Equal(t, "session-1", claims.SessionID)
+	assert.Equal(t, "test-issuer", claims.Issuer)
+	assert.Equal(t, jwt.ClaimStrings{"test-audience"}, claims.Audience)
+	assert.NotEmpty(t, claims.ID)
+	assert.NotNil(t, claims.IssuedAt)
+	assert.NotNil(t, claims.NotBefore)
+	assert.Equal(t, []string{"admin"}, claims.Roles)
+	assert.Equal(t, []string{"users:read"}, claims.Permissions)
+}
+
+// TestTokenService_RejectsWrongUseUnit tests that an mfa challenge is not accepted as an access token
+func TestTokenService_RejectsWrongUseUnit(t *testing.T) {
+	service := newTestTokenService()
+
+	challenge, err := service.IssueMFAChallenge(1)
+	assert.NoError(t, err)
+
+	_, err = service.Verify(challenge, TokenUseAccess)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	_, err = service.Verify(challenge, TokenUseMFAPending)
+	assert.NoError(t, err)
+}
+
+// TestTokenService_RejectsWrongIssuerAndAudienceUnit tests that tokens of another issuer or audience are rejected
+func TestTokenService_RejectsWrongIssuerAndAudienceUnit(t *testing.T) {
+	keys := newTestKeyManager()
+	otherIssuer := testTokenConfig
+	otherIssuer.Issuer = "other-issuer"
+	otherAudience := testTokenConfig
+	otherAudience.Audience = "other-audience"
+
+	token, err := NewTokenService(keys, otherIssuer).IssueAccessToken(1, "session-1", nil, nil)
+	assert.NoError(t, err)
+	_, err = NewTokenService(keys, testTokenConfig).Verify(token, TokenUseAccess)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	token, err = NewTokenService(keys, otherAudience).IssueAccessToken(1, "session-1", nil, nil)
+	assert.NoError(t, err)
+	_, err = NewTokenService(keys, testTokenConfig).Verify(token, TokenUseAccess)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+// TestTokenService_ClockSkewUnit tests that expiry and not-before are checked with the configured leeway
+func TestTokenService_ClockSkewUnit(t *testing.T) {
+	issuedAt := time.Now()
+	service := &TokenServiceImpl{keys: newTestKeyManager(), cfg: testTokenConfig, now: func() time.Time { return issuedAt }}
+
+	token, err := service.IssueAccessToken(1, "session-1", nil, nil)
+	assert.NoError(t, err)
+
+	// Slightly expired, within the skew
+	service.now = func() time.Time { return issuedAt.Add(15*time.Minute + 10*time.Second) }
+	_, err = service.Verify(token, TokenUseAccess)
+	assert.NoError(t, err)
+
+	// Expired beyond the skew
+	service.now = func() time.Time { return issuedAt.Add(16 * time.Minute) }
+	_, err = service.Verify(token, TokenUseAccess)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	// Verifier clock behind the issuer by more than the skew
+	service.now = func() time.Time { return issuedAt.Add(-time.Minute) }
+	_, err = service.Verify(token, TokenUseAccess)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+// TestTokenService_RejectsMissingClaimsUnit tests that tokens signed with the right key but missing sub or jti are rejected
+func TestTokenService_RejectsMissingClaimsUnit(t *testing.T) {
+	keys := newTestKeyManager()
+	service := NewTokenService(keys, testTokenConfig)
+
+	now := time.Now()
+	token, err := keys.Sign(TokenClaims{
+		TokenUse: TokenUseAccess,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    testTokenConfig.Issuer,
+			Audience:  jwt.ClaimStrings{testTokenConfig.Audience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute)),
+		},
+	})
+	assert.NoError(t, err)
+
+	_, err = service.Verify(token, TokenUseAccess)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
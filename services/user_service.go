@@ -1,128 +1,246 @@
 package services
 
 import (
+	"encoding/base64"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"gin-user-app/apperrors"
 	"gin-user-app/dto"
 	"gin-user-app/models"
 	"gin-user-app/repositories"
 	"gin-user-app/utils"
+	"log"
+	"reflect"
 	"strings"
 	"time"
 )
 
+const (
+	userListDefaultLimit = 20
+	userListMaxLimit     = 100
+)
+
+var (
+	// ErrInvalidListQuery dikembalikan jika parameter daftar user tidak valid
+	ErrInvalidListQuery = apperrors.Validation("invalid list query")
+	// ErrUnsupportedPatch dikembalikan untuk Content-Type PATCH yang tidak didukung
+	ErrUnsupportedPatch = apperrors.New(apperrors.ErrUnsupportedMediaType, "Content-Type must be "+dto.MergePatchContentType+" or "+dto.JSONPatchContentType)
+	// ErrInvalidPatch dikembalikan jika patch tidak valid atau menyentuh field yang tidak dikenal
+	ErrInvalidPatch = utils.ErrInvalidPatch
+	// ErrPatchTestFailed dikembalikan jika operasi "test" JSON Patch gagal
+	ErrPatchTestFailed = utils.ErrPatchTestFailed
+	// ErrPreconditionFailed dikembalikan jika If-Match tidak sama dengan version user saat ini
+	ErrPreconditionFailed = apperrors.New(apperrors.ErrPreconditionFailed, "user has been modified, If-Match does not match the current version")
+	// ErrUserModified dikembalikan jika user diubah request lain di antara baca dan simpan
+	ErrUserModified = repositories.ErrUserVersionConflict
+	// ErrDuplicate dikembalikan (sebagai *ConstraintError) jika username atau email sudah dipakai
+	ErrDuplicate = repositories.ErrUniqueViolation
+	// ErrCredentialsChangeForbidden dikembalikan jika pemilik akun tanpa users:update
+	// mengganti password atau email lewat /users/:id
+	ErrCredentialsChangeForbidden = apperrors.Forbidden("use POST /me/password and POST /me/email to change your password or email")
+)
+
+// ConstraintError dikembalikan jika data melanggar constraint database.
+// Field berisi nama field yang bermasalah, misalnya "username" atau "email".
+type ConstraintError = repositories.ConstraintError
+
+// userListCursor adalah isi cursor yang dikirim ke client (base64 JSON)
+type userListCursor struct {
+	Sort string `json:"s"`
+	repositories.UserCursor
+}
+
 // UserService interface untuk layanan pengguna
 type UserService interface {
-	GetAllUsers() ([]dto.UserDTO, error)
+	ListUsers(query dto.UserListQueryDTO) (dto.UserPageDTO, error)
 	GetUserByID(id int) (dto.UserDTO, error)
 	CreateUser(user dto.CreateUserDTO) (dto.UserDTO, error)
-	UpdateUser(id int, user dto.UpdateUserDTO) (dto.UserDTO, error)
-	DeleteUser(id int) error
+	UpdateUser(id int, user dto.UpdateUserDTO, expectedVersion int) (dto.UserDTO, error)
+	PatchUser(id int, tenant string, canChangeCredentials bool, contentType string, patch []byte, expectedVersion int) (dto.UserDTO, error)
+	DeleteUser(id int, expectedVersion int) error
 }
 
 // UserServiceImpl implementasi UserService
 type UserServiceImpl struct {
-	userRepo repositories.UserRepository
+	userRepo       repositories.UserRepository
+	emailVerifier  EmailVerificationService
+	emailPolicy    EmailPolicy
+	passwordPolicy PasswordPolicy
+	initialStatus  string
 }
 
-// NewUserService membuat instance baru UserService
-func NewUserService(userRepo repositories.UserRepository) UserService {
+// NewUserService membuat instance baru UserService. emailVerifier boleh nil,
+// misalnya untuk seeding, sehingga tidak ada email verifikasi yang dikirim.
+// emailPolicy boleh nil, semua domain email diterima. passwordPolicy boleh
+// nil, password hanya dicek panjang minimalnya. initialStatus adalah status
+// user baru, active atau pending (aktif setelah email diverifikasi); kosong
+// berarti active.
+func NewUserService(userRepo repositories.UserRepository, emailVerifier EmailVerificationService, emailPolicy EmailPolicy, passwordPolicy PasswordPolicy, initialStatus string) UserService {
+	if initialStatus == "" {
+		initialStatus = models.UserStatusActive
+	}
 	return &UserServiceImpl{
-		userRepo: userRepo,
+		userRepo:       userRepo,
+		emailVerifier:  emailVerifier,
+		emailPolicy:    emailPolicy,
+		passwordPolicy: passwordPolicy,
+		initialStatus:  initialStatus,
 	}
 }
 
-// GetAllUsers mengambil semua pengguna
-func (s *UserServiceImpl) GetAllUsers() ([]dto.UserDTO, error) {
-	users, err := s.userRepo.GetAll()
+// ListUsers mengambil satu halaman pengguna sesuai filter, sort dan pagination
+func (s *UserServiceImpl) ListUsers(query dto.UserListQueryDTO) (dto.UserPageDTO, error) {
+	params, err := buildUserListParams(query)
 	if err != nil {
-		return nil, err
+		return dto.UserPageDTO{}, err
 	}
 
-	var userDTOs []dto.UserDTO
-	for _, user := range users {
-		userDTOs = append(userDTOs, dto.UserDTO{
-			ID:        user.ID,
-			Username:  user.Username,
-			Email:     user.Email,
-			FirstName: user.FirstName,
-			LastName:  user.LastName,
-			Age:       user.Age,
-			CreatedAt: user.CreatedAt,
-			UpdatedAt: user.UpdatedAt,
-		})
+	result, err := s.userRepo.List(params)
+	if err != nil {
+		return dto.UserPageDTO{}, err
 	}
 
-	return userDTOs, nil
-}
+	page := dto.UserPageDTO{Data: make([]dto.UserDTO, 0, len(result.Users))}
+	for _, user := range result.Users {
+		page.Data = append(page.Data, toUserDTO(user))
+	}
+	if params.IncludeTotal {
+		page.Total = &result.Total
+	}
 
-// GetUserByID mengambil pengguna berdasarkan ID
-func (s *UserServiceImpl) GetUserByID(id int) (dto.UserDTO, error) {
-	user, err := s.userRepo.GetByID(id)
-	if err != nil {
-		return dto.UserDTO{}, err
+	if result.HasMore {
+		if params.Offset != nil {
+			nextOffset := *params.Offset + len(result.Users)
+			page.NextOffset = &nextOffset
+		} else {
+			last := result.Users[len(result.Users)-1]
+			page.NextCursor = encodeUserListCursor(userListCursor{
+				Sort:       query.Sort,
+				UserCursor: repositories.UserCursorFor(last, params.Sort.Field),
+			})
+		}
 	}
 
-	return dto.UserDTO{
-		ID:        user.ID,
-		Username:  user.Username,
-		Email:     user.Email,
-		FirstName: user.FirstName,
-		LastName:  user.LastName,
-		Age:       user.Age,
-		CreatedAt: user.CreatedAt,
-		UpdatedAt: user.UpdatedAt,
-	}, nil
+	return page, nil
 }
 
-// CreateUser membuat pengguna baru
-func (s *UserServiceImpl) CreateUser(user dto.CreateUserDTO) (dto.UserDTO, error) {
-	// Validasi username
-	if len(user.Username) < utils.UsernameMinLength || len(user.Username) > utils.UsernameMaxLength {
-		return dto.UserDTO{}, errors.New("username must be 3-20 characters")
-	}
-	if !utils.UsernameRegex.MatchString(user.Username) {
-		return dto.UserDTO{}, errors.New("username must contain only letters and numbers")
+// buildUserListParams memvalidasi query dan mengubahnya menjadi parameter repository
+func buildUserListParams(query dto.UserListQueryDTO) (repositories.UserListParams, error) {
+	params := repositories.UserListParams{
+		Limit:        query.Limit,
+		Offset:       query.Offset,
+		IncludeTotal: query.IncludeTotal,
+		Filter: repositories.UserFilter{
+			UsernamePrefix: query.Username,
+			EmailPrefix:    query.Email,
+			NamePrefix:     query.Name,
+			AgeMin:         query.AgeMin,
+			AgeMax:         query.AgeMax,
+			CreatedAfter:   query.CreatedAfter,
+			CreatedBefore:  query.CreatedBefore,
+			Status:         query.Status,
+		},
 	}
 
-	// Validasi email
-	if utils.EmailRequired && user.Email == "" {
-		return dto.UserDTO{}, errors.New("email is required")
+	if params.Limit == 0 {
+		params.Limit = userListDefaultLimit
+	}
+	if params.Limit < 1 || params.Limit > userListMaxLimit {
+		return params, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidListQuery, userListMaxLimit)
+	}
+	if params.Offset != nil && *params.Offset < 0 {
+		return params, fmt.Errorf("%w: offset must not be negative", ErrInvalidListQuery)
 	}
-	if !utils.EmailRegex.MatchString(user.Email) {
-		return dto.UserDTO{}, errors.New("invalid email format")
+	if params.Offset != nil && query.Cursor != "" {
+		return params, fmt.Errorf("%w: cursor and offset cannot be combined", ErrInvalidListQuery)
 	}
-	if !strings.HasSuffix(user.Email, utils.EmailGmailSuffix) {
-		return dto.UserDTO{}, errors.New("email must be a valid Gmail address (@gmail.com)")
+	if _, ok := accountStatusTransitions[query.Status]; query.Status != "" && !ok {
+		return params, fmt.Errorf("%w: unknown status %q", ErrInvalidListQuery, query.Status)
 	}
 
-	// Validasi password
-	if len(user.Password) < utils.PasswordMinLength {
-		return dto.UserDTO{}, errors.New("password must be at least 8 characters")
+	params.Sort = repositories.UserSort{Field: strings.TrimPrefix(query.Sort, "-"), Desc: strings.HasPrefix(query.Sort, "-")}
+	if params.Sort.Field == "" {
+		params.Sort.Field = "id"
+	}
+	if !repositories.IsSortableUserField(params.Sort.Field) {
+		return params, fmt.Errorf("%w: cannot sort by %q", ErrInvalidListQuery, params.Sort.Field)
 	}
 
-	// Validasi first name
-	if user.FirstName != "" {
-		if len(user.FirstName) < utils.NameMinLength || len(user.FirstName) > utils.NameMaxLength {
-			return dto.UserDTO{}, errors.New("first name must be 3-20 characters")
+	if query.Cursor != "" {
+		cursor, err := decodeUserListCursor(query.Cursor)
+		if err != nil || cursor.Sort != query.Sort {
+			return params, fmt.Errorf("%w: cursor is invalid or does not match sort", ErrInvalidListQuery)
 		}
+		params.After = &cursor.UserCursor
 	}
 
-	// Validasi last name
-	if user.LastName != "" {
-		if len(user.LastName) < utils.NameMinLength || len(user.LastName) > utils.NameMaxLength {
-			return dto.UserDTO{}, errors.New("last name must be 3-20 characters")
-		}
+	return params, nil
+}
+
+func encodeUserListCursor(cursor userListCursor) string {
+	raw, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeUserListCursor(encoded string) (userListCursor, error) {
+	var cursor userListCursor
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return cursor, err
+	}
+	err = json.Unmarshal(raw, &cursor)
+	return cursor, err
+}
+
+// toUserDTO mengubah model User menjadi UserDTO
+func toUserDTO(user models.User) dto.UserDTO {
+	userDTO := dto.UserDTO{
+		ID:              user.ID,
+		Username:        user.Username,
+		Email:           user.Email,
+		FirstName:       user.FirstName,
+		LastName:        user.LastName,
+		Age:             user.Age,
+		EmailVerifiedAt: user.EmailVerifiedAt,
+		Status:          EffectiveStatus(user, time.Now()),
+		CreatedAt:       user.CreatedAt,
+		UpdatedAt:       user.UpdatedAt,
+		Version:         user.Version,
+	}
+	if user.PhoneVerifiedAt != nil {
+		userDTO.Phone = user.Phone
+	}
+	// Alasan dan masa berlaku hanya relevan selama status belum kembali active
+	if userDTO.Status != models.UserStatusActive {
+		userDTO.StatusReason = user.StatusReason
+		userDTO.StatusExpiresAt = user.StatusExpiresAt
 	}
+	return userDTO
+}
 
-	// Validasi age
-	if user.Age != nil && *user.Age <= utils.AgeMin {
-		return dto.UserDTO{}, errors.New("age must be greater than 15")
+// GetUserByID mengambil pengguna berdasarkan ID
+func (s *UserServiceImpl) GetUserByID(id int) (dto.UserDTO, error) {
+	user, err := s.userRepo.GetByID(id)
+	if err != nil {
+		return dto.UserDTO{}, err
 	}
 
-	// Cek apakah username sudah digunakan
-	existingUser, _ := s.userRepo.FindByUsername(user.Username)
-	if existingUser.ID != 0 {
-		return dto.UserDTO{}, errors.New("username already taken")
+	return toUserDTO(user), nil
+}
+
+// CreateUser membuat pengguna baru
+func (s *UserServiceImpl) CreateUser(user dto.CreateUserDTO) (dto.UserDTO, error) {
+	account := models.User{Username: user.Username, Email: user.Email, FirstName: user.FirstName, LastName: user.LastName}
+	if err := s.rulesFor(user.Tenant, account).Validate(map[string]interface{}{
+		"username":   user.Username,
+		"email":      user.Email,
+		"password":   user.Password,
+		"first_name": user.FirstName,
+		"last_name":  user.LastName,
+		"age":        user.Age,
+	}); err != nil {
+		return dto.UserDTO{}, err
 	}
 
 	// Hash password sebelum disimpan
@@ -131,133 +249,369 @@ func (s *UserServiceImpl) CreateUser(user dto.CreateUserDTO) (dto.UserDTO, error
 		return dto.UserDTO{}, err
 	}
 
+	now := time.Now()
 	newUser := models.User{
-		Username:  user.Username,
-		Password:  hashedPassword,
-		Email:     user.Email,
-		FirstName: user.FirstName,
-		LastName:  user.LastName,
-		Age:       user.Age,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		Username:          user.Username,
+		Password:          hashedPassword,
+		PasswordChangedAt: &now,
+		Email:             user.Email,
+		FirstName:         user.FirstName,
+		LastName:          user.LastName,
+		Age:               user.Age,
+		Status:            s.initialStatus,
+		CreatedAt:         now,
+		UpdatedAt:         now,
 	}
 
-	// Simpan user ke database
+	// Simpan user ke database. Keunikan username dan email dijaga constraint
+	// database, duplikat dikembalikan sebagai *ConstraintError.
 	createdUser, err := s.userRepo.Create(newUser)
 	if err != nil {
 		return dto.UserDTO{}, err
 	}
 
-	return dto.UserDTO{
-		ID:        createdUser.ID,
-		Username:  createdUser.Username,
-		Email:     createdUser.Email,
-		FirstName: createdUser.FirstName,
-		LastName:  createdUser.LastName,
-		Age:       createdUser.Age,
-		CreatedAt: createdUser.CreatedAt,
-		UpdatedAt: createdUser.UpdatedAt,
-	}, nil
+	// Kirim email verifikasi, kegagalan kirim tidak membatalkan pembuatan user
+	s.sendVerification(createdUser)
+
+	return toUserDTO(createdUser), nil
 }
 
-// UpdateUser memperbarui pengguna
-func (s *UserServiceImpl) UpdateUser(id int, user dto.UpdateUserDTO) (dto.UserDTO, error) {
-	// Validasi username
-	if user.Username != "" {
-		if len(user.Username) < utils.UsernameMinLength || len(user.Username) > utils.UsernameMaxLength {
-			return dto.UserDTO{}, errors.New("username must be 3-20 characters")
-		}
-		if !utils.UsernameRegex.MatchString(user.Username) {
-			return dto.UserDTO{}, errors.New("username must contain only letters and numbers")
-		}
+// UpdateUser mengganti seluruh data user (PUT). Age yang tidak dikirim menjadi
+// null; password bukan bagian dari representasi user dan hanya diganti jika diisi.
+// Tanpa user.CanChangeCredentials password dan email tidak boleh berubah.
+// expectedVersion berasal dari If-Match, 0 berarti tanpa precondition.
+func (s *UserServiceImpl) UpdateUser(id int, user dto.UpdateUserDTO, expectedVersion int) (dto.UserDTO, error) {
+	values := map[string]interface{}{
+		"username":   user.Username,
+		"email":      user.Email,
+		"first_name": user.FirstName,
+		"last_name":  user.LastName,
+		"age":        user.Age,
 	}
-
-	// Validasi email
-	if user.Email != "" {
-		if !utils.EmailRegex.MatchString(user.Email) {
-			return dto.UserDTO{}, errors.New("invalid email format")
-		}
-		if !strings.HasSuffix(user.Email, utils.EmailGmailSuffix) {
-			return dto.UserDTO{}, errors.New("email must be a valid Gmail address (@gmail.com)")
-		}
+	if user.Password != "" {
+		values["password"] = user.Password
+	}
+	account := models.User{Username: user.Username, Email: user.Email, FirstName: user.FirstName, LastName: user.LastName}
+	if err := s.rulesFor(user.Tenant, account).Validate(values); err != nil {
+		return dto.UserDTO{}, err
 	}
 
-	// Validasi password
+	// Ambil user yang ada
+	existingUser, err := s.userRepo.GetByID(id)
+	if err != nil {
+		return dto.UserDTO{}, err
+	}
+	if err := checkVersion(existingUser, expectedVersion); err != nil {
+		return dto.UserDTO{}, err
+	}
+	emailChanged := !sameEmail(user.Email, existingUser.Email)
+	if !user.CanChangeCredentials && (user.Password != "" || emailChanged) {
+		return dto.UserDTO{}, ErrCredentialsChangeForbidden
+	}
+	previous := existingUser
 	if user.Password != "" {
-		if len(user.Password) < utils.PasswordMinLength {
-			return dto.UserDTO{}, errors.New("password must be at least 8 characters")
+		if err := s.checkPasswordHistory(existingUser, user.Password); err != nil {
+			return dto.UserDTO{}, err
 		}
 	}
 
-	// Validasi first name
-	if user.FirstName != "" {
-		if len(user.FirstName) < utils.NameMinLength || len(user.FirstName) > utils.NameMaxLength {
-			return dto.UserDTO{}, errors.New("first name must be 3-20 characters")
-		}
+	if emailChanged {
+		existingUser.EmailVerifiedAt = nil
 	}
-
-	// Validasi last name
-	if user.LastName != "" {
-		if len(user.LastName) < utils.NameMinLength || len(user.LastName) > utils.NameMaxLength {
-			return dto.UserDTO{}, errors.New("last name must be 3-20 characters")
+	existingUser.Username = user.Username
+	existingUser.Email = user.Email
+	existingUser.FirstName = user.FirstName
+	existingUser.LastName = user.LastName
+	existingUser.Age = user.Age
+	if user.Password != "" {
+		if err := setUserPassword(&existingUser, user.Password); err != nil {
+			return dto.UserDTO{}, err
 		}
 	}
 
-	// Validasi age
-	if user.Age != nil && *user.Age <= utils.AgeMin {
-		return dto.UserDTO{}, errors.New("age must be greater than 15")
+	updated, err := s.saveUser(existingUser, emailChanged, expectedVersion)
+	if err == nil && user.Password != "" {
+		rememberPassword(s.passwordPolicy, previous)
 	}
+	return updated, err
+}
 
-	// Ambil user yang ada
+// PatchUser mengubah sebagian data user dengan JSON Merge Patch (RFC 7396) atau
+// JSON Patch (RFC 6902) sesuai contentType. Patch diterapkan ke representasi
+// user (username, email, first_name, last_name, age); password hanya bisa
+// di-set. Hanya field yang berubah yang divalidasi, email dengan kebijakan
+// domain milik tenant dan password dengan kebijakan password serta riwayatnya.
+// Tanpa canChangeCredentials patch yang mengubah password atau email ditolak.
+func (s *UserServiceImpl) PatchUser(id int, tenant string, canChangeCredentials bool, contentType string, patch []byte, expectedVersion int) (dto.UserDTO, error) {
 	existingUser, err := s.userRepo.GetByID(id)
 	if err != nil {
 		return dto.UserDTO{}, err
 	}
+	if err := checkVersion(existingUser, expectedVersion); err != nil {
+		return dto.UserDTO{}, err
+	}
 
-	// Update field yang diisi
-	if user.Username != "" {
-		existingUser.Username = user.Username
+	original := userPatchDocument(existingUser)
+	var patched interface{}
+	switch contentType {
+	case dto.MergePatchContentType:
+		patched, err = utils.MergePatch(original, patch)
+	case dto.JSONPatchContentType:
+		patched, err = utils.ApplyJSONPatch(original, patch)
+	default:
+		return dto.UserDTO{}, ErrUnsupportedPatch
 	}
-	if user.Email != "" {
-		existingUser.Email = user.Email
+	if err != nil {
+		return dto.UserDTO{}, err
 	}
-	if user.Password != "" {
-		hashedPassword, err := utils.HashPassword(user.Password)
+
+	document, ok := patched.(map[string]interface{})
+	if !ok {
+		return dto.UserDTO{}, fmt.Errorf("%w: patched user must be an object", ErrInvalidPatch)
+	}
+	for field := range document {
+		if !containsString(userPatchFields, field) {
+			return dto.UserDTO{}, fmt.Errorf("%w: unknown field %q", ErrInvalidPatch, field)
+		}
+	}
+
+	// Field yang dihapus dianggap null; field yang tidak berubah dilewati.
+	// Semua field yang berubah divalidasi sekaligus sebelum diterapkan.
+	changed := map[string]interface{}{}
+	for _, field := range userPatchFields {
+		value := document[field]
+		if reflect.DeepEqual(value, original[field]) {
+			continue
+		}
+		if field == "password" && value == nil {
+			// Password tidak ada di dokumen, null berarti tidak diubah
+			continue
+		}
+		changed[field] = value
+	}
+	_, passwordChanged := changed["password"]
+	emailChanged := false
+	if value, ok := changed["email"]; ok {
+		email, _ := value.(string)
+		emailChanged = !sameEmail(email, existingUser.Email)
+	}
+	if !canChangeCredentials && (passwordChanged || emailChanged) {
+		return dto.UserDTO{}, ErrCredentialsChangeForbidden
+	}
+	rules := s.rulesFor(tenant, patchedAccount(existingUser, document))
+	if passwordChanged && s.passwordPolicy != nil {
+		history, err := s.passwordPolicy.HistoryRules(existingUser)
 		if err != nil {
 			return dto.UserDTO{}, err
 		}
-		existingUser.Password = hashedPassword
+		rules = rules.With("password", history...)
 	}
-	if user.FirstName != "" {
-		existingUser.FirstName = user.FirstName
+	if err := rules.Validate(changed); err != nil {
+		return dto.UserDTO{}, err
 	}
-	if user.LastName != "" {
-		existingUser.LastName = user.LastName
+
+	previous := existingUser
+	if emailChanged {
+		existingUser.EmailVerifiedAt = nil
 	}
-	if user.Age != nil {
-		existingUser.Age = user.Age
+	for _, field := range userPatchFields {
+		value, ok := changed[field]
+		if !ok {
+			continue
+		}
+		if err := applyUserPatchField(&existingUser, field, value); err != nil {
+			return dto.UserDTO{}, err
+		}
+	}
+
+	updated, err := s.saveUser(existingUser, emailChanged, expectedVersion)
+	if err == nil && passwordChanged {
+		rememberPassword(s.passwordPolicy, previous)
+	}
+	return updated, err
+}
+
+// patchedAccount mengembalikan user dengan username, email dan nama dari
+// dokumen hasil patch, dipakai untuk mengecek data pribadi di password
+func patchedAccount(user models.User, document map[string]interface{}) models.User {
+	user.Username, _ = document["username"].(string)
+	user.Email, _ = document["email"].(string)
+	user.FirstName, _ = document["first_name"].(string)
+	user.LastName, _ = document["last_name"].(string)
+	return user
+}
+
+// checkPasswordHistory menolak password yang sama dengan password user saat
+// ini atau password sebelumnya sesuai riwayat kebijakan password
+func (s *UserServiceImpl) checkPasswordHistory(user models.User, password string) error {
+	if s.passwordPolicy == nil {
+		return nil
+	}
+	history, err := s.passwordPolicy.HistoryRules(user)
+	if err != nil {
+		return err
+	}
+	return utils.RuleSet{{Field: "password", Rules: history}}.Validate(map[string]interface{}{"password": password})
+}
+
+// sameEmail melaporkan apakah dua email sama setelah kanonikalisasi, sehingga
+// perubahan huruf besar kecil saja tidak dianggap mengganti email
+func sameEmail(a, b string) bool {
+	return utils.CanonicalEmail(a) == utils.CanonicalEmail(b)
+}
+
+// setUserPassword meng-hash password baru dan mencatat waktu penggantiannya
+func setUserPassword(user *models.User, password string) error {
+	hashedPassword, err := utils.HashPassword(password)
+	if err != nil {
+		return err
 	}
-	existingUser.UpdatedAt = time.Now()
+	now := time.Now()
+	user.Password = hashedPassword
+	user.PasswordChangedAt = &now
+	return nil
+}
+
+// checkVersion membandingkan version user dengan If-Match, 0 berarti tanpa precondition
+func checkVersion(user models.User, expectedVersion int) error {
+	if expectedVersion != 0 && user.Version != expectedVersion {
+		return ErrPreconditionFailed
+	}
+	return nil
+}
 
-	// Simpan perubahan
-	updatedUser, err := s.userRepo.Update(existingUser)
+// saveUser menyimpan perubahan user dan mengirim verifikasi jika email berubah.
+// Jika user diubah request lain sejak dibaca, If-Match yang dikirim sudah tidak
+// berlaku lagi sehingga dikembalikan ErrPreconditionFailed.
+func (s *UserServiceImpl) saveUser(user models.User, emailChanged bool, expectedVersion int) (dto.UserDTO, error) {
+	user.UpdatedAt = time.Now()
+	updatedUser, err := s.userRepo.Update(user)
+	if errors.Is(err, ErrUserModified) && expectedVersion != 0 {
+		return dto.UserDTO{}, ErrPreconditionFailed
+	}
 	if err != nil {
 		return dto.UserDTO{}, err
 	}
 
-	return dto.UserDTO{
-		ID:        updatedUser.ID,
-		Username:  updatedUser.Username,
-		Email:     updatedUser.Email,
-		FirstName: updatedUser.FirstName,
-		LastName:  updatedUser.LastName,
-		Age:       updatedUser.Age,
-		CreatedAt: updatedUser.CreatedAt,
-		UpdatedAt: updatedUser.UpdatedAt,
-	}, nil
+	// Email baru harus diverifikasi ulang
+	if emailChanged {
+		s.sendVerification(updatedUser)
+	}
+
+	return toUserDTO(updatedUser), nil
+}
+
+// userPatchFields adalah field yang boleh diubah lewat PATCH, dalam urutan validasi
+var userPatchFields = []string{"username", "email", "password", "first_name", "last_name", "age"}
+
+// userPatchDocument mengubah user menjadi dokumen JSON yang menjadi target patch
+func userPatchDocument(user models.User) map[string]interface{} {
+	document := map[string]interface{}{
+		"username":   user.Username,
+		"email":      user.Email,
+		"first_name": user.FirstName,
+		"last_name":  user.LastName,
+		"age":        nil,
+	}
+	if user.Age != nil {
+		document["age"] = float64(*user.Age)
+	}
+	return document
+}
+
+// applyUserPatchField menerapkan satu field hasil patch yang sudah divalidasi
+// ke user. Null mengosongkan first_name dan last_name serta menghapus age.
+func applyUserPatchField(user *models.User, field string, value interface{}) error {
+	switch field {
+	case "username":
+		user.Username, _ = value.(string)
+	case "email":
+		user.Email, _ = value.(string)
+	case "password":
+		return setUserPassword(user, value.(string))
+	case "first_name":
+		user.FirstName, _ = value.(string)
+	case "last_name":
+		user.LastName, _ = value.(string)
+	case "age":
+		user.Age = nil
+		if number, ok := value.(float64); ok {
+			age := int(number)
+			user.Age = &age
+		}
+	}
+	return nil
 }
 
-// DeleteUser menghapus pengguna
-func (s *UserServiceImpl) DeleteUser(id int) error {
-	return s.userRepo.Delete(id)
+// userRules adalah aturan validasi field user yang dipakai bersama oleh
+// create, update, patch dan update profil. Urutannya menentukan urutan error.
+var userRules = utils.RuleSet{
+	{Field: "username", Rules: []utils.Rule{
+		utils.Required(),
+		utils.String(),
+		utils.Length(utils.UsernameMinLength, utils.UsernameMaxLength),
+		utils.Pattern("alphanumeric", utils.UsernameRegex, "{field} must contain only letters and numbers"),
+	}},
+	{Field: "email", Rules: emailRules()},
+	// Aturan password lainnya berasal dari PasswordPolicy, lihat rulesFor
+	{Field: "password", Rules: []utils.Rule{
+		utils.Required(),
+	}},
+	{Field: "first_name", Rules: nameRules()},
+	{Field: "last_name", Rules: nameRules()},
+	{Field: "age", Rules: []utils.Rule{
+		utils.Integer(),
+		utils.GreaterThan(utils.AgeMin),
+	}},
+}
+
+// rulesFor mengembalikan userRules ditambah kebijakan domain email tenant dan
+// kebijakan password. account adalah user setelah perubahan, username, email
+// dan namanya tidak boleh ada di password.
+func (s *UserServiceImpl) rulesFor(tenant string, account models.User) utils.RuleSet {
+	rules := userRules.With("password", passwordRules(s.passwordPolicy, account)...)
+	if s.emailPolicy == nil {
+		return rules
+	}
+	return rules.With("email", s.emailPolicy.Rules(tenant)...)
+}
+
+// emailRules memvalidasi format email. Domain yang boleh dipakai diatur
+// EmailPolicy, lihat rulesFor.
+func emailRules() []utils.Rule {
+	rules := []utils.Rule{
+		utils.String(),
+		utils.Pattern("email", utils.EmailRegex, "invalid email format"),
+	}
+	if utils.EmailRequired {
+		rules = append([]utils.Rule{utils.Required()}, rules...)
+	}
+	return rules
+}
+
+// nameRules memvalidasi first name atau last name, nama kosong diperbolehkan
+func nameRules() []utils.Rule {
+	return []utils.Rule{
+		utils.String(),
+		utils.Length(utils.NameMinLength, utils.NameMaxLength),
+	}
+}
+
+// sendVerification mengirim email verifikasi jika emailVerifier tersedia
+func (s *UserServiceImpl) sendVerification(user models.User) {
+	if s.emailVerifier == nil {
+		return
+	}
+	if err := s.emailVerifier.SendVerification(user); err != nil {
+		log.Println("Failed to send verification email:", err)
+	}
+}
+
+// DeleteUser menghapus pengguna. expectedVersion berasal dari If-Match, 0 berarti tanpa precondition.
+func (s *UserServiceImpl) DeleteUser(id int, expectedVersion int) error {
+	err := s.userRepo.Delete(id, expectedVersion)
+	if errors.Is(err, ErrUserModified) {
+		return ErrPreconditionFailed
+	}
+	return err
 }
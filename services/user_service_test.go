@@ -1,9 +1,10 @@
 package services
 
 import (
-	"errors"
+	"gin-user-app/apperrors"
 	"gin-user-app/dto"
 	"gin-user-app/models"
+	"gin-user-app/repositories"
 	"testing"
 	"time"
 
@@ -16,9 +17,9 @@ type MockUserRepository struct {
 	mock.Mock
 }
 
-func (m *MockUserRepository) GetAll() ([]models.User, error) {
-	args := m.Called()
-	return args.Get(0).([]models.User), args.Error(1)
+func (m *MockUserRepository) List(params repositories.UserListParams) (repositories.UserListResult, error) {
+	args := m.Called(params)
+	return args.Get(0).(repositories.UserListResult), args.Error(1)
 }
 
 func (m *MockUserRepository) GetByID(id int) (models.User, error) {
@@ -31,6 +32,16 @@ func (m *MockUserRepository) FindByUsername(username string) (models.User, error
 	return args.Get(0).(models.User), args.Error(1)
 }
 
+func (m *MockUserRepository) FindByEmail(email string) (models.User, error) {
+	args := m.Called(email)
+	return args.Get(0).(models.User), args.Error(1)
+}
+
+func (m *MockUserRepository) FindByPhone(phone string) (models.User, error) {
+	args := m.Called(phone)
+	return args.Get(0).(models.User), args.Error(1)
+}
+
 func (m *MockUserRepository) Create(user models.User) (models.User, error) {
 	args := m.Called(user)
 	return args.Get(0).(models.User), args.Error(1)
@@ -41,15 +52,15 @@ func (m *MockUserRepository) Update(user models.User) (models.User, error) {
 	return args.Get(0).(models.User), args.Error(1)
 }
 
-func (m *MockUserRepository) Delete(id int) error {
-	args := m.Called(id)
+func (m *MockUserRepository) Delete(id int, expectedVersion int) error {
+	args := m.Called(id, expectedVersion)
 	return args.Error(0)
 }
 
 // TestCreateUser_ValidInputUnit tests creating a user with valid input
 func TestCreateUser_ValidInputUnit(t *testing.T) {
 	mockRepo := new(MockUserRepository)
-	service := NewUserService(mockRepo)
+	service := NewUserService(mockRepo, nil, nil, nil, "")
 	age := 20
 
 	createUserDTO := dto.CreateUserDTO{
@@ -62,7 +73,6 @@ func TestCreateUser_ValidInputUnit(t *testing.T) {
 	}
 
 	// Mock repository behavior
-	mockRepo.On("FindByUsername", createUserDTO.Username).Return(models.User{}, nil)
 	mockRepo.On("Create", mock.Anything).Return(models.User{
 		ID:        1,
 		Username:  createUserDTO.Username,
@@ -87,7 +97,7 @@ func TestCreateUser_ValidInputUnit(t *testing.T) {
 // TestCreateUser_InvalidUsernameUnit tests creating a user with an invalid username
 func TestCreateUser_InvalidUsernameUnit(t *testing.T) {
 	mockRepo := new(MockUserRepository)
-	service := NewUserService(mockRepo)
+	service := NewUserService(mockRepo, nil, nil, nil, "")
 	age := 20
 
 	createUserDTO := dto.CreateUserDTO{
@@ -110,7 +120,7 @@ func TestCreateUser_InvalidUsernameUnit(t *testing.T) {
 // TestCreateUser_NonGmailEmailUnit tests creating a user with a non-Gmail email
 func TestCreateUser_NonGmailEmailUnit(t *testing.T) {
 	mockRepo := new(MockUserRepository)
-	service := NewUserService(mockRepo)
+	service := NewUserService(mockRepo, nil, newGmailOnlyPolicy(t), nil, "")
 	age := 20
 
 	createUserDTO := dto.CreateUserDTO{
@@ -124,7 +134,7 @@ func TestCreateUser_NonGmailEmailUnit(t *testing.T) {
 
 	user, err := service.CreateUser(createUserDTO)
 	assert.Error(t, err)
-	assert.Equal(t, "email must be a valid Gmail address (@gmail.com)", err.Error())
+	assert.Equal(t, "email domain is not allowed", err.Error())
 	assert.Equal(t, dto.UserDTO{}, user)
 	mockRepo.AssertNotCalled(t, "FindByUsername")
 	mockRepo.AssertNotCalled(t, "Create")
@@ -133,7 +143,7 @@ func TestCreateUser_NonGmailEmailUnit(t *testing.T) {
 // TestCreateUser_ShortPasswordUnit tests creating a user with a short password
 func TestCreateUser_ShortPasswordUnit(t *testing.T) {
 	mockRepo := new(MockUserRepository)
-	service := NewUserService(mockRepo)
+	service := NewUserService(mockRepo, nil, nil, nil, "")
 	age := 20
 
 	createUserDTO := dto.CreateUserDTO{
@@ -156,7 +166,7 @@ func TestCreateUser_ShortPasswordUnit(t *testing.T) {
 // TestCreateUser_InvalidAgeUnit tests creating a user with an invalid age
 func TestCreateUser_InvalidAgeUnit(t *testing.T) {
 	mockRepo := new(MockUserRepository)
-	service := NewUserService(mockRepo)
+	service := NewUserService(mockRepo, nil, nil, nil, "")
 	age := 14 // Too young
 
 	createUserDTO := dto.CreateUserDTO{
@@ -176,10 +186,10 @@ func TestCreateUser_InvalidAgeUnit(t *testing.T) {
 	mockRepo.AssertNotCalled(t, "Create")
 }
 
-// TestCreateUser_UsernameTakenUnit tests creating a user with a taken username
+// TestCreateUser_UsernameTakenUnit tests that a duplicate username reported by the database names the field
 func TestCreateUser_UsernameTakenUnit(t *testing.T) {
 	mockRepo := new(MockUserRepository)
-	service := NewUserService(mockRepo)
+	service := NewUserService(mockRepo, nil, nil, nil, "")
 	age := 20
 
 	createUserDTO := dto.CreateUserDTO{
@@ -191,29 +201,33 @@ func TestCreateUser_UsernameTakenUnit(t *testing.T) {
 		Age:       &age,
 	}
 
-	// Mock username already taken
-	mockRepo.On("FindByUsername", createUserDTO.Username).Return(models.User{ID: 1}, nil)
+	// Mock unique constraint violation on username
+	mockRepo.On("Create", mock.Anything).Return(models.User{}, &repositories.ConstraintError{Kind: repositories.ErrUniqueViolation, Field: "username", Constraint: "uni_users_username"})
 
 	user, err := service.CreateUser(createUserDTO)
-	assert.Error(t, err)
+	assert.ErrorIs(t, err, ErrDuplicate)
 	assert.Equal(t, "username already taken", err.Error())
+	var constraint *ConstraintError
+	assert.ErrorAs(t, err, &constraint)
+	assert.Equal(t, "username", constraint.Field)
 	assert.Equal(t, dto.UserDTO{}, user)
 	mockRepo.AssertExpectations(t)
-	mockRepo.AssertNotCalled(t, "Create")
+	mockRepo.AssertNotCalled(t, "FindByUsername", mock.Anything)
 }
 
 // TestUpdateUser_ValidInputUnit tests updating a user with valid input
 func TestUpdateUser_ValidInputUnit(t *testing.T) {
 	mockRepo := new(MockUserRepository)
-	service := NewUserService(mockRepo)
+	service := NewUserService(mockRepo, nil, nil, nil, "")
 	age := 25
 
 	updateUserDTO := dto.UpdateUserDTO{
-		Username:  "newuser",
-		Email:     "new@gmail.com",
-		FirstName: "New",
-		LastName:  "User",
-		Age:       &age,
+		Username:             "newuser",
+		Email:                "new@gmail.com",
+		FirstName:            "New",
+		LastName:             "User",
+		Age:                  &age,
+		CanChangeCredentials: true,
 	}
 
 	// Mock existing user
@@ -240,7 +254,7 @@ func TestUpdateUser_ValidInputUnit(t *testing.T) {
 		UpdatedAt: time.Now(),
 	}, nil)
 
-	user, err := service.UpdateUser(1, updateUserDTO)
+	user, err := service.UpdateUser(1, updateUserDTO, 0)
 	assert.NoError(t, err)
 	assert.Equal(t, updateUserDTO.Username, user.Username)
 	assert.Equal(t, updateUserDTO.Email, user.Email)
@@ -250,16 +264,63 @@ func TestUpdateUser_ValidInputUnit(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+// TestUpdateUser_SelfEditCannotChangeCredentialsUnit tests that an account
+// owner without users:update cannot change the password or email through
+// PUT or PATCH /users/:id, but can still change the other fields
+func TestUpdateUser_SelfEditCannotChangeCredentialsUnit(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	service := NewUserService(mockRepo, nil, nil, nil, "")
+	existingUser := models.User{ID: 1, Username: "alice", Email: "alice@gmail.com", FirstName: "Alice", LastName: "Smith", Version: 1}
+	mockRepo.On("GetByID", 1).Return(existingUser, nil)
+
+	_, err := service.UpdateUser(1, dto.UpdateUserDTO{Username: "alice", Password: "new-password-123", Email: "alice@gmail.com", FirstName: "Alice", LastName: "Smith"}, 0)
+	assert.ErrorIs(t, err, ErrCredentialsChangeForbidden)
+	_, err = service.UpdateUser(1, dto.UpdateUserDTO{Username: "alice", Email: "mallory@gmail.com", FirstName: "Alice", LastName: "Smith"}, 0)
+	assert.ErrorIs(t, err, ErrCredentialsChangeForbidden)
+	_, err = service.PatchUser(1, "", false, dto.MergePatchContentType, []byte(`{"password":"new-password-123"}`), 0)
+	assert.ErrorIs(t, err, ErrCredentialsChangeForbidden)
+	_, err = service.PatchUser(1, "", false, dto.JSONPatchContentType, []byte(`[{"op":"replace","path":"/email","value":"mallory@gmail.com"}]`), 0)
+	assert.ErrorIs(t, err, ErrCredentialsChangeForbidden)
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything)
+
+	mockRepo.On("Update", mock.MatchedBy(func(user models.User) bool {
+		return user.LastName == "Jones" && user.Password == "" && user.Email == "alice@gmail.com"
+	})).Return(models.User{ID: 1, Username: "alice", Email: "alice@gmail.com", LastName: "Jones", Version: 2}, nil)
+	_, err = service.PatchUser(1, "", false, dto.MergePatchContentType, []byte(`{"last_name":"Jones"}`), 0)
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+// TestUpdateUser_EmailCaseChangeUnit tests that changing only the case of the
+// email is not a credential change and keeps the verification
+func TestUpdateUser_EmailCaseChangeUnit(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	service := NewUserService(mockRepo, nil, nil, nil, "")
+	verifiedAt := time.Now()
+	existingUser := models.User{ID: 1, Username: "alice", Email: "alice@gmail.com", FirstName: "Alice", LastName: "Smith", EmailVerifiedAt: &verifiedAt, Version: 1}
+	mockRepo.On("GetByID", 1).Return(existingUser, nil)
+	mockRepo.On("Update", mock.MatchedBy(func(user models.User) bool {
+		return user.Email == "Alice@Gmail.com" && user.EmailVerifiedAt != nil
+	})).Return(models.User{ID: 1, Username: "alice", Email: "Alice@Gmail.com", EmailVerifiedAt: &verifiedAt, Version: 2}, nil)
+
+	_, err := service.UpdateUser(1, dto.UpdateUserDTO{Username: "alice", Email: "Alice@Gmail.com", FirstName: "Alice", LastName: "Smith"}, 0)
+	assert.NoError(t, err)
+	_, err = service.PatchUser(1, "", false, dto.MergePatchContentType, []byte(`{"email":"Alice@Gmail.com"}`), 0)
+	assert.NoError(t, err)
+	mockRepo.AssertNumberOfCalls(t, "Update", 2)
+}
+
 // TestUpdateUser_InvalidUsernameUnit tests updating a user with an invalid username
 func TestUpdateUser_InvalidUsernameUnit(t *testing.T) {
 	mockRepo := new(MockUserRepository)
-	service := NewUserService(mockRepo)
+	service := NewUserService(mockRepo, nil, nil, nil, "")
 
 	updateUserDTO := dto.UpdateUserDTO{
 		Username: "ab", // Too short
+		Email:    "test@gmail.com",
 	}
 
-	user, err := service.UpdateUser(1, updateUserDTO)
+	user, err := service.UpdateUser(1, updateUserDTO, 0)
 	assert.Error(t, err)
 	assert.Equal(t, "username must be 3-20 characters", err.Error())
 	assert.Equal(t, dto.UserDTO{}, user)
@@ -267,18 +328,43 @@ func TestUpdateUser_InvalidUsernameUnit(t *testing.T) {
 	mockRepo.AssertNotCalled(t, "Update")
 }
 
+// TestCreateUser_ReportsAllInvalidFieldsUnit tests that every invalid field is reported in one error
+func TestCreateUser_ReportsAllInvalidFieldsUnit(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	service := NewUserService(mockRepo, nil, newGmailOnlyPolicy(t), nil, "")
+	age := 12
+
+	_, err := service.CreateUser(dto.CreateUserDTO{
+		Username: "ab",
+		Email:    "test@yahoo.com",
+		Password: "short",
+		LastName: "Jo",
+		Age:      &age,
+	})
+	assert.ErrorIs(t, err, apperrors.ErrValidation)
+	assert.Equal(t, []apperrors.FieldError{
+		{Field: "username", Code: "length", Message: "username must be 3-20 characters", Params: map[string]interface{}{"min": 3, "max": 20}},
+		{Field: "email", Code: "domain_not_allowed", Message: "email domain is not allowed", Params: map[string]interface{}{"allowed": []string{"gmail.com"}}},
+		{Field: "password", Code: "min_length", Message: "password must be at least 8 characters", Params: map[string]interface{}{"min": 8}},
+		{Field: "last_name", Code: "length", Message: "last name must be 3-20 characters", Params: map[string]interface{}{"min": 3, "max": 20}},
+		{Field: "age", Code: "greater_than", Message: "age must be greater than 15", Params: map[string]interface{}{"min": 15}},
+	}, apperrors.Fields(err))
+	mockRepo.AssertNotCalled(t, "Create")
+}
+
 // TestUpdateUser_NonGmailEmailUnit tests updating a user with a non-Gmail email
 func TestUpdateUser_NonGmailEmailUnit(t *testing.T) {
 	mockRepo := new(MockUserRepository)
-	service := NewUserService(mockRepo)
+	service := NewUserService(mockRepo, nil, newGmailOnlyPolicy(t), nil, "")
 
 	updateUserDTO := dto.UpdateUserDTO{
-		Email: "test@yahoo.com",
+		Username: "newuser",
+		Email:    "test@yahoo.com",
 	}
 
-	user, err := service.UpdateUser(1, updateUserDTO)
+	user, err := service.UpdateUser(1, updateUserDTO, 0)
 	assert.Error(t, err)
-	assert.Equal(t, "email must be a valid Gmail address (@gmail.com)", err.Error())
+	assert.Equal(t, "email domain is not allowed", err.Error())
 	assert.Equal(t, dto.UserDTO{}, user)
 	mockRepo.AssertNotCalled(t, "GetByID")
 	mockRepo.AssertNotCalled(t, "Update")
@@ -287,19 +373,192 @@ func TestUpdateUser_NonGmailEmailUnit(t *testing.T) {
 // TestUpdateUser_UserNotFoundUnit tests updating a non-existent user
 func TestUpdateUser_UserNotFoundUnit(t *testing.T) {
 	mockRepo := new(MockUserRepository)
-	service := NewUserService(mockRepo)
+	service := NewUserService(mockRepo, nil, nil, nil, "")
 
 	updateUserDTO := dto.UpdateUserDTO{
 		Username: "newuser",
+		Email:    "new@gmail.com",
 	}
 
 	// Mock user not found
-	mockRepo.On("GetByID", 1).Return(models.User{}, errors.New("user not found"))
+	mockRepo.On("GetByID", 1).Return(models.User{}, repositories.ErrUserNotFound)
 
-	user, err := service.UpdateUser(1, updateUserDTO)
+	user, err := service.UpdateUser(1, updateUserDTO, 0)
 	assert.Error(t, err)
 	assert.Equal(t, "user not found", err.Error())
 	assert.Equal(t, dto.UserDTO{}, user)
 	mockRepo.AssertExpectations(t)
 	mockRepo.AssertNotCalled(t, "Update")
 }
+
+// TestListUsers_InvalidSortUnit tests listing users with a sort field that is not whitelisted
+func TestListUsers_InvalidSortUnit(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	service := NewUserService(mockRepo, nil, nil, nil, "")
+
+	_, err := service.ListUsers(dto.UserListQueryDTO{Sort: "password"})
+	assert.ErrorIs(t, err, ErrInvalidListQuery)
+	mockRepo.AssertNotCalled(t, "List")
+}
+
+// TestListUsers_InvalidLimitUnit tests listing users with a limit above the maximum
+func TestListUsers_InvalidLimitUnit(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	service := NewUserService(mockRepo, nil, nil, nil, "")
+
+	_, err := service.ListUsers(dto.UserListQueryDTO{Limit: 1000})
+	assert.ErrorIs(t, err, ErrInvalidListQuery)
+	mockRepo.AssertNotCalled(t, "List")
+}
+
+// TestListUsers_CursorRoundTripUnit tests that next_cursor resumes after the last row of the page
+func TestListUsers_CursorRoundTripUnit(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	service := NewUserService(mockRepo, nil, nil, nil, "")
+
+	firstPage := repositories.UserListParams{
+		Limit: 2,
+		Sort:  repositories.UserSort{Field: "username", Desc: true},
+	}
+	mockRepo.On("List", firstPage).Return(repositories.UserListResult{
+		Users:   []models.User{{ID: 7, Username: "zed"}, {ID: 3, Username: "mia"}},
+		HasMore: true,
+	}, nil)
+
+	page, err := service.ListUsers(dto.UserListQueryDTO{Limit: 2, Sort: "-username"})
+	assert.NoError(t, err)
+	assert.Len(t, page.Data, 2)
+	assert.NotEmpty(t, page.NextCursor)
+	assert.Nil(t, page.Total)
+
+	secondPage := firstPage
+	secondPage.After = &repositories.UserCursor{Value: "mia", ID: 3}
+	mockRepo.On("List", secondPage).Return(repositories.UserListResult{
+		Users: []models.User{{ID: 1, Username: "amy"}},
+	}, nil)
+
+	page, err = service.ListUsers(dto.UserListQueryDTO{Limit: 2, Sort: "-username", Cursor: page.NextCursor})
+	assert.NoError(t, err)
+	assert.Len(t, page.Data, 1)
+	assert.Empty(t, page.NextCursor)
+	mockRepo.AssertExpectations(t)
+
+	// Cursor tidak boleh dipakai dengan sort yang berbeda
+	_, err = service.ListUsers(dto.UserListQueryDTO{Limit: 2, Sort: "username", Cursor: encodeUserListCursor(userListCursor{Sort: "-username"})})
+	assert.ErrorIs(t, err, ErrInvalidListQuery)
+}
+
+// TestUpdateUser_ReplacesAllFieldsUnit tests that PUT clears omitted fields and keeps the password when none is given
+func TestUpdateUser_ReplacesAllFieldsUnit(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	service := NewUserService(mockRepo, nil, nil, nil, "")
+	age := 30
+
+	mockRepo.On("GetByID", 1).Return(models.User{ID: 1, Username: "alice", Email: "alice@gmail.com", Password: "hash", FirstName: "Alice", LastName: "Smith", Age: &age}, nil)
+	mockRepo.On("Update", mock.MatchedBy(func(user models.User) bool {
+		return user.Age == nil && user.LastName == "Jones" && user.Password == "hash"
+	})).Return(models.User{ID: 1}, nil)
+
+	_, err := service.UpdateUser(1, dto.UpdateUserDTO{Username: "alice", Email: "alice@gmail.com", FirstName: "Alice", LastName: "Jones"}, 0)
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+// TestPatchUser_MergePatchUnit tests that a merge patch changes only the given fields and null clears age
+func TestPatchUser_MergePatchUnit(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	service := NewUserService(mockRepo, nil, nil, nil, "")
+	age := 30
+
+	// First name is invalid under current rules but untouched, so it is not validated
+	mockRepo.On("GetByID", 1).Return(models.User{ID: 1, Username: "alice", Email: "alice@gmail.com", Password: "hash", FirstName: "Al", LastName: "Smith", Age: &age}, nil)
+	var saved models.User
+	mockRepo.On("Update", mock.Anything).Run(func(args mock.Arguments) {
+		saved = args.Get(0).(models.User)
+	}).Return(models.User{ID: 1}, nil)
+
+	_, err := service.PatchUser(1, "", true, dto.MergePatchContentType, []byte(`{"last_name":"Jones","age":null}`), 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "Al", saved.FirstName)
+	assert.Equal(t, "Jones", saved.LastName)
+	assert.Nil(t, saved.Age)
+	assert.Equal(t, "hash", saved.Password)
+	assert.Equal(t, "alice@gmail.com", saved.Email)
+}
+
+// TestPatchUser_JSONPatchUnit tests JSON Patch operations, including a failing test operation
+func TestPatchUser_JSONPatchUnit(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	service := NewUserService(mockRepo, nil, nil, nil, "")
+
+	mockRepo.On("GetByID", 1).Return(models.User{ID: 1, Username: "alice", Email: "alice@gmail.com", FirstName: "Alice"}, nil)
+	mockRepo.On("Update", mock.MatchedBy(func(user models.User) bool {
+		return user.Age != nil && *user.Age == 20 && user.FirstName == "Alicia"
+	})).Return(models.User{ID: 1}, nil)
+
+	_, err := service.PatchUser(1, "", true, dto.JSONPatchContentType, []byte(`[
+		{"op":"test","path":"/username","value":"alice"},
+		{"op":"replace","path":"/first_name","value":"Alicia"},
+		{"op":"replace","path":"/age","value":20}
+	]`), 0)
+	assert.NoError(t, err)
+
+	_, err = service.PatchUser(1, "", true, dto.JSONPatchContentType, []byte(`[{"op":"test","path":"/username","value":"bob"}]`), 0)
+	assert.ErrorIs(t, err, ErrPatchTestFailed)
+	mockRepo.AssertNumberOfCalls(t, "Update", 1)
+}
+
+// TestPatchUser_RejectsInvalidPatchesUnit tests unknown fields, invalid touched fields and unsupported content types
+func TestPatchUser_RejectsInvalidPatchesUnit(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	service := NewUserService(mockRepo, nil, newGmailOnlyPolicy(t), nil, "")
+	mockRepo.On("GetByID", 1).Return(models.User{ID: 1, Username: "alice", Email: "alice@gmail.com"}, nil)
+
+	_, err := service.PatchUser(1, "", true, dto.MergePatchContentType, []byte(`{"is_admin":true}`), 0)
+	assert.ErrorIs(t, err, ErrInvalidPatch)
+
+	_, err = service.PatchUser(1, "", true, dto.MergePatchContentType, []byte(`{"username":null}`), 0)
+	assert.EqualError(t, err, "username is required")
+
+	_, err = service.PatchUser(1, "", true, dto.MergePatchContentType, []byte(`{"age":12.5}`), 0)
+	assert.EqualError(t, err, "age must be an integer")
+
+	_, err = service.PatchUser(1, "", true, dto.MergePatchContentType, []byte(`{"email":"alice@yahoo.com"}`), 0)
+	assert.EqualError(t, err, "email domain is not allowed")
+
+	_, err = service.PatchUser(1, "", true, dto.MergePatchContentType, []byte(`{"username":"a!","first_name":7}`), 0)
+	assert.Equal(t, []string{"username", "first_name"}, []string{apperrors.Fields(err)[0].Field, apperrors.Fields(err)[1].Field})
+
+	_, err = service.PatchUser(1, "", true, "application/json", []byte(`{"last_name":"Jones"}`), 0)
+	assert.ErrorIs(t, err, ErrUnsupportedPatch)
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything)
+}
+
+// TestPatchUser_ChecksIfMatchVersionUnit tests that a stale or concurrently changed version is rejected
+func TestPatchUser_ChecksIfMatchVersionUnit(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	service := NewUserService(mockRepo, nil, nil, nil, "")
+	mockRepo.On("GetByID", 1).Return(models.User{ID: 1, Username: "alice", Email: "alice@gmail.com", Version: 3}, nil)
+	mockRepo.On("Update", mock.Anything).Return(models.User{}, ErrUserModified)
+
+	_, err := service.PatchUser(1, "", true, dto.MergePatchContentType, []byte(`{"last_name":"Jones"}`), 2)
+	assert.ErrorIs(t, err, ErrPreconditionFailed)
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything)
+
+	_, err = service.PatchUser(1, "", true, dto.MergePatchContentType, []byte(`{"last_name":"Jones"}`), 3)
+	assert.ErrorIs(t, err, ErrPreconditionFailed)
+
+	_, err = service.PatchUser(1, "", true, dto.MergePatchContentType, []byte(`{"last_name":"Jones"}`), 0)
+	assert.ErrorIs(t, err, ErrUserModified)
+}
+
+// TestDeleteUser_ChecksIfMatchVersionUnit tests that deleting a changed user fails the precondition
+func TestDeleteUser_ChecksIfMatchVersionUnit(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	service := NewUserService(mockRepo, nil, nil, nil, "")
+	mockRepo.On("Delete", 1, 2).Return(ErrUserModified)
+	mockRepo.On("Delete", 1, 3).Return(nil)
+
+	assert.ErrorIs(t, service.DeleteUser(1, 2), ErrPreconditionFailed)
+	assert.NoError(t, service.DeleteUser(1, 3))
+}
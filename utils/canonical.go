@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// emailProviderRules adalah aturan kanonikalisasi khusus provider, key-nya
+// domain setelah lowercase. Aturan ini harus sama dengan backfill di migrasi
+// 0015_add_user_canonical_identity.
+var emailProviderRules = map[string]func(local string) (string, string){
+	"gmail.com":      canonicalGmail,
+	"googlemail.com": canonicalGmail,
+}
+
+// CanonicalUsername mengembalikan bentuk username yang dipakai untuk lookup dan
+// keunikan: Unicode NFKC lalu lowercase, sehingga "Bob" dan "bob" sama
+func CanonicalUsername(username string) string {
+	return strings.ToLower(norm.NFKC.String(strings.TrimSpace(username)))
+}
+
+// CanonicalEmail mengembalikan bentuk email yang dipakai untuk lookup dan
+// keunikan: Unicode NFKC, lowercase, lalu aturan provider jika ada, misalnya
+// "B.o.b+news@GoogleMail.com" menjadi "bob@gmail.com"
+func CanonicalEmail(email string) string {
+	email = strings.ToLower(norm.NFKC.String(strings.TrimSpace(email)))
+	at := strings.LastIndexByte(email, '@')
+	if at < 0 {
+		return email
+	}
+	local, domain := email[:at], email[at+1:]
+	if rule, ok := emailProviderRules[domain]; ok {
+		local, domain = rule(local)
+	}
+	return local + "@" + domain
+}
+
+// phoneRegex adalah nomor telepon format E.164 setelah pemisah dibuang
+var phoneRegex = regexp.MustCompile(`^\+[1-9][0-9]{6,14}$`)
+
+// CanonicalPhone mengubah nomor telepon menjadi format E.164, misalnya
+// "+62 812-3456-789" menjadi "+628123456789". ok bernilai false jika nomor
+// tidak diawali "+" dan kode negara atau panjangnya tidak valid.
+func CanonicalPhone(phone string) (canonical string, ok bool) {
+	canonical = strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '.', '(', ')':
+			return -1
+		}
+		return r
+	}, norm.NFKC.String(strings.TrimSpace(phone)))
+	if !phoneRegex.MatchString(canonical) {
+		return "", false
+	}
+	return canonical, true
+}
+
+// canonicalGmail membuang titik dan bagian setelah "+" karena Gmail
+// mengabaikannya, googlemail.com adalah alias gmail.com
+func canonicalGmail(local string) (string, string) {
+	if plus := strings.IndexByte(local, '+'); plus >= 0 {
+		local = local[:plus]
+	}
+	return strings.ReplaceAll(local, ".", ""), "gmail.com"
+}
@@ -0,0 +1,38 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCanonicalEmail_RulesUnit menguji lowercase, NFKC dan aturan Gmail
+func TestCanonicalEmail_RulesUnit(t *testing.T) {
+	examples := map[string]string{
+		"Bob@Gmail.com":          "bob@gmail.com",
+		"b.o.b+news@gmail.com":   "bob@gmail.com",
+		"B.O.B@GoogleMail.com":   "bob@gmail.com",
+		"b.o.b+news@example.com": "b.o.b+news@example.com",
+		" Alice@Example.COM ":    "alice@example.com",
+		"ａｌｉｃｅ@example.com":      "alice@example.com",
+		"not-an-email":           "not-an-email",
+	}
+	for email, want := range examples {
+		assert.Equal(t, want, CanonicalEmail(email), email)
+	}
+
+	assert.Equal(t, "bob", CanonicalUsername("Bob"))
+	assert.Equal(t, "bob", CanonicalUsername("ＢＯＢ"))
+}
+
+// TestCanonicalPhone_E164Unit menguji bahwa pemisah dibuang dan nomor tanpa kode negara ditolak
+func TestCanonicalPhone_E164Unit(t *testing.T) {
+	phone, ok := CanonicalPhone("+62 (812) 3456-789")
+	assert.True(t, ok)
+	assert.Equal(t, "+628123456789", phone)
+
+	for _, invalid := range []string{"08123456789", "+0123456789", "+62 81", "+62abc4567890", "alice"} {
+		_, ok := CanonicalPhone(invalid)
+		assert.False(t, ok, invalid)
+	}
+}
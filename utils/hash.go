@@ -1,20 +1,60 @@
 package utils
 
-import "golang.org/x/crypto/bcrypt"
+import (
+	"sync"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// passwordHasher adalah hasher yang dipakai oleh fungsi-fungsi di bawah.
+// Defaultnya bcrypt, diganti saat startup lewat SetPasswordHasher.
+var passwordHasher PasswordHasher = &multiHasher{
+	preferred: NewBcryptHasher(bcrypt.DefaultCost),
+	all:       []formatHasher{NewBcryptHasher(bcrypt.DefaultCost), NewArgon2idHasher(0, 0, 0)},
+}
+
+// dummyHash dipakai VerifyDummyPassword, dibuat sekali per hasher
+var (
+	dummyHash     string
+	dummyHashOnce sync.Once
+)
+
+// SetPasswordHasher mengganti hasher yang dipakai aplikasi
+func SetPasswordHasher(hasher PasswordHasher) {
+	passwordHasher = hasher
+	dummyHashOnce = sync.Once{}
+}
+
+// VerifyDummyPassword memverifikasi password terhadap hash palsu agar login
+// dengan username yang tidak ada memakan waktu yang sama dengan password salah
+func VerifyDummyPassword(password string) {
+	dummyHashOnce.Do(func() {
+		dummyHash, _ = passwordHasher.Hash("dummy-password-for-constant-time-login")
+	})
+	_, _ = passwordHasher.Verify(password, dummyHash)
+}
 
 // HashPassword meng-hash password sebelum disimpan di database
 func HashPassword(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	return string(bytes), err
+	return passwordHasher.Hash(password)
+}
+
+// VerifyPassword membandingkan password dengan hash tersimpan dan melaporkan
+// apakah hash perlu dibuat ulang dengan algoritma/parameter terbaru
+func VerifyPassword(password, hash string) (ok bool, needsRehash bool) {
+	ok, err := passwordHasher.Verify(password, hash)
+	if err != nil || !ok {
+		return false, false
+	}
+	return true, passwordHasher.NeedsRehash(hash)
 }
 
 // CheckPasswordHash membandingkan password dengan hash yang tersimpan
 func CheckPasswordHash(password, hash string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
-	return err == nil
+	ok, _ := VerifyPassword(password, hash)
+	return ok
 }
 
 func ComparePasswords(hashedPassword, password string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
-	return err == nil
+	return CheckPasswordHash(password, hashedPassword)
 }
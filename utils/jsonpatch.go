@@ -0,0 +1,320 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"gin-user-app/apperrors"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrInvalidPatch dikembalikan jika patch tidak valid atau tidak bisa diterapkan
+	ErrInvalidPatch = apperrors.BadRequest("invalid patch")
+	// ErrPatchTestFailed dikembalikan jika operasi "test" JSON Patch gagal
+	ErrPatchTestFailed = apperrors.Conflict("patch test operation failed")
+)
+
+// MergePatch menerapkan JSON Merge Patch (RFC 7396) ke dokumen hasil decode JSON.
+// Nilai null di patch menghapus key. Dokumen asli tidak diubah.
+func MergePatch(doc interface{}, patch []byte) (interface{}, error) {
+	var patchValue interface{}
+	if err := json.Unmarshal(patch, &patchValue); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	return mergeValue(cloneJSON(doc), patchValue), nil
+}
+
+// mergeValue adalah algoritma MergePatch dari RFC 7396 section 2
+func mergeValue(target, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = map[string]interface{}{}
+	}
+	for key, value := range patchObject {
+		if value == nil {
+			delete(targetObject, key)
+			continue
+		}
+		targetObject[key] = mergeValue(targetObject[key], value)
+	}
+	return targetObject
+}
+
+// jsonPatchOperation adalah satu operasi JSON Patch. Value berupa raw JSON
+// supaya "value": null bisa dibedakan dari value yang tidak dikirim.
+type jsonPatchOperation struct {
+	Op    string          `json:"op"`
+	Path  *string         `json:"path"`
+	From  *string         `json:"from"`
+	Value json.RawMessage `json:"value"`
+}
+
+// ApplyJSONPatch menerapkan JSON Patch (RFC 6902) ke dokumen hasil decode JSON.
+// Patch diterapkan seluruhnya atau tidak sama sekali; dokumen asli tidak diubah.
+func ApplyJSONPatch(doc interface{}, patch []byte) (interface{}, error) {
+	var operations []jsonPatchOperation
+	if err := json.Unmarshal(patch, &operations); err != nil {
+		return nil, fmt.Errorf("%w: patch must be an array of operations", ErrInvalidPatch)
+	}
+
+	result := cloneJSON(doc)
+	for i, operation := range operations {
+		var err error
+		result, err = applyOperation(result, operation)
+		if err != nil {
+			return nil, fmt.Errorf("operation %d: %w", i, err)
+		}
+	}
+	return result, nil
+}
+
+// applyOperation menerapkan satu operasi JSON Patch
+func applyOperation(doc interface{}, operation jsonPatchOperation) (interface{}, error) {
+	if operation.Path == nil {
+		return nil, fmt.Errorf("%w: missing path", ErrInvalidPatch)
+	}
+	path, err := parsePointer(*operation.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch operation.Op {
+	case "add", "replace", "test":
+		value, err := operationValue(operation)
+		if err != nil {
+			return nil, err
+		}
+		switch operation.Op {
+		case "add":
+			return addAt(doc, path, value)
+		case "replace":
+			if _, err := getAt(doc, path); err != nil {
+				return nil, err
+			}
+			if len(path) == 0 {
+				return value, nil
+			}
+			if doc, err = removeAt(doc, path); err != nil {
+				return nil, err
+			}
+			return addAt(doc, path, value)
+		default:
+			current, err := getAt(doc, path)
+			if err != nil {
+				return nil, err
+			}
+			if !reflect.DeepEqual(current, value) {
+				return nil, fmt.Errorf("%w: value at %q differs", ErrPatchTestFailed, *operation.Path)
+			}
+			return doc, nil
+		}
+	case "remove":
+		if _, err := getAt(doc, path); err != nil {
+			return nil, err
+		}
+		return removeAt(doc, path)
+	case "move", "copy":
+		if operation.From == nil {
+			return nil, fmt.Errorf("%w: missing from", ErrInvalidPatch)
+		}
+		from, err := parsePointer(*operation.From)
+		if err != nil {
+			return nil, err
+		}
+		value, err := getAt(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		if operation.Op == "copy" {
+			return addAt(doc, path, cloneJSON(value))
+		}
+		if *operation.Path == *operation.From {
+			return doc, nil
+		}
+		if strings.HasPrefix(*operation.Path, *operation.From+"/") {
+			return nil, fmt.Errorf("%w: cannot move a value into itself", ErrInvalidPatch)
+		}
+		if doc, err = removeAt(doc, from); err != nil {
+			return nil, err
+		}
+		return addAt(doc, path, value)
+	default:
+		return nil, fmt.Errorf("%w: unknown op %q", ErrInvalidPatch, operation.Op)
+	}
+}
+
+// operationValue mengambil value sebuah operasi, null diperbolehkan
+func operationValue(operation jsonPatchOperation) (interface{}, error) {
+	if len(operation.Value) == 0 {
+		return nil, fmt.Errorf("%w: missing value", ErrInvalidPatch)
+	}
+	var value interface{}
+	if err := json.Unmarshal(operation.Value, &value); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	return value, nil
+}
+
+// parsePointer memecah JSON Pointer (RFC 6901) menjadi token
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("%w: path %q must start with /", ErrInvalidPatch, pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+// arrayIndex mengubah token menjadi index array; "-" berarti setelah elemen terakhir
+func arrayIndex(token string, length int, allowEnd bool) (int, error) {
+	if allowEnd && token == "-" {
+		return length, nil
+	}
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 || (len(token) > 1 && token[0] == '0') || token[0] == '+' {
+		return 0, fmt.Errorf("%w: invalid array index %q", ErrInvalidPatch, token)
+	}
+	if index > length || (!allowEnd && index == length) {
+		return 0, fmt.Errorf("%w: array index %d out of range", ErrInvalidPatch, index)
+	}
+	return index, nil
+}
+
+// getAt mengambil nilai pada path
+func getAt(node interface{}, path []string) (interface{}, error) {
+	for _, token := range path {
+		switch n := node.(type) {
+		case map[string]interface{}:
+			value, ok := n[token]
+			if !ok {
+				return nil, fmt.Errorf("%w: path %q does not exist", ErrInvalidPatch, token)
+			}
+			node = value
+		case []interface{}:
+			index, err := arrayIndex(token, len(n), false)
+			if err != nil {
+				return nil, err
+			}
+			node = n[index]
+		default:
+			return nil, fmt.Errorf("%w: path %q does not exist", ErrInvalidPatch, token)
+		}
+	}
+	return node, nil
+}
+
+// addAt menambahkan nilai pada path dan mengembalikan node yang sudah diubah
+func addAt(node interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	token := path[0]
+	switch n := node.(type) {
+	case map[string]interface{}:
+		if len(path) == 1 {
+			n[token] = value
+			return n, nil
+		}
+		child, ok := n[token]
+		if !ok {
+			return nil, fmt.Errorf("%w: path %q does not exist", ErrInvalidPatch, token)
+		}
+		updated, err := addAt(child, path[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		n[token] = updated
+		return n, nil
+	case []interface{}:
+		if len(path) == 1 {
+			index, err := arrayIndex(token, len(n), true)
+			if err != nil {
+				return nil, err
+			}
+			n = append(n, nil)
+			copy(n[index+1:], n[index:])
+			n[index] = value
+			return n, nil
+		}
+		index, err := arrayIndex(token, len(n), false)
+		if err != nil {
+			return nil, err
+		}
+		updated, err := addAt(n[index], path[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		n[index] = updated
+		return n, nil
+	default:
+		return nil, fmt.Errorf("%w: path %q does not exist", ErrInvalidPatch, token)
+	}
+}
+
+// removeAt menghapus nilai pada path dan mengembalikan node yang sudah diubah
+func removeAt(node interface{}, path []string) (interface{}, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("%w: cannot remove the whole document", ErrInvalidPatch)
+	}
+	token := path[0]
+	switch n := node.(type) {
+	case map[string]interface{}:
+		if len(path) == 1 {
+			delete(n, token)
+			return n, nil
+		}
+		updated, err := removeAt(n[token], path[1:])
+		if err != nil {
+			return nil, err
+		}
+		n[token] = updated
+		return n, nil
+	case []interface{}:
+		index, err := arrayIndex(token, len(n), false)
+		if err != nil {
+			return nil, err
+		}
+		if len(path) == 1 {
+			return append(n[:index], n[index+1:]...), nil
+		}
+		updated, err := removeAt(n[index], path[1:])
+		if err != nil {
+			return nil, err
+		}
+		n[index] = updated
+		return n, nil
+	default:
+		return nil, fmt.Errorf("%w: path %q does not exist", ErrInvalidPatch, token)
+	}
+}
+
+// cloneJSON menyalin dokumen hasil decode JSON secara mendalam
+func cloneJSON(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		clone := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			clone[key] = cloneJSON(item)
+		}
+		return clone
+	case []interface{}:
+		clone := make([]interface{}, len(v))
+		for i, item := range v {
+			clone[i] = cloneJSON(item)
+		}
+		return clone
+	default:
+		return v
+	}
+}
@@ -0,0 +1,101 @@
+package utils
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// decodeJSON men-decode dokumen JSON untuk test
+func decodeJSON(t *testing.T, raw string) interface{} {
+	var value interface{}
+	assert.NoError(t, json.Unmarshal([]byte(raw), &value))
+	return value
+}
+
+// TestMergePatch_RFC7396ExamplesUnit menguji contoh dari RFC 7396 appendix A
+func TestMergePatch_RFC7396ExamplesUnit(t *testing.T) {
+	examples := []struct{ target, patch, want string }{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+	for _, example := range examples {
+		got, err := MergePatch(decodeJSON(t, example.target), []byte(example.patch))
+		assert.NoError(t, err)
+		assert.Equal(t, decodeJSON(t, example.want), got, "%s + %s", example.target, example.patch)
+	}
+}
+
+// TestMergePatch_DoesNotModifyTargetUnit menguji bahwa dokumen asli tidak berubah
+func TestMergePatch_DoesNotModifyTargetUnit(t *testing.T) {
+	target := decodeJSON(t, `{"a":{"b":"c"}}`)
+	_, err := MergePatch(target, []byte(`{"a":{"b":null}}`))
+	assert.NoError(t, err)
+	assert.Equal(t, decodeJSON(t, `{"a":{"b":"c"}}`), target)
+
+	_, err = MergePatch(target, []byte(`{"a":`))
+	assert.ErrorIs(t, err, ErrInvalidPatch)
+}
+
+// TestApplyJSONPatch_OperationsUnit menguji setiap operasi RFC 6902
+func TestApplyJSONPatch_OperationsUnit(t *testing.T) {
+	cases := []struct{ doc, patch, want string }{
+		{`{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux"}]`, `{"foo":"bar","baz":"qux"}`},
+		{`{"foo":["bar","baz"]}`, `[{"op":"add","path":"/foo/1","value":"qux"}]`, `{"foo":["bar","qux","baz"]}`},
+		{`{"foo":["bar"]}`, `[{"op":"add","path":"/foo/-","value":["abc"]}]`, `{"foo":["bar",["abc"]]}`},
+		{`{"baz":"qux","foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, `{"foo":"bar"}`},
+		{`{"foo":["bar","qux","baz"]}`, `[{"op":"remove","path":"/foo/1"}]`, `{"foo":["bar","baz"]}`},
+		{`{"baz":"qux","foo":"bar"}`, `[{"op":"replace","path":"/baz","value":null}]`, `{"baz":null,"foo":"bar"}`},
+		{`{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`, `[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`, `{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`},
+		{`{"foo":["all","grass","cows","eat"]}`, `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`, `{"foo":["all","cows","eat","grass"]}`},
+		{`{"a":{"b":1}}`, `[{"op":"copy","from":"/a","path":"/c"}]`, `{"a":{"b":1},"c":{"b":1}}`},
+		{`{"a/b":1,"m~n":2}`, `[{"op":"test","path":"/a~1b","value":1},{"op":"test","path":"/m~0n","value":2}]`, `{"a/b":1,"m~n":2}`},
+	}
+	for _, c := range cases {
+		got, err := ApplyJSONPatch(decodeJSON(t, c.doc), []byte(c.patch))
+		assert.NoError(t, err, c.patch)
+		assert.Equal(t, decodeJSON(t, c.want), got, c.patch)
+	}
+}
+
+// TestApplyJSONPatch_ErrorsUnit menguji patch yang tidak valid dan test yang gagal
+func TestApplyJSONPatch_ErrorsUnit(t *testing.T) {
+	doc := decodeJSON(t, `{"foo":"bar","list":[1]}`)
+	invalid := []string{
+		`{"op":"add"}`,
+		`[{"op":"add","path":"/a"}]`,
+		`[{"op":"remove","path":"/missing"}]`,
+		`[{"op":"replace","path":"/missing","value":1}]`,
+		`[{"op":"add","path":"/missing/child","value":1}]`,
+		`[{"op":"add","path":"/list/5","value":1}]`,
+		`[{"op":"add","path":"/list/01","value":1}]`,
+		`[{"op":"move","from":"/foo","path":"/foo/bar"}]`,
+		`[{"op":"explode","path":"/foo"}]`,
+		`[{"op":"add","path":"foo","value":1}]`,
+	}
+	for _, patch := range invalid {
+		_, err := ApplyJSONPatch(doc, []byte(patch))
+		assert.ErrorIs(t, err, ErrInvalidPatch, patch)
+	}
+
+	_, err := ApplyJSONPatch(doc, []byte(`[{"op":"test","path":"/foo","value":"baz"}]`))
+	assert.ErrorIs(t, err, ErrPatchTestFailed)
+}
+
+// TestApplyJSONPatch_AtomicUnit menguji bahwa operasi sebelum operasi yang gagal tidak mengubah dokumen
+func TestApplyJSONPatch_AtomicUnit(t *testing.T) {
+	doc := decodeJSON(t, `{"foo":"bar"}`)
+	_, err := ApplyJSONPatch(doc, []byte(`[{"op":"replace","path":"/foo","value":"baz"},{"op":"test","path":"/foo","value":"bar"}]`))
+	assert.ErrorIs(t, err, ErrPatchTestFailed)
+	assert.Equal(t, decodeJSON(t, `{"foo":"bar"}`), doc)
+}
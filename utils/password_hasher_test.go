@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestArgon2idHasher_HashAndVerifyUnit menguji hash argon2id dalam format PHC
+func TestArgon2idHasher_HashAndVerifyUnit(t *testing.T) {
+	hasher := NewArgon2idHasher(1024, 1, 1)
+
+	hash, err := hasher.Hash("password123")
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=1024,t=1,p=1$"))
+
+	ok, err := hasher.Verify("password123", hash)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = hasher.Verify("wrongpassword", hash)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	assert.False(t, hasher.NeedsRehash(hash))
+	assert.True(t, NewArgon2idHasher(2048, 1, 1).NeedsRehash(hash))
+}
+
+// TestMultiHasher_UpgradesBcryptUnit menguji bahwa hash bcrypt lama tetap valid tetapi perlu di-hash ulang
+func TestMultiHasher_UpgradesBcryptUnit(t *testing.T) {
+	legacy, err := NewBcryptHasher(4).Hash("password123")
+	assert.NoError(t, err)
+
+	hasher, err := NewPasswordHasher(PasswordHashConfig{
+		Algorithm:         AlgorithmArgon2id,
+		Argon2Memory:      1024,
+		Argon2Iterations:  1,
+		Argon2Parallelism: 1,
+	})
+	assert.NoError(t, err)
+
+	ok, err := hasher.Verify("password123", legacy)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.True(t, hasher.NeedsRehash(legacy))
+
+	upgraded, err := hasher.Hash("password123")
+	assert.NoError(t, err)
+	assert.False(t, hasher.NeedsRehash(upgraded))
+}
+
+// TestMultiHasher_BcryptCostChangeUnit menguji bahwa perubahan cost bcrypt memicu rehash
+func TestMultiHasher_BcryptCostChangeUnit(t *testing.T) {
+	hash, err := NewBcryptHasher(4).Hash("password123")
+	assert.NoError(t, err)
+
+	hasher, err := NewPasswordHasher(PasswordHashConfig{Algorithm: AlgorithmBcrypt, BcryptCost: 5})
+	assert.NoError(t, err)
+	assert.True(t, hasher.NeedsRehash(hash))
+
+	_, err = hasher.Verify("password123", "plaintext")
+	assert.ErrorIs(t, err, ErrUnknownHashFormat)
+}
+
+// TestNewPasswordHasher_UnknownAlgorithmUnit menguji konfigurasi algoritma yang tidak didukung
+func TestNewPasswordHasher_UnknownAlgorithmUnit(t *testing.T) {
+	_, err := NewPasswordHasher(PasswordHashConfig{Algorithm: "md5"})
+	assert.Error(t, err)
+}
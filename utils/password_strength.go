@@ -0,0 +1,111 @@
+package utils
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// passwordSequences adalah urutan karakter yang mudah ditebak: alfabet, angka
+// dan baris keyboard. Urutan dibaca maju maupun mundur.
+var passwordSequences = []string{
+	"abcdefghijklmnopqrstuvwxyz",
+	"01234567890",
+	"qwertyuiop",
+	"asdfghjkl",
+	"zxcvbnm",
+}
+
+// PasswordClasses menghitung jenis karakter di password: huruf kecil, huruf
+// besar, angka dan simbol (semua karakter lain)
+func PasswordClasses(password string) int {
+	var lower, upper, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+	count := 0
+	for _, present := range []bool{lower, upper, digit, symbol} {
+		if present {
+			count++
+		}
+	}
+	return count
+}
+
+// PasswordScore memberi skor kekuatan password 0 (sangat lemah) sampai 4
+// (kuat) dengan skala yang sama seperti zxcvbn. Jumlah tebakan diperkirakan
+// seperti brute force zxcvbn, 10 tebakan per karakter, sedangkan pengulangan
+// ("aaaa") dan urutan ("abcd", "4321", "qwerty") dihitung hampir seperti satu
+// karakter. Kata dari kamus tidak dikenali, itu tugas daftar password bocor.
+func PasswordScore(password string) int {
+	guesses := passwordGuessesLog10(password)
+	switch {
+	case guesses < 3:
+		return 0
+	case guesses < 6:
+		return 1
+	case guesses < 8:
+		return 2
+	case guesses < 10:
+		return 3
+	default:
+		return 4
+	}
+}
+
+// passwordGuessesLog10 memperkirakan log10 jumlah tebakan untuk password
+func passwordGuessesLog10(password string) float64 {
+	runes := []rune(strings.ToLower(password))
+	guesses := 0.0
+	for i := 0; i < len(runes); {
+		length := patternLength(runes[i:])
+		if length >= 3 {
+			guesses += 1 + math.Log10(float64(length))
+			i += length
+			continue
+		}
+		guesses++
+		i++
+	}
+	return guesses
+}
+
+// patternLength mengembalikan panjang pengulangan atau urutan terpanjang di
+// awal runes, paling sedikit 1
+func patternLength(runes []rune) int {
+	repeat := 1
+	for repeat < len(runes) && runes[repeat] == runes[0] {
+		repeat++
+	}
+
+	longest := repeat
+	for _, sequence := range passwordSequences {
+		start := strings.IndexRune(sequence, runes[0])
+		if start < 0 {
+			continue
+		}
+		for _, step := range []int{1, -1} {
+			length := 1
+			for length < len(runes) {
+				position := start + length*step
+				if position < 0 || position >= len(sequence) || rune(sequence[position]) != runes[length] {
+					break
+				}
+				length++
+			}
+			if length > longest {
+				longest = length
+			}
+		}
+	}
+	return longest
+}
@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestPasswordScore_PatternsUnit menguji bahwa pengulangan dan urutan dinilai lemah
+func TestPasswordScore_PatternsUnit(t *testing.T) {
+	examples := map[string]int{
+		"ab":                0,
+		"aaaaaaaaaaaa":      0,
+		"12345678":          0,
+		"abcdefgh":          0,
+		"qwerty123":         1,
+		"zyxwvu":            0,
+		"tr0ub4":            2,
+		"kitten99":          3,
+		"correct-horse-bat": 4,
+	}
+	for password, want := range examples {
+		assert.Equal(t, want, PasswordScore(password), password)
+	}
+}
+
+// TestPasswordClasses_CountsKindsUnit menguji penghitungan jenis karakter
+func TestPasswordClasses_CountsKindsUnit(t *testing.T) {
+	assert.Equal(t, 1, PasswordClasses("password"))
+	assert.Equal(t, 2, PasswordClasses("Password"))
+	assert.Equal(t, 3, PasswordClasses("Password1"))
+	assert.Equal(t, 4, PasswordClasses("Pässword1!"))
+}
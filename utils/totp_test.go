@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// rfcTOTPSecret adalah secret RFC 6238 appendix B ("12345678901234567890") dalam base32
+const rfcTOTPSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// TestTOTPCode_RFC6238VectorsUnit menguji vektor SHA1 dari RFC 6238, dipotong ke 6 digit
+func TestTOTPCode_RFC6238VectorsUnit(t *testing.T) {
+	vectors := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1111111111: "050471",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+	for unix, want := range vectors {
+		got, err := TOTPCode(rfcTOTPSecret, TOTPStep(time.Unix(unix, 0)))
+		assert.NoError(t, err)
+		assert.Equal(t, want, got, "unix time %d", unix)
+	}
+}
+
+// TestValidateTOTP_SkewUnit menguji bahwa hanya time-step dalam jendela TOTPSkew yang diterima
+func TestValidateTOTP_SkewUnit(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	previous, _ := TOTPCode(rfcTOTPSecret, TOTPStep(now)-1)
+	tooOld, _ := TOTPCode(rfcTOTPSecret, TOTPStep(now)-2)
+
+	ok, step := ValidateTOTP(rfcTOTPSecret, previous, now)
+	assert.True(t, ok)
+	assert.Equal(t, TOTPStep(now)-1, step)
+
+	ok, _ = ValidateTOTP(rfcTOTPSecret, tooOld, now)
+	assert.False(t, ok)
+}
+
+// TestTOTPAuthURLUnit menguji format URI otpauth://
+func TestTOTPAuthURLUnit(t *testing.T) {
+	uri := TOTPAuthURL("gin-user-app", "alice", rfcTOTPSecret)
+
+	assert.True(t, strings.HasPrefix(uri, "otpauth://totp/gin-user-app:alice?"))
+	assert.Contains(t, uri, "secret="+rfcTOTPSecret)
+	assert.Contains(t, uri, "issuer=gin-user-app")
+}
+
+// TestNormalizeRecoveryCodeUnit menguji bahwa huruf besar, spasi dan pemisah diabaikan
+func TestNormalizeRecoveryCodeUnit(t *testing.T) {
+	code, err := GenerateRecoveryCode()
+	assert.NoError(t, err)
+	assert.Len(t, code, 11)
+	assert.Equal(t, byte('-'), code[5])
+
+	assert.Equal(t, strings.Replace(code, "-", "", 1), NormalizeRecoveryCode(" "+strings.ToUpper(code)+" "))
+}
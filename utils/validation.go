@@ -0,0 +1,226 @@
+package utils
+
+import (
+	"fmt"
+	"gin-user-app/apperrors"
+	"math"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Rule adalah satu aturan validasi deklaratif. Check hanya dipanggil untuk
+// nilai yang diisi; Message adalah template dengan placeholder {field} untuk
+// label field dan {nama} untuk setiap entry Params.
+type Rule struct {
+	Code    string
+	Message string
+	Params  map[string]interface{}
+	Check   func(value interface{}) bool
+	// required berarti rule juga dijalankan untuk nilai kosong
+	required bool
+}
+
+// FieldRules adalah daftar aturan untuk satu field. Label dipakai di pesan
+// error, jika kosong diambil dari Field dengan "_" diganti spasi.
+type FieldRules struct {
+	Field string
+	Label string
+	Rules []Rule
+}
+
+// RuleSet adalah registry aturan validasi per field, urutannya menentukan
+// urutan error yang dilaporkan
+type RuleSet []FieldRules
+
+// Validate memeriksa setiap field yang ada di values dengan aturannya dan
+// mengumpulkan semua field yang gagal, satu error per field. Field yang tidak
+// ada di values dilewati; nil atau string kosong berarti field dikosongkan.
+// Hasilnya nil atau *apperrors.Error dengan kind ErrValidation.
+func (rs RuleSet) Validate(values map[string]interface{}) error {
+	var fields []apperrors.FieldError
+	for _, fieldRules := range rs {
+		value, ok := values[fieldRules.Field]
+		if !ok {
+			continue
+		}
+		if fieldErr, failed := fieldRules.check(normalizeValue(value)); failed {
+			fields = append(fields, fieldErr)
+		}
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+
+	messages := make([]string, len(fields))
+	for i, field := range fields {
+		messages[i] = field.Message
+	}
+	return apperrors.Validation(strings.Join(messages, "; "), fields...)
+}
+
+// With mengembalikan salinan rule set dengan aturan tambahan untuk field,
+// misalnya kebijakan domain email yang berbeda per tenant
+func (rs RuleSet) With(field string, rules ...Rule) RuleSet {
+	result := make(RuleSet, len(rs))
+	copy(result, rs)
+	for i := range result {
+		if result[i].Field == field {
+			result[i].Rules = append(append([]Rule{}, result[i].Rules...), rules...)
+		}
+	}
+	return result
+}
+
+// check menjalankan aturan satu field sampai aturan pertama yang gagal
+func (fr FieldRules) check(value interface{}) (apperrors.FieldError, bool) {
+	label := fr.Label
+	if label == "" {
+		label = strings.ReplaceAll(fr.Field, "_", " ")
+	}
+	empty := value == nil || value == ""
+	for _, rule := range fr.Rules {
+		if empty && !rule.required {
+			continue
+		}
+		if rule.Check(value) {
+			continue
+		}
+		return apperrors.FieldError{
+			Field:   fr.Field,
+			Code:    rule.Code,
+			Message: rule.message(label),
+			Params:  rule.Params,
+		}, true
+	}
+	return apperrors.FieldError{}, false
+}
+
+// message mengisi placeholder pada template pesan rule
+func (r Rule) message(label string) string {
+	replacements := []string{"{field}", label}
+	for name, value := range r.Params {
+		replacements = append(replacements, "{"+name+"}", fmt.Sprint(value))
+	}
+	return strings.NewReplacer(replacements...).Replace(r.Message)
+}
+
+// normalizeValue menyamakan nilai dari DTO dan dokumen JSON, pointer nil
+// menjadi nil dan pointer int menjadi int
+func normalizeValue(value interface{}) interface{} {
+	if pointer, ok := value.(*int); ok {
+		if pointer == nil {
+			return nil
+		}
+		return *pointer
+	}
+	return value
+}
+
+// Required menolak nilai nil atau string kosong
+func Required() Rule {
+	return Rule{
+		Code:     apperrors.CodeRequired,
+		Message:  "{field} is required",
+		Check:    func(value interface{}) bool { return value != nil && value != "" },
+		required: true,
+	}
+}
+
+// String menolak nilai yang bukan string, misalnya angka di dokumen patch
+func String() Rule {
+	return Rule{
+		Code:    "type",
+		Message: "{field} must be a string",
+		Params:  map[string]interface{}{"type": "string"},
+		Check: func(value interface{}) bool {
+			_, ok := value.(string)
+			return ok
+		},
+	}
+}
+
+// Length membatasi jumlah karakter string antara min dan max
+func Length(min, max int) Rule {
+	return Rule{
+		Code:    "length",
+		Message: "{field} must be {min}-{max} characters",
+		Params:  map[string]interface{}{"min": min, "max": max},
+		Check: func(value interface{}) bool {
+			text, ok := value.(string)
+			if !ok {
+				return true
+			}
+			length := utf8.RuneCountInString(text)
+			return length >= min && length <= max
+		},
+	}
+}
+
+// MinLength mewajibkan string paling sedikit min karakter
+func MinLength(min int) Rule {
+	return Rule{
+		Code:    "min_length",
+		Message: "{field} must be at least {min} characters",
+		Params:  map[string]interface{}{"min": min},
+		Check: func(value interface{}) bool {
+			text, ok := value.(string)
+			return !ok || utf8.RuneCountInString(text) >= min
+		},
+	}
+}
+
+// Pattern mewajibkan string cocok dengan pattern. code dan message
+// menjelaskan arti pattern bagi client, misalnya "alphanumeric".
+func Pattern(code string, pattern *regexp.Regexp, message string) Rule {
+	return Rule{
+		Code:    code,
+		Message: message,
+		Params:  map[string]interface{}{"pattern": pattern.String()},
+		Check: func(value interface{}) bool {
+			text, ok := value.(string)
+			return !ok || pattern.MatchString(text)
+		},
+	}
+}
+
+// Integer menolak nilai yang bukan bilangan bulat. Angka dari dokumen JSON
+// berupa float64 sehingga diterima jika tidak punya pecahan.
+func Integer() Rule {
+	return Rule{
+		Code:    "integer",
+		Message: "{field} must be an integer",
+		Check: func(value interface{}) bool {
+			_, ok := toInt(value)
+			return ok
+		},
+	}
+}
+
+// GreaterThan mewajibkan angka lebih besar dari min
+func GreaterThan(min int) Rule {
+	return Rule{
+		Code:    "greater_than",
+		Message: "{field} must be greater than {min}",
+		Params:  map[string]interface{}{"min": min},
+		Check: func(value interface{}) bool {
+			number, ok := toInt(value)
+			return !ok || number > min
+		},
+	}
+}
+
+// toInt mengubah int atau float64 tanpa pecahan menjadi int
+func toInt(value interface{}) (int, bool) {
+	switch number := value.(type) {
+	case int:
+		return number, true
+	case float64:
+		if number != math.Trunc(number) || math.Abs(number) > math.MaxInt32 {
+			return 0, false
+		}
+		return int(number), true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,38 @@
+package utils
+
+import (
+	"gin-user-app/apperrors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testRules adalah rule set untuk test validasi
+var testRules = RuleSet{
+	{Field: "username", Rules: []Rule{Required(), String(), Length(3, 5)}},
+	{Field: "nick_name", Rules: []Rule{String(), MinLength(3)}},
+	{Field: "age", Rules: []Rule{Integer(), GreaterThan(15)}},
+}
+
+// TestRuleSet_ReportsAllFieldsUnit menguji bahwa semua field yang gagal dilaporkan, satu error per field
+func TestRuleSet_ReportsAllFieldsUnit(t *testing.T) {
+	err := testRules.Validate(map[string]interface{}{"username": nil, "nick_name": 5, "age": 15.5})
+	assert.ErrorIs(t, err, apperrors.ErrValidation)
+	assert.EqualError(t, err, "username is required; nick name must be a string; age must be an integer")
+	assert.Equal(t, []apperrors.FieldError{
+		{Field: "username", Code: "required", Message: "username is required"},
+		{Field: "nick_name", Code: "type", Message: "nick name must be a string", Params: map[string]interface{}{"type": "string"}},
+		{Field: "age", Code: "integer", Message: "age must be an integer"},
+	}, apperrors.Fields(err))
+}
+
+// TestRuleSet_SkipsAbsentAndEmptyFieldsUnit menguji bahwa field yang tidak ada atau kosong hanya dicek oleh Required
+func TestRuleSet_SkipsAbsentAndEmptyFieldsUnit(t *testing.T) {
+	var noAge *int
+	age := 20
+	assert.NoError(t, testRules.Validate(map[string]interface{}{"nick_name": "", "age": noAge}))
+	assert.NoError(t, testRules.Validate(map[string]interface{}{"username": "alice", "age": &age}))
+
+	err := testRules.Validate(map[string]interface{}{"username": "alexander", "age": 10})
+	assert.EqualError(t, err, "username must be 3-5 characters; age must be greater than 15")
+}
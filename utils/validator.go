@@ -9,7 +9,6 @@ const (
 	NameMinLength      = 3
 	NameMaxLength      = 20
 	AgeMin             = 15
-	EmailGmailSuffix   = "@gmail.com"
 	EmailRequired      = true
 )
 